@@ -0,0 +1,171 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+)
+
+// maxArchiveFileSize is the size limit of the files of archives, which are
+// read in memory.
+const maxArchiveFileSize = 64 << 20
+
+// isArchive reports whether filename names an archive accepted in place of
+// a target directory: a zip file, such as the module zips served by module
+// proxies, or a tarball, possibly gzip-compressed.
+func isArchive(filename string) bool {
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(filename, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveDir is a directory of an archive holding .go files, documented in
+// memory with extract.ParseFS.
+type archiveDir struct {
+	FS  fs.FS
+	Dir string // slash-separated path in FS
+}
+
+// OpenArchive reads the archive filename in memory and returns its files.
+// The version prefix of module zips, "path@version/", is kept. Files larger
+// than maxArchiveFileSize are rejected.
+func OpenArchive(filename string) (fs.FS, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var fsys fs.FS
+	if strings.HasSuffix(filename, ".zip") {
+		fsys, err = readZip(data)
+	} else {
+		var r io.Reader = bytes.NewReader(data)
+		if !strings.HasSuffix(filename, ".tar") {
+			if r, err = gzip.NewReader(r); err != nil {
+				return nil, fmt.Errorf("%s: %s", filename, err)
+			}
+		}
+		fsys, err = readTar(r)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return fsys, nil
+}
+
+// readZip returns the files of a zip archive, which are decompressed as
+// they are read.
+func readZip(data []byte) (*zip.Reader, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range r.File {
+		if f.UncompressedSize64 > maxArchiveFileSize {
+			return nil, fmt.Errorf("file %s of archive is larger than %d bytes", f.Name, maxArchiveFileSize)
+		}
+	}
+	return r, nil
+}
+
+// readTar reads the regular files of a tarball in memory. Links and other
+// special files are skipped.
+func readTar(r io.Reader) (fstest.MapFS, error) {
+	fsys := fstest.MapFS{}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return fsys, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := strings.TrimPrefix(header.Name, "./")
+		if !fs.ValidPath(name) {
+			return nil, fmt.Errorf("invalid file name %s in archive", header.Name)
+		}
+		if header.Size > maxArchiveFileSize {
+			return nil, fmt.Errorf("file %s of archive is larger than %d bytes", name, maxArchiveFileSize)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		fsys[name] = &fstest.MapFile{Data: data, Mode: 0644}
+	}
+}
+
+// archiveDirectories returns the directories of fsys holding .go files, as
+// selected by rules, named after their path below filename.
+func archiveDirectories(filename string, fsys fs.FS, rules WalkRules) (map[string]*archiveDir, []string, error) {
+	dirs := map[string]*archiveDir{}
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != "." && (rules.skip(d.Name()) || rules.excludes(".", filepath.FromSlash(p))) {
+			return fs.SkipDir
+		}
+		entries, err := fs.ReadDir(fsys, p)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				name := filepath.Join(filename, filepath.FromSlash(p))
+				dirs[name] = &archiveDir{FS: fsys, Dir: p}
+				names = append(names, name)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	return dirs, names, nil
+}
+
+// expandArchives replaces the archives of args, see isArchive, with the
+// directories of their packages selected by rules, named after their path
+// below the archive, and returns the archive directories by name.
+func expandArchives(args []string, rules WalkRules) ([]string, map[string]*archiveDir, error) {
+	var expanded []string
+	archives := map[string]*archiveDir{}
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err != nil || info.IsDir() || !isArchive(arg) {
+			expanded = append(expanded, arg)
+			continue
+		}
+		fsys, err := OpenArchive(arg)
+		if err != nil {
+			return nil, nil, err
+		}
+		dirs, names, err := archiveDirectories(arg, fsys, rules)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, dir := range dirs {
+			archives[name] = dir
+		}
+		expanded = append(expanded, names...)
+	}
+	return expanded, archives, nil
+}
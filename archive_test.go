@@ -0,0 +1,116 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// moduleFiles are the files of a module zip, below its version prefix.
+var moduleFiles = map[string]string{
+	"example.com/m@v1.0.0/go.mod":   "module example.com/m\n",
+	"example.com/m@v1.0.0/p/p.go":   "// Package p is documented.\npackage p\n\n// F does nothing.\nfunc F() {}\n",
+	"example.com/m@v1.0.0/README":   "m\n",
+	"example.com/m@v1.0.0/_x/x.go":  "package x\n",
+	"example.com/m@v1.0.0/q/doc.go": "// Package q is documented.\npackage q\n",
+}
+
+func writeZip(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	if err := os.WriteFile(name, zipFiles(t, files), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTarball(t *testing.T, name string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for filename, src := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: "./" + filename, Mode: 0644, Size: int64(len(src)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(tw, src)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDocumentArchives documents a module zip and a tarball of the same
+// files, which must be read in memory and keep the paths of the archive.
+func TestDocumentArchives(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheDir)
+	t.Setenv("HOME", cacheDir)
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "m.zip"), moduleFiles)
+	writeTarball(t, filepath.Join(dir, "m.tgz"), moduleFiles)
+
+	for _, name := range []string{"m.zip", "m.tgz"} {
+		t.Run(name, func(t *testing.T) {
+			archive := filepath.Join(dir, name)
+			args, archives, err := expandArchives([]string{archive}, WalkRules{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{
+				filepath.Join(archive, "example.com", "m@v1.0.0", "p"),
+				filepath.Join(archive, "example.com", "m@v1.0.0", "q"),
+			}
+			if strings.Join(args, " ") != strings.Join(want, " ") {
+				t.Fatalf("got directories %q, want %q", args, want)
+			}
+			var pkgs []*extract.Package
+			out := &outputTarget{Format: "json", Write: func(w io.Writer, pkg *extract.Package) error {
+				pkgs = append(pkgs, pkg)
+				return nil
+			}}
+			if err := documentDirectories(args, archives, extract.Options{}, out, 2, nil, nil); err != nil {
+				t.Fatal(err)
+			}
+			if len(pkgs) != 2 {
+				t.Fatalf("got %d packages, want 2", len(pkgs))
+			}
+			if got := extract.PackagePath(pkgs[0]); got != "example.com/m/p" {
+				t.Errorf("got import path %q, want example.com/m/p", got)
+			}
+			if got := strings.Join(pkgs[0].Filenames, " "); got != "example.com/m@v1.0.0/p/p.go" {
+				t.Errorf("got filenames %q, want the path in the archive", got)
+			}
+		})
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) > 0 {
+		t.Errorf("%s was written to", cacheDir)
+	}
+}
+
+func TestOversizedArchiveFile(t *testing.T) {
+	// Only the header is needed to reject the file.
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "p/p.go", Mode: 0644, Size: maxArchiveFileSize + 1, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "big.tar")
+	if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenArchive(name); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("got error %v, want the file to be rejected", err)
+	}
+}
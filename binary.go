@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// genericValue converts v to the generic representation produced by
+// decoding its JSON encoding, so that binary encoders use the same field
+// names as the JSON output. Numbers are kept as json.Number.
+func genericValue(v interface{}) (interface{}, error) {
+	vJSON, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(vJSON))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// binaryEncoder encodes the generic representation of a JSON value.
+type binaryEncoder interface {
+	encodeNil()
+	encodeBool(b bool)
+	encodeInt(i int64)
+	encodeFloat(f float64)
+	encodeString(s string)
+	encodeArrayHeader(n int)
+	encodeMapHeader(n int)
+}
+
+// encodeGeneric walks a generic value and feeds it to enc. Map keys are
+// sorted so the output is deterministic.
+func encodeGeneric(enc binaryEncoder, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		enc.encodeNil()
+	case bool:
+		enc.encodeBool(v)
+	case string:
+		enc.encodeString(v)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			enc.encodeInt(i)
+		} else if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+			enc.encodeFloat(f)
+		} else {
+			return fmt.Errorf("invalid number %s", v)
+		}
+	case []interface{}:
+		enc.encodeArrayHeader(len(v))
+		for _, item := range v {
+			if err := encodeGeneric(enc, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		enc.encodeMapHeader(len(v))
+		for _, k := range keys {
+			enc.encodeString(k)
+			if err := encodeGeneric(enc, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot encode %T", v)
+	}
+	return nil
+}
+
+// binaryFormatter returns a formatter encoding packages with the encoder
+// returned by newEncoder.
+func binaryFormatter(newEncoder func(w *bufio.Writer) binaryEncoder) func(opts *outputOptions) formatter {
+	return func(opts *outputOptions) formatter {
+		return func(w io.Writer, pkg *extract.Package) error {
+			generic, err := genericValue(pkg)
+			if err != nil {
+				return err
+			}
+			bw := bufio.NewWriter(w)
+			if err := encodeGeneric(newEncoder(bw), generic); err != nil {
+				return err
+			}
+			return bw.Flush()
+		}
+	}
+}
+
+// msgpackEncoder writes MessagePack (https://msgpack.org/).
+type msgpackEncoder struct {
+	w *bufio.Writer
+}
+
+func newMsgpackEncoder(w *bufio.Writer) binaryEncoder {
+	return msgpackEncoder{w}
+}
+
+func (e msgpackEncoder) encodeNil() {
+	e.w.WriteByte(0xc0)
+}
+
+func (e msgpackEncoder) encodeBool(b bool) {
+	if b {
+		e.w.WriteByte(0xc3)
+	} else {
+		e.w.WriteByte(0xc2)
+	}
+}
+
+func (e msgpackEncoder) encodeInt(i int64) {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		e.w.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		e.w.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		e.w.Write([]byte{0xcc, byte(i)})
+	case i >= 0 && i <= math.MaxUint16:
+		e.header(0xcd, 2, uint64(i))
+	case i >= 0 && i <= math.MaxUint32:
+		e.header(0xce, 4, uint64(i))
+	case i >= 0:
+		e.header(0xcf, 8, uint64(i))
+	case i >= math.MinInt8:
+		e.w.Write([]byte{0xd0, byte(int8(i))})
+	case i >= math.MinInt16:
+		e.header(0xd1, 2, uint64(uint16(int16(i))))
+	case i >= math.MinInt32:
+		e.header(0xd2, 4, uint64(uint32(int32(i))))
+	default:
+		e.header(0xd3, 8, uint64(i))
+	}
+}
+
+func (e msgpackEncoder) encodeFloat(f float64) {
+	e.header(0xcb, 8, math.Float64bits(f))
+}
+
+func (e msgpackEncoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		e.w.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		e.w.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		e.header(0xda, 2, uint64(n))
+	default:
+		e.header(0xdb, 4, uint64(n))
+	}
+	e.w.WriteString(s)
+}
+
+func (e msgpackEncoder) encodeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		e.w.WriteByte(0x90 | byte(n))
+	case n <= math.MaxUint16:
+		e.header(0xdc, 2, uint64(n))
+	default:
+		e.header(0xdd, 4, uint64(n))
+	}
+}
+
+func (e msgpackEncoder) encodeMapHeader(n int) {
+	switch {
+	case n < 16:
+		e.w.WriteByte(0x80 | byte(n))
+	case n <= math.MaxUint16:
+		e.header(0xde, 2, uint64(n))
+	default:
+		e.header(0xdf, 4, uint64(n))
+	}
+}
+
+// header writes the type byte b followed by the size bytes big-endian
+// representation of v.
+func (e msgpackEncoder) header(b byte, size int, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	e.w.WriteByte(b)
+	e.w.Write(buf[8-size:])
+}
+
+// cborEncoder writes CBOR (RFC 8949).
+type cborEncoder struct {
+	w *bufio.Writer
+}
+
+func newCBOREncoder(w *bufio.Writer) binaryEncoder {
+	return cborEncoder{w}
+}
+
+const (
+	cborUnsigned = 0 << 5
+	cborNegative = 1 << 5
+	cborText     = 3 << 5
+	cborArray    = 4 << 5
+	cborMap      = 5 << 5
+)
+
+func (e cborEncoder) encodeNil() {
+	e.w.WriteByte(0xf6)
+}
+
+func (e cborEncoder) encodeBool(b bool) {
+	if b {
+		e.w.WriteByte(0xf5)
+	} else {
+		e.w.WriteByte(0xf4)
+	}
+}
+
+func (e cborEncoder) encodeInt(i int64) {
+	if i >= 0 {
+		e.head(cborUnsigned, uint64(i))
+	} else {
+		e.head(cborNegative, uint64(-1-i))
+	}
+}
+
+func (e cborEncoder) encodeFloat(f float64) {
+	var buf [9]byte
+	buf[0] = 0xfb
+	binary.BigEndian.PutUint64(buf[1:], math.Float64bits(f))
+	e.w.Write(buf[:])
+}
+
+func (e cborEncoder) encodeString(s string) {
+	e.head(cborText, uint64(len(s)))
+	e.w.WriteString(s)
+}
+
+func (e cborEncoder) encodeArrayHeader(n int) {
+	e.head(cborArray, uint64(n))
+}
+
+func (e cborEncoder) encodeMapHeader(n int) {
+	e.head(cborMap, uint64(n))
+}
+
+// head writes the initial bytes of a data item of the given major type with
+// argument v, using the shortest encoding.
+func (e cborEncoder) head(major byte, v uint64) {
+	var buf [9]byte
+	switch {
+	case v < 24:
+		e.w.WriteByte(major | byte(v))
+		return
+	case v <= math.MaxUint8:
+		buf[0], buf[1] = major|24, byte(v)
+		e.w.Write(buf[:2])
+	case v <= math.MaxUint16:
+		buf[0] = major | 25
+		binary.BigEndian.PutUint16(buf[1:], uint16(v))
+		e.w.Write(buf[:3])
+	case v <= math.MaxUint32:
+		buf[0] = major | 26
+		binary.BigEndian.PutUint32(buf[1:], uint32(v))
+		e.w.Write(buf[:5])
+	default:
+		buf[0] = major | 27
+		binary.BigEndian.PutUint64(buf[1:], v)
+		e.w.Write(buf[:9])
+	}
+}
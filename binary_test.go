@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncodeGeneric(t *testing.T) {
+	for _, test := range []struct {
+		json, msgpack, cbor string // encodings in hexadecimal
+	}{
+		{`null`, "c0", "f6"},
+		{`true`, "c3", "f5"},
+		{`1`, "01", "01"},
+		{`-1`, "ff", "20"},
+		{`200`, "ccc8", "18c8"},
+		{`-200`, "d1ff38", "38c7"},
+		{`70000`, "ce00011170", "1a00011170"},
+		{`1.5`, "cb3ff8000000000000", "fb3ff8000000000000"},
+		{`"a"`, "a161", "6161"},
+		{`[1, 2]`, "920102", "820102"},
+		{`{"b": 1, "a": 2}`, "82a16102a16201", "a2616102616201"},
+	} {
+		dec := json.NewDecoder(strings.NewReader(test.json))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			t.Fatal(err)
+		}
+		for _, encoding := range []struct {
+			name       string
+			newEncoder func(w *bufio.Writer) binaryEncoder
+			want       string
+		}{
+			{"msgpack", newMsgpackEncoder, test.msgpack},
+			{"cbor", newCBOREncoder, test.cbor},
+		} {
+			var buf bytes.Buffer
+			w := bufio.NewWriter(&buf)
+			if err := encodeGeneric(encoding.newEncoder(w), v); err != nil {
+				t.Fatal(err)
+			}
+			w.Flush()
+			if got := hex.EncodeToString(buf.Bytes()); got != encoding.want {
+				t.Errorf("%s of %s = %s, want %s", encoding.name, test.json, got, encoding.want)
+			}
+		}
+	}
+}
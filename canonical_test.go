@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalNumber(t *testing.T) {
+	tests := []struct {
+		f    float64
+		want string
+	}{
+		{0, "0"},
+		{math.Copysign(0, -1), "0"},
+		{1, "1"},
+		{-10, "-10"},
+		{0.5, "0.5"},
+		{1e20, "100000000000000000000"},
+		{1e21, "1e+21"},
+		{1e-6, "0.000001"},
+		{1e-7, "1e-7"},
+		{333333333.33333329, "333333333.3333333"},
+		{5e-324, "5e-324"},
+		{1.7976931348623157e308, "1.7976931348623157e+308"},
+		{9007199254740992, "9007199254740992"},
+	}
+	for _, test := range tests {
+		if got := canonicalNumber(test.f); got != test.want {
+			t.Errorf("canonicalNumber(%v) = %q, want %q", test.f, got, test.want)
+		}
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "null"},
+		{[]interface{}{true, false, 1.5, "a"}, `[true,false,1.5,"a"]`},
+		{map[string]interface{}{"b": 1, "a": map[string]int{"d": 2, "c": 3}}, `{"a":{"c":3,"d":2},"b":1}`},
+		// Keys sort by UTF-16 code units: U+1F600 (D83D DE00) before U+FB33.
+		{map[string]int{"דּ": 1, "\U0001f600": 2, "é": 3, "1": 4}, "{\"1\":4,\"é\":3,\"\U0001f600\":2,\"דּ\":1}"},
+		{"<&> \x01\t\"\\", "\"<&> \\u0001\\t\\\"\\\\\""},
+		{struct {
+			Name string `json:"name"`
+			Age  int    `json:"age,omitempty"`
+		}{Name: "x"}, `{"name":"x"}`},
+	}
+	for _, test := range tests {
+		got, err := marshalCanonical(test.v)
+		if err != nil {
+			t.Errorf("marshalCanonical(%#v): %s", test.v, err)
+			continue
+		}
+		if string(got) != test.want {
+			t.Errorf("marshalCanonical(%#v) = %s, want %s", test.v, got, test.want)
+		}
+	}
+}
+
+func TestWriteJSONCanonical(t *testing.T) {
+	var b strings.Builder
+	if err := writeJSON(&b, map[string]interface{}{"b": []int{1}, "a": "<"}, &outputOptions{Indent: "  ", Canonical: true}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":\"<\",\"b\":[1]}\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
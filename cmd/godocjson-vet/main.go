@@ -0,0 +1,14 @@
+// Command godocjson-vet runs the documentation checks of godocjson under
+// go vet:
+//
+//	go vet -vettool=$(which godocjson-vet) ./...
+package main
+
+import (
+	"github.com/rtfd/godocjson/doclint"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(doclint.Analyzers...)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// Collision is an exported identifier declared by several packages of a
+// module.
+type Collision struct {
+	Module  string             `json:"module,omitempty"` // empty for packages outside of modules
+	Name    string             `json:"name"`
+	Symbols []*CollidingSymbol `json:"symbols"`
+}
+
+// CollidingSymbol is the declaration of a colliding identifier in one
+// package.
+type CollidingSymbol struct {
+	Kind       string `json:"kind"` // "const", "var", "type" or "func"
+	ImportPath string `json:"importPath"`
+	Filename   string `json:"filename"`
+	Line       int    `json:"line"`
+}
+
+// collisionIndex collects the exported identifiers of the packages of a
+// run by module and name.
+type collisionIndex struct {
+	symbols map[[2]string][]*CollidingSymbol
+}
+
+func newCollisionIndex() *collisionIndex {
+	return &collisionIndex{symbols: map[[2]string][]*CollidingSymbol{}}
+}
+
+// add records the package-level exported identifiers of pkg, documented
+// from directory. Methods are qualified by their type and never collide;
+// external test packages are ignored.
+func (idx *collisionIndex) add(directory string, pkg *extract.Package) {
+	if strings.HasSuffix(pkg.Name, "_test") {
+		return
+	}
+	_, module := extract.FindModule(directory)
+	importPath := extract.ImportPathOf(directory)
+	extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+		if entry.Kind == "package" || entry.Kind == "method" || !ast.IsExported(entry.Name) {
+			return
+		}
+		key := [2]string{module, entry.Name}
+		idx.symbols[key] = append(idx.symbols[key], &CollidingSymbol{
+			Kind:       entry.Kind,
+			ImportPath: importPath,
+			Filename:   entry.Filename,
+			Line:       entry.Line,
+		})
+	})
+}
+
+// Collisions returns the identifiers declared by more than one package of
+// the same module, sorted by module and name.
+func (idx *collisionIndex) Collisions() []*Collision {
+	collisions := []*Collision{}
+	for key, symbols := range idx.symbols {
+		packages := map[string]bool{}
+		for _, s := range symbols {
+			packages[s.ImportPath] = true
+		}
+		if len(packages) < 2 {
+			continue
+		}
+		sort.Slice(symbols, func(i, j int) bool { return symbols[i].ImportPath < symbols[j].ImportPath })
+		collisions = append(collisions, &Collision{Module: key[0], Name: key[1], Symbols: symbols})
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		a, b := collisions[i], collisions[j]
+		if a.Module != b.Module {
+			return a.Module < b.Module
+		}
+		return a.Name < b.Name
+	})
+	return collisions
+}
+
+// writeCollisions writes the collisions of idx as a JSON array.
+func writeCollisions(w io.Writer, idx *collisionIndex) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(idx.Collisions())
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// defaultConfigFile is the configuration file read from the current
+// directory when no -config flag is given.
+const defaultConfigFile = ".godocjson.json"
+
+// Config is the content of a godocjson configuration file.
+type Config struct {
+	// Layers declares the architecture layers checked by the imports
+	// subcommand.
+	Layers []*Layer `json:"layers"`
+}
+
+// Layer is a named group of packages, which may only import packages of
+// the same layer and of the layers listed in MayImport. Imports of
+// packages outside of any layer are not restricted.
+type Layer struct {
+	Name string `json:"name"`
+	// Packages lists import paths; a path ending in "/..." also matches
+	// every package below it.
+	Packages  []string `json:"packages"`
+	MayImport []string `json:"mayImport"`
+}
+
+// LoadConfig reads the configuration file name. An empty name reads
+// defaultConfigFile if it exists, and returns an empty configuration
+// otherwise.
+func LoadConfig(name string) (*Config, error) {
+	config := &Config{}
+	if name == "" {
+		name = defaultConfigFile
+		if _, err := os.Stat(name); os.IsNotExist(err) {
+			return config, nil
+		}
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return config, nil
+}
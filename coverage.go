@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rtfd/godocjson/doclint"
+	"github.com/rtfd/godocjson/extract"
+)
+
+// coverageKinds are the kinds of symbols counted by coverage reports, in
+// the order they are written.
+var coverageKinds = []string{"package", "const", "var", "type", "func", "method"}
+
+// CoverageCount counts the documented symbols among a set of symbols.
+type CoverageCount struct {
+	Documented int     `json:"documented"`
+	Total      int     `json:"total"`
+	Percent    float64 `json:"percent"` // 100 for an empty set
+}
+
+func (c *CoverageCount) add(documented bool) {
+	c.Total++
+	if documented {
+		c.Documented++
+	}
+}
+
+func (c *CoverageCount) setPercent() {
+	c.Percent = 100
+	if c.Total > 0 {
+		c.Percent = float64(c.Documented) * 100 / float64(c.Total)
+	}
+}
+
+// PackageCoverage is the documentation coverage of a package.
+type PackageCoverage struct {
+	ImportPath string `json:"importPath"`
+	CoverageCount
+	Kinds        map[string]*CoverageCount `json:"kinds"`        // by symbol kind, see coverageKinds
+	Undocumented []string                  `json:"undocumented"` // names of the symbols without doc comment, methods named Type.Method
+}
+
+// CoverageReport is the documentation coverage of a set of packages: the
+// share of their exported symbols, and of the packages themselves, having
+// a doc comment.
+type CoverageReport struct {
+	CoverageCount
+	Kinds    map[string]*CoverageCount `json:"kinds"`
+	Packages []*PackageCoverage        `json:"packages"`
+}
+
+// NewCoverageReport returns the documentation coverage of pkgs, whose
+// symbols are documented as checked by doclint: constants and variables
+// count once per name, documented by the comment of their spec or of its
+// group.
+func NewCoverageReport(pkgs []*lintPackage) *CoverageReport {
+	r := &CoverageReport{Kinds: map[string]*CoverageCount{}, Packages: []*PackageCoverage{}}
+	for _, kind := range coverageKinds {
+		r.Kinds[kind] = &CoverageCount{}
+	}
+	for _, pkg := range pkgs {
+		pc := &PackageCoverage{ImportPath: pkg.ImportPath, Kinds: map[string]*CoverageCount{}, Undocumented: []string{}}
+		for _, kind := range coverageKinds {
+			pc.Kinds[kind] = &CoverageCount{}
+		}
+		for _, symbol := range pkg.Symbols {
+			documented := true
+			for _, f := range doclint.Check(symbol) {
+				if f.Rule == doclint.MissingDoc {
+					documented = false
+				}
+			}
+			pc.add(documented)
+			pc.Kinds[symbol.Kind].add(documented)
+			r.add(documented)
+			r.Kinds[symbol.Kind].add(documented)
+			if !documented {
+				pc.Undocumented = append(pc.Undocumented, symbol.Name)
+			}
+		}
+		pc.setPercent()
+		for _, c := range pc.Kinds {
+			c.setPercent()
+		}
+		r.Packages = append(r.Packages, pc)
+	}
+	sort.SliceStable(r.Packages, func(i, j int) bool { return r.Packages[i].ImportPath < r.Packages[j].ImportPath })
+	r.setPercent()
+	for _, c := range r.Kinds {
+		c.setPercent()
+	}
+	return r
+}
+
+// writeText writes r as tables of the coverage of each package and kind,
+// followed by the undocumented symbols if verbose.
+func (r *CoverageReport) writeText(w io.Writer, verbose bool) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "PACKAGE\tDOCUMENTED\tCOVERAGE\n")
+	for _, pc := range r.Packages {
+		fmt.Fprintf(tw, "%s\t%d/%d\t%.1f%%\n", pc.ImportPath, pc.Documented, pc.Total, pc.Percent)
+	}
+	fmt.Fprintf(tw, "total\t%d/%d\t%.1f%%\n", r.Documented, r.Total, r.Percent)
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintf(tw, "KIND\tDOCUMENTED\tCOVERAGE\n")
+	for _, kind := range coverageKinds {
+		if c := r.Kinds[kind]; c.Total > 0 {
+			fmt.Fprintf(tw, "%s\t%d/%d\t%.1f%%\n", kind, c.Documented, c.Total, c.Percent)
+		}
+	}
+	tw.Flush()
+	if !verbose || r.Documented == r.Total {
+		return
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Undocumented:")
+	for _, pc := range r.Packages {
+		for _, name := range pc.Undocumented {
+			fmt.Fprintf(w, "  %s.%s\n", pc.ImportPath, name)
+		}
+	}
+}
+
+// runCoverage implements the coverage subcommand.
+func runCoverage(args []string) int {
+	flags := flag.NewFlagSet("coverage", flag.ExitOnError)
+	format := flags.String("format", "text", "Report format: text or json")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	minPercent := flags.Float64("min", 0, "Fail if the total coverage is below this percentage")
+	verbose := flags.Bool("v", false, "List the undocumented symbols in the text report")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson coverage [-format text|json] [-min percent] [-v] [-e pattern] directory...")
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "unknown report format %q\n", *format)
+		return 2
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var pkgs []*lintPackage
+	for _, directory := range directories {
+		_, dirPkgs, err := lintPackages(directory, fileFilter)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		pkgs = append(pkgs, dirPkgs...)
+	}
+	report := NewCoverageReport(pkgs)
+
+	if *format == "json" {
+		reportJSON, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Printf("%s\n", reportJSON)
+	} else {
+		report.writeText(os.Stdout, *verbose)
+	}
+	if report.Percent < *minPercent {
+		fmt.Fprintf(os.Stderr, "documentation coverage %.1f%% is below %.1f%%\n", report.Percent, *minPercent)
+		return 1
+	}
+	return 0
+}
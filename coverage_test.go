@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/rtfd/godocjson/doclint"
+)
+
+const coverageSource = `// Package p is documented.
+package p
+
+// Grouped constants.
+const (
+	// A is documented on its own line.
+	A = 1
+	B = 2
+)
+
+const (
+	// C is documented.
+	C = 3
+	D = 4
+)
+
+// F is documented.
+func F() {}
+
+func G() {}
+
+type T int
+
+// M is documented.
+func (T) M() {}
+`
+
+func TestCoverageMatchesLint(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(coverageSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, pkgs, err := lintPackages(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewCoverageReport(pkgs)
+	findings, err := LintDirectory(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var missing []string
+	for _, f := range findings {
+		if f.Rule == doclint.MissingDoc {
+			missing = append(missing, f.Name)
+		}
+	}
+	want := []string{"D", "G", "T"}
+	if len(r.Packages) != 1 || !slices.Equal(r.Packages[0].Undocumented, want) || !slices.Equal(missing, want) {
+		t.Fatalf("got undocumented %+v and lint findings %v, want %v", r.Packages[0].Undocumented, missing, want)
+	}
+	if r.Total != 9 || r.Documented != 6 {
+		t.Errorf("got %d/%d documented, want 6/9", r.Documented, r.Total)
+	}
+	if c := r.Kinds["const"]; c.Total != 4 || c.Documented != 3 {
+		t.Errorf("got %d/%d constants documented, want 3/4", c.Documented, c.Total)
+	}
+}
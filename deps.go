@@ -0,0 +1,59 @@
+package main
+
+import (
+	"go/build"
+	"path/filepath"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// AddDependencies returns the directories of the packages imported by the
+// packages of directories, up to depth levels of imports: 1 adds the direct
+// dependencies, and a negative depth all transitive dependencies.
+// Dependencies are resolved like the go command does, through the module
+// cache, with the import context of opts; standard library packages are
+// not added. Dependencies that cannot be resolved are reported as warnings
+// and skipped.
+func AddDependencies(directories []string, depth int, opts extract.Options) []string {
+	ctx := opts.ImportContext()
+	seen := map[string]bool{}
+	for _, directory := range directories {
+		if abs, err := filepath.Abs(directory); err == nil {
+			seen[abs] = true
+		}
+	}
+	var all []string
+	level := directories
+	for ; depth != 0 && len(level) > 0; depth-- {
+		var next []string
+		for _, directory := range level {
+			abs, err := filepath.Abs(directory)
+			if err != nil {
+				continue
+			}
+			bp, err := ctx.ImportDir(abs, 0)
+			if err != nil {
+				// Reported when the directory is documented.
+				continue
+			}
+			for _, importPath := range bp.Imports {
+				if importPath == "C" || extract.IsStdImportPath(importPath) {
+					continue
+				}
+				dep, err := ctx.Import(importPath, bp.Dir, build.FindOnly)
+				if err != nil {
+					skipf(1, "cannot resolve %s imported by %s: %s", importPath, directory, err)
+					continue
+				}
+				if seen[dep.Dir] {
+					continue
+				}
+				seen[dep.Dir] = true
+				next = append(next, dep.Dir)
+			}
+		}
+		all = append(all, next...)
+		level = next
+	}
+	return all
+}
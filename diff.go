@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// SymbolChange is a difference between two versions of an exported symbol.
+type SymbolChange struct {
+	Change  string `json:"change"`  // "added", "removed", "signature" or "doc"
+	Package string `json:"package"` // import path
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Old     string `json:"old,omitempty"` // previous signature or doc
+	New     string `json:"new,omitempty"` // new signature or doc
+
+	// Breaking is true for changes that may break code using the old
+	// version: removed symbols, changed signatures, and methods added to
+	// interfaces.
+	Breaking bool `json:"breaking"`
+}
+
+// SemverReport classifies the changes between two versions for semantic
+// versioning.
+type SemverReport struct {
+	Bump       string          `json:"bump"` // "major", "minor", "patch" or "none"
+	Breaking   []*SymbolChange `json:"breaking"`
+	Compatible []*SymbolChange `json:"compatible"`
+}
+
+// NewSemverReport classifies changes, as returned by DiffPackages.
+func NewSemverReport(changes []*SymbolChange) *SemverReport {
+	r := &SemverReport{Bump: "none", Breaking: []*SymbolChange{}, Compatible: []*SymbolChange{}}
+	for _, c := range changes {
+		if c.Breaking {
+			r.Breaking = append(r.Breaking, c)
+		} else {
+			r.Compatible = append(r.Compatible, c)
+		}
+	}
+	switch {
+	case len(r.Breaking) > 0:
+		r.Bump = "major"
+	case len(r.Compatible) > 0:
+		// Only documentation changes do not extend the API.
+		r.Bump = "patch"
+		for _, c := range r.Compatible {
+			if c.Change == "added" {
+				r.Bump = "minor"
+			}
+		}
+	}
+	return r
+}
+
+// readDocuments reads every package document in the file name.
+func readDocuments(name string) ([]*extract.Package, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var pkgs []*extract.Package
+	dec := json.NewDecoder(f)
+	for {
+		var pkg extract.Package
+		if err := dec.Decode(&pkg); err == io.EOF {
+			return pkgs, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("%s: %s", name, err)
+		}
+		pkgs = append(pkgs, &pkg)
+	}
+}
+
+// diffSymbol is an exported symbol as compared by DiffPackages. The
+// exported fields of struct types and the methods of interface types are
+// symbols of their own, named like "T.Field", whose parent is the key of
+// their type.
+type diffSymbol struct {
+	kind, name, signature, doc string
+	parent                     string
+	addBreaks                  bool // adding the symbol breaks code, e.g. implementations of an interface
+}
+
+// exportedSymbols returns the exported symbols of pkg by kind and name.
+func exportedSymbols(pkg *extract.Package) map[string]*diffSymbol {
+	symbols := map[string]*diffSymbol{}
+	extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+		if entry.Kind == "package" {
+			return
+		}
+		for _, part := range strings.Split(entry.Name, ".") {
+			if !ast.IsExported(part) {
+				return
+			}
+		}
+		s := &diffSymbol{kind: entry.Kind, name: entry.Name}
+		switch symbol := symbol.(type) {
+		case *extract.Func:
+			s.doc = symbol.Doc
+			s.signature = funcSignatureOf(symbol)
+		case *extract.Type:
+			s.doc = symbol.Doc
+			// Turning a type into an alias, or changing the aliased type,
+			// changes its identity and method set.
+			if symbol.IsAlias {
+				s.signature = "type " + symbol.Name + " = " + symbol.AliasOf
+			} else {
+				s.signature = typeSignature(symbol, symbols, entry.Kind+" "+entry.Name)
+			}
+		case *extract.Value:
+			s.doc = symbol.Doc
+		}
+		symbols[entry.Kind+" "+entry.Name] = s
+	})
+	return symbols
+}
+
+// typeSignature returns the signature of the type t, declared as type T
+// followed by its underlying type, e.g. "type T int". Struct and interface
+// types are only declared with their kind: their exported fields, embedded
+// types and methods are added to symbols, with parent as their parent.
+func typeSignature(t *extract.Type, symbols map[string]*diffSymbol, parent string) string {
+	if t.Underlying == "" {
+		// Documents that predate the underlying type.
+		return ""
+	}
+	x, err := parser.ParseExpr(t.Underlying)
+	if err != nil {
+		return "type " + t.Name + " " + oneLine(t.Underlying)
+	}
+	add := func(kind, name, signature string, addBreaks bool) {
+		s := &diffSymbol{kind: kind, name: t.Name + "." + name, signature: signature, parent: parent, addBreaks: addBreaks}
+		symbols[kind+" "+s.name] = s
+	}
+	switch x := x.(type) {
+	case *ast.StructType:
+		for _, field := range x.Fields.List {
+			typ := types.ExprString(field.Type)
+			if len(field.Names) == 0 {
+				name := embeddedName(field.Type)
+				if ast.IsExported(name) {
+					add("field", name, typ, false)
+				}
+			}
+			for _, name := range field.Names {
+				if ast.IsExported(name.Name) {
+					add("field", name.Name, name.Name+" "+typ, false)
+				}
+			}
+		}
+		return "type " + t.Name + " struct"
+	case *ast.InterfaceType:
+		// Adding methods or embedded types to an interface breaks its
+		// implementations.
+		for _, method := range x.Methods.List {
+			if len(method.Names) == 0 {
+				typ := types.ExprString(method.Type)
+				add("embedded", typ, typ, true)
+			}
+			for _, name := range method.Names {
+				if ast.IsExported(name.Name) {
+					add("method", name.Name, name.Name+strings.TrimPrefix(types.ExprString(method.Type), "func"), true)
+				}
+			}
+		}
+		return "type " + t.Name + " interface"
+	}
+	return "type " + t.Name + " " + types.ExprString(x)
+}
+
+// embeddedName returns the name of the field embedding the type x, e.g.
+// "Reader" for *io.Reader.
+func embeddedName(x ast.Expr) string {
+	for {
+		switch t := x.(type) {
+		case *ast.StarExpr:
+			x = t.X
+		case *ast.IndexExpr:
+			x = t.X
+		case *ast.IndexListExpr:
+			x = t.X
+		case *ast.SelectorExpr:
+			return t.Sel.Name
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// funcSignatureOf returns the signature of f, rebuilding it from the
+// parameter and result types for documents that predate the signature
+// field.
+func funcSignatureOf(f *extract.Func) string {
+	if f.Signature != "" {
+		return oneLine(f.Signature)
+	}
+	types := func(params []extract.FuncParam) string {
+		strs := make([]string, len(params))
+		for i, p := range params {
+			strs[i] = p.Type
+		}
+		return strings.Join(strs, ", ")
+	}
+	return fmt.Sprintf("func %s(%s) (%s)", f.Name, types(f.Params), types(f.Results))
+}
+
+// DiffPackages returns the changes to the exported symbols between the old
+// and new versions of the packages. Packages are matched by import path,
+// or paired directly when both sides hold a single package.
+func DiffPackages(oldPkgs, newPkgs []*extract.Package) []*SymbolChange {
+	byPath := func(pkgs []*extract.Package) map[string]*extract.Package {
+		m := map[string]*extract.Package{}
+		for _, pkg := range pkgs {
+			m[pkg.ImportPath] = pkg
+		}
+		return m
+	}
+	oldByPath, newByPath := byPath(oldPkgs), byPath(newPkgs)
+	if len(oldPkgs) == 1 && len(newPkgs) == 1 {
+		oldByPath = map[string]*extract.Package{newPkgs[0].ImportPath: oldPkgs[0]}
+	}
+
+	paths := map[string]bool{}
+	for p := range oldByPath {
+		paths[p] = true
+	}
+	for p := range newByPath {
+		paths[p] = true
+	}
+	changes := []*SymbolChange{}
+	for p := range paths {
+		oldSymbols, newSymbols := map[string]*diffSymbol{}, map[string]*diffSymbol{}
+		if pkg := oldByPath[p]; pkg != nil {
+			oldSymbols = exportedSymbols(pkg)
+		}
+		if pkg := newByPath[p]; pkg != nil {
+			newSymbols = exportedSymbols(pkg)
+		}
+		for key, o := range oldSymbols {
+			n, ok := newSymbols[key]
+			if !ok && o.parent != "" && newSymbols[o.parent] == nil {
+				// Reported with its type.
+				continue
+			}
+			if !ok {
+				changes = append(changes, &SymbolChange{Change: "removed", Package: p, Kind: o.kind, Name: o.name, Old: o.signature})
+				continue
+			}
+			if o.signature != n.signature {
+				changes = append(changes, &SymbolChange{Change: "signature", Package: p, Kind: o.kind, Name: o.name, Old: o.signature, New: n.signature})
+			}
+			if o.doc != n.doc {
+				changes = append(changes, &SymbolChange{Change: "doc", Package: p, Kind: o.kind, Name: o.name, Old: o.doc, New: n.doc})
+			}
+		}
+		for key, n := range newSymbols {
+			if _, ok := oldSymbols[key]; ok || n.parent != "" && oldSymbols[n.parent] == nil {
+				continue
+			}
+			changes = append(changes, &SymbolChange{Change: "added", Package: p, Kind: n.kind, Name: n.name, New: n.signature, Breaking: n.addBreaks})
+		}
+	}
+	for _, c := range changes {
+		c.Breaking = c.Breaking || c.Change == "removed" || c.Change == "signature"
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Change < b.Change
+	})
+	return changes
+}
+
+// writeChanges writes changes in a human-readable form.
+func writeChanges(w io.Writer, changes []*SymbolChange) {
+	for _, c := range changes {
+		switch c.Change {
+		case "added":
+			fmt.Fprintf(w, "+ %s: %s %s\n", c.Package, c.Kind, c.Name)
+		case "removed":
+			fmt.Fprintf(w, "- %s: %s %s\n", c.Package, c.Kind, c.Name)
+		default:
+			fmt.Fprintf(w, "~ %s: %s %s: %s changed\n", c.Package, c.Kind, c.Name, c.Change)
+			fmt.Fprintf(w, "    - %s\n", strings.Replace(strings.TrimSpace(c.Old), "\n", "\n      ", -1))
+			fmt.Fprintf(w, "    + %s\n", strings.Replace(strings.TrimSpace(c.New), "\n", "\n      ", -1))
+		}
+	}
+}
+
+// writeSemverReport writes r in a human-readable form.
+func writeSemverReport(w io.Writer, r *SemverReport) {
+	if len(r.Breaking) > 0 {
+		fmt.Fprintln(w, "Incompatible changes:")
+		writeChanges(w, r.Breaking)
+		fmt.Fprintln(w)
+	}
+	if len(r.Compatible) > 0 {
+		fmt.Fprintln(w, "Compatible changes:")
+		writeChanges(w, r.Compatible)
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Suggested version bump: %s\n", r.Bump)
+}
+
+// runDiff implements the diff subcommand.
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Write the changes as JSON")
+	semver := flags.Bool("semver", false, "Classify the changes as breaking or compatible, and only fail on breaking changes")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson diff [-json] [-semver] old.json new.json")
+		return 2
+	}
+	oldPkgs, err := readDocuments(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	newPkgs, err := readDocuments(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	changes := DiffPackages(oldPkgs, newPkgs)
+	if *semver {
+		report := NewSemverReport(changes)
+		if *asJSON {
+			reportJSON, _ := json.MarshalIndent(report, "", "  ")
+			fmt.Printf("%s\n", reportJSON)
+		} else {
+			writeSemverReport(os.Stdout, report)
+		}
+		if len(report.Breaking) > 0 {
+			return 1
+		}
+		return 0
+	}
+	if *asJSON {
+		changesJSON, _ := json.MarshalIndent(changes, "", "  ")
+		fmt.Printf("%s\n", changesJSON)
+	} else {
+		writeChanges(os.Stdout, changes)
+	}
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestDiffAliases(t *testing.T) {
+	tests := []struct {
+		old, new string
+		changes  []string // "change name old -> new"
+	}{
+		{"type A = int", "type A = int", nil},
+		{"type A = int", "type A = int64", []string{"signature A type A = int -> type A = int64"}},
+		{"type A int", "type A = int", []string{"signature A type A int -> type A = int"}},
+		{"type A = int", "type A int", []string{"signature A type A = int -> type A int"}},
+	}
+	for _, test := range tests {
+		oldPkg := extractSource(t, "package p\n\n"+test.old+"\n")
+		newPkg := extractSource(t, "package p\n\n"+test.new+"\n")
+		var got []string
+		for _, c := range DiffPackages([]*extract.Package{oldPkg}, []*extract.Package{newPkg}) {
+			got = append(got, c.Change+" "+c.Name+" "+c.Old+" -> "+c.New)
+		}
+		if strings.Join(got, "\n") != strings.Join(test.changes, "\n") {
+			t.Errorf("%s to %s: got changes %q, want %q", test.old, test.new, got, test.changes)
+		}
+	}
+}
+
+func TestDiffTypes(t *testing.T) {
+	for _, test := range []struct {
+		name, old, new string
+		want           []SymbolChange // Package is not compared
+	}{{
+		name: "kind",
+		old:  "package p\n\ntype T struct{ X int }\n",
+		new:  "package p\n\ntype T int\n",
+		want: []SymbolChange{
+			{Change: "signature", Kind: "type", Name: "T", Old: "type T struct", New: "type T int", Breaking: true},
+			{Change: "removed", Kind: "field", Name: "T.X", Old: "X int", Breaking: true},
+		},
+	}, {
+		name: "underlying",
+		old:  "package p\n\ntype T func(int)\n",
+		new:  "package p\n\ntype T func(int, int)\n",
+		want: []SymbolChange{
+			{Change: "signature", Kind: "type", Name: "T", Old: "type T func(int)", New: "type T func(int, int)", Breaking: true},
+		},
+	}, {
+		name: "removed field",
+		old:  "package p\n\ntype T struct {\n\tX, Y int\n\tz  int\n}\n",
+		new:  "package p\n\ntype T struct {\n\tX int\n\ty int\n}\n",
+		want: []SymbolChange{
+			{Change: "removed", Kind: "field", Name: "T.Y", Old: "Y int", Breaking: true},
+		},
+	}, {
+		name: "added field",
+		old:  "package p\n\ntype T struct{ X int }\n",
+		new:  "package p\n\ntype T struct {\n\tX int\n\tY string\n}\n",
+		want: []SymbolChange{
+			{Change: "added", Kind: "field", Name: "T.Y", New: "Y string"},
+		},
+	}, {
+		name: "field type",
+		old:  "package p\n\ntype T struct{ X int }\n",
+		new:  "package p\n\ntype T struct{ X int64 }\n",
+		want: []SymbolChange{
+			{Change: "signature", Kind: "field", Name: "T.X", Old: "X int", New: "X int64", Breaking: true},
+		},
+	}, {
+		name: "added interface method",
+		old:  "package p\n\ntype I interface{ M() }\n",
+		new:  "package p\n\ntype I interface {\n\tM()\n\tN(x int) error\n}\n",
+		want: []SymbolChange{
+			{Change: "added", Kind: "method", Name: "I.N", New: "N(x int) error", Breaking: true},
+		},
+	}, {
+		name: "added type",
+		old:  "package p\n",
+		new:  "package p\n\ntype T struct{ X int }\n",
+		want: []SymbolChange{
+			{Change: "added", Kind: "type", Name: "T", New: "type T struct"},
+		},
+	}} {
+		t.Run(test.name, func(t *testing.T) {
+			changes := DiffPackages([]*extract.Package{extractSource(t, test.old)}, []*extract.Package{extractSource(t, test.new)})
+			if len(changes) != len(test.want) {
+				t.Fatalf("got %d changes, want %d: %+v", len(changes), len(test.want), changes)
+			}
+			for i, c := range changes {
+				got := *c
+				got.Package = ""
+				if got != test.want[i] {
+					t.Errorf("got change %+v, want %+v", got, test.want[i])
+				}
+			}
+			if report := NewSemverReport(changes); (report.Bump == "major") != test.want[0].Breaking {
+				t.Errorf("got bump %s", report.Bump)
+			}
+		})
+	}
+}
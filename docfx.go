@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// DocFXItem is an item of a DocFX managed reference document.
+type DocFXItem struct {
+	UID          string
+	ID           string
+	Parent       string
+	Children     []string
+	Name         string
+	NameWithType string
+	FullName     string
+	Namespace    string // import path of the package
+	Type         string // DocFX item type, e.g. "Namespace", "Class" or "Method"
+	Summary      string
+	Syntax       string
+	Filename     string
+	Line         int
+}
+
+// docfxTypes maps index entry kinds to DocFX item types. Go declarations
+// have no exact counterpart, so types are classes and constants and
+// variables are fields.
+var docfxTypes = map[string]string{
+	"package": "Namespace",
+	"const":   "Field",
+	"var":     "Field",
+	"type":    "Class",
+	"func":    "Method",
+	"method":  "Method",
+}
+
+// BuildDocFXItems returns the DocFX items of pkg: the package, then every
+// symbol, each listed in the children of its package or type.
+func BuildDocFXItems(pkg *extract.Package) []*DocFXItem {
+	importPath := extract.PackagePath(pkg)
+	var items []*DocFXItem
+	byUID := map[string]*DocFXItem{}
+	extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+		item := &DocFXItem{
+			UID:          importPath,
+			ID:           entry.Name,
+			Name:         entry.Name,
+			NameWithType: entry.Name,
+			FullName:     importPath,
+			Namespace:    importPath,
+			Type:         docfxTypes[entry.Kind],
+			Filename:     entry.Filename,
+			Line:         entry.Line,
+		}
+		if entry.Kind != "package" {
+			item.UID += "." + entry.Name
+			item.NameWithType = pkg.Name + "." + entry.Name
+			item.FullName = item.UID
+			item.Parent = importPath
+			if i := strings.IndexByte(entry.Name, '.'); i >= 0 {
+				item.Parent += "." + entry.Name[:i]
+				item.Name = entry.Name[i+1:]
+			}
+		}
+		switch s := symbol.(type) {
+		case *extract.Package:
+			item.Summary = s.Doc
+		case *extract.Type:
+			item.Summary, item.Syntax = s.Doc, s.Source
+			if item.Syntax == "" {
+				item.Syntax = "type " + s.Name
+			}
+		case *extract.Func:
+			item.Summary, item.Syntax = s.Doc, s.Signature
+		case *extract.Value:
+			item.Summary, item.Syntax = s.Doc, s.Source
+			if item.Syntax == "" {
+				item.Syntax = s.Type + " " + entry.Name
+			}
+		}
+		if byUID[item.UID] != nil {
+			// Values declaring several names are visited once per name.
+			return
+		}
+		if parent := byUID[item.Parent]; parent != nil {
+			parent.Children = append(parent.Children, item.UID)
+		}
+		byUID[item.UID] = item
+		items = append(items, item)
+	})
+	return items
+}
+
+// docfxFormatter writes every package as a DocFX managed reference YAML
+// document. Strings are written as double-quoted scalars, encoded like JSON
+// strings.
+func docfxFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		var b strings.Builder
+		b.WriteString("### YamlMime:ManagedReference\nitems:\n")
+		for _, item := range BuildDocFXItems(pkg) {
+			fmt.Fprintf(&b, "- uid: %s\n", yamlString(item.UID))
+			fmt.Fprintf(&b, "  commentId: %s\n", yamlString(item.UID))
+			fmt.Fprintf(&b, "  id: %s\n", yamlString(item.ID))
+			if item.Parent != "" {
+				fmt.Fprintf(&b, "  parent: %s\n", yamlString(item.Parent))
+			}
+			if len(item.Children) > 0 {
+				b.WriteString("  children:\n")
+				for _, child := range item.Children {
+					fmt.Fprintf(&b, "  - %s\n", yamlString(child))
+				}
+			}
+			b.WriteString("  langs:\n  - go\n")
+			fmt.Fprintf(&b, "  name: %s\n", yamlString(item.Name))
+			fmt.Fprintf(&b, "  nameWithType: %s\n", yamlString(item.NameWithType))
+			fmt.Fprintf(&b, "  fullName: %s\n", yamlString(item.FullName))
+			fmt.Fprintf(&b, "  type: %s\n", item.Type)
+			if item.Filename != "" {
+				fmt.Fprintf(&b, "  source:\n    path: %s\n    startLine: %d\n", yamlString(item.Filename), item.Line)
+			}
+			fmt.Fprintf(&b, "  namespace: %s\n", yamlString(item.Namespace))
+			if item.Summary != "" {
+				fmt.Fprintf(&b, "  summary: %s\n", yamlString(item.Summary))
+			}
+			if item.Syntax != "" {
+				fmt.Fprintf(&b, "  syntax:\n    content: %s\n", yamlString(item.Syntax))
+			}
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+}
+
+// yamlString returns s as a YAML double-quoted scalar. The escapes of JSON
+// strings are a subset of those of YAML.
+func yamlString(s string) string {
+	sJSON, _ := json.Marshal(s)
+	return string(sJSON)
+}
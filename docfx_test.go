@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildDocFXItems(t *testing.T) {
+	pkg := extractSource(t, `// Package p is documented.
+package p
+
+// A and B are values.
+const A, B = 1, 2
+
+// T is a type.
+type T int
+
+// M is a method.
+func (T) M() {}
+`)
+	path := pkg.ImportPath
+	tests := []struct {
+		uid, parent, name, typ string
+		children               []string
+	}{
+		{path, "", "p", "Namespace", []string{path + ".A", path + ".B", path + ".T"}},
+		{path + ".A", path, "A", "Field", nil},
+		{path + ".B", path, "B", "Field", nil},
+		{path + ".T", path, "T", "Class", []string{path + ".T.M"}},
+		{path + ".T.M", path + ".T", "M", "Method", nil},
+	}
+	items := BuildDocFXItems(pkg)
+	byUID := map[string]*DocFXItem{}
+	for _, item := range items {
+		byUID[item.UID] = item
+	}
+	for _, test := range tests {
+		item := byUID[test.uid]
+		if item == nil {
+			t.Errorf("missing item %s", test.uid)
+			continue
+		}
+		if item.Parent != test.parent || item.Name != test.name || item.Type != test.typ || !reflect.DeepEqual(item.Children, test.children) {
+			t.Errorf("got %s parent %q name %q type %s children %q, want parent %q name %q type %s children %q",
+				test.uid, item.Parent, item.Name, item.Type, item.Children, test.parent, test.name, test.typ, test.children)
+		}
+	}
+}
+
+func TestYamlString(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"", `""`},
+		{"a: b", `"a: b"`},
+		{"line\n\"quoted\"", `"line\n\"quoted\""`},
+		{"#comment", `"#comment"`},
+	}
+	for _, test := range tests {
+		if got := yamlString(test.s); got != test.want {
+			t.Errorf("yamlString(%q) = %s, want %s", test.s, got, test.want)
+		}
+	}
+}
+
+func TestDocFXFormatter(t *testing.T) {
+	var b strings.Builder
+	if err := docfxFormatter(&outputOptions{})(&b, extractSource(t, schemaSource)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "### YamlMime:ManagedReference\nitems:\n- uid: ") {
+		t.Errorf("got %q", b.String())
+	}
+	if !strings.Contains(b.String(), "  syntax:\n    content: \"func F(t T) T\"\n") {
+		t.Errorf("missing the syntax of F in\n%s", b.String())
+	}
+}
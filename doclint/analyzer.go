@@ -0,0 +1,84 @@
+package doclint
+
+import (
+	"go/ast"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// CoverageAnalyzer reports exported declarations without a doc comment. Its
+// result is the *Coverage of the package.
+var CoverageAnalyzer = &analysis.Analyzer{
+	Name:       "doccoverage",
+	Doc:        "report exported declarations without a doc comment",
+	Run:        runCoverage,
+	ResultType: reflect.TypeOf((*Coverage)(nil)),
+}
+
+// LintAnalyzer reports doc comments that do not start with the name of the
+// declaration they document.
+var LintAnalyzer = &analysis.Analyzer{
+	Name: "doclint",
+	Doc:  "report doc comments not of the form \"Name ...\"",
+	Run:  runLint,
+}
+
+// Analyzers lists the analyzers of this package, for use with multichecker
+// and unitchecker.
+var Analyzers = []*analysis.Analyzer{CoverageAnalyzer, LintAnalyzer}
+
+// Coverage counts the documented exported declarations of a package.
+type Coverage struct {
+	Documented int
+	Total      int
+}
+
+// symbolsOf returns the exported symbols of the non-test files of pass,
+// package first.
+func symbolsOf(pass *analysis.Pass) []*Symbol {
+	var files []*ast.File
+	for _, f := range pass.Files {
+		if !strings.HasSuffix(pass.Fset.Position(f.Package).Filename, "_test.go") {
+			files = append(files, f)
+		}
+	}
+	var symbols []*Symbol
+	if s := PackageSymbol(files); s != nil {
+		symbols = append(symbols, s)
+	}
+	for _, f := range files {
+		symbols = append(symbols, Symbols(f)...)
+	}
+	return symbols
+}
+
+func runCoverage(pass *analysis.Pass) (interface{}, error) {
+	coverage := &Coverage{}
+	for _, s := range symbolsOf(pass) {
+		coverage.Total++
+		documented := true
+		for _, f := range Check(s) {
+			if f.Rule == MissingDoc {
+				pass.Reportf(s.Pos, "%s", f.Message)
+				documented = false
+			}
+		}
+		if documented {
+			coverage.Documented++
+		}
+	}
+	return coverage, nil
+}
+
+func runLint(pass *analysis.Pass) (interface{}, error) {
+	for _, s := range symbolsOf(pass) {
+		for _, f := range Check(s) {
+			if f.Rule != MissingDoc {
+				pass.Reportf(s.Pos, "%s", f.Message)
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,164 @@
+// Package doclint implements the documentation checks of godocjson: which
+// exported declarations lack a doc comment, and which doc comments do not
+// follow the Go conventions.
+//
+// The checks are shared by the godocjson command line reports and by the
+// go/analysis analyzers of this package.
+package doclint
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Rule names, as reported in findings.
+const (
+	// MissingDoc is reported for an exported declaration without a doc
+	// comment, and for a package without a package comment.
+	MissingDoc = "missing-doc"
+
+	// DocPrefix is reported for a doc comment that does not start with the
+	// name of the declaration it documents.
+	DocPrefix = "doc-prefix"
+)
+
+// Symbol is an exported declaration of a package.
+type Symbol struct {
+	Kind string // "package", "const", "var", "type", "func" or "method"
+	Name string // e.g. "Foo", or "T.Foo" for methods
+	Pos  token.Pos
+	Doc  *ast.CommentGroup
+}
+
+// Finding is a violation of a rule by a symbol.
+type Finding struct {
+	Rule    string
+	Symbol  *Symbol
+	Message string
+}
+
+// Symbols returns the exported declarations of file. Methods are only
+// included when their receiver type is exported, and grouped constants and
+// variables are documented by the comment of their group.
+func Symbols(file *ast.File) []*Symbol {
+	var symbols []*Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			s := &Symbol{Kind: "func", Name: d.Name.Name, Pos: d.Name.Pos(), Doc: d.Doc}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				recv := receiverName(d.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				s.Kind, s.Name = "method", recv+"."+d.Name.Name
+			}
+			symbols = append(symbols, s)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if !spec.Name.IsExported() {
+						continue
+					}
+					doc := spec.Doc
+					if doc == nil && !d.Lparen.IsValid() {
+						doc = d.Doc
+					}
+					symbols = append(symbols, &Symbol{Kind: "type", Name: spec.Name.Name, Pos: spec.Name.Pos(), Doc: doc})
+				case *ast.ValueSpec:
+					doc := spec.Doc
+					if doc == nil {
+						doc = d.Doc
+					}
+					for _, name := range spec.Names {
+						if name.IsExported() {
+							symbols = append(symbols, &Symbol{Kind: d.Tok.String(), Name: name.Name, Pos: name.Pos(), Doc: doc})
+						}
+					}
+				}
+			}
+		}
+	}
+	return symbols
+}
+
+// receiverName returns the name of the type of a method receiver.
+func receiverName(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.ParenExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// PackageSymbol returns the symbol of the package of files, documented by
+// the first package comment found.
+func PackageSymbol(files []*ast.File) *Symbol {
+	if len(files) == 0 {
+		return nil
+	}
+	s := &Symbol{Kind: "package", Name: files[0].Name.Name, Pos: files[0].Package}
+	for _, f := range files {
+		if f.Doc != nil {
+			s.Pos, s.Doc = f.Package, f.Doc
+			break
+		}
+	}
+	return s
+}
+
+// Check returns the findings of symbol.
+func Check(symbol *Symbol) []*Finding {
+	if symbol.Doc == nil || strings.TrimSpace(symbol.Doc.Text()) == "" {
+		return []*Finding{{
+			Rule:    MissingDoc,
+			Symbol:  symbol,
+			Message: "exported " + symbol.Kind + " " + symbol.Name + " should have a doc comment",
+		}}
+	}
+	if symbol.Kind == "const" || symbol.Kind == "var" {
+		// Group comments describe several values at once.
+		return nil
+	}
+	if symbol.Kind == "package" && symbol.Name == "main" {
+		// Commands are conventionally documented as "Command foo ...".
+		return nil
+	}
+	name := symbol.Name
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	prefix := name + " "
+	if symbol.Kind == "package" {
+		prefix = "Package " + name + " "
+	}
+	text := symbol.Doc.Text()
+	if strings.HasPrefix(text, prefix) {
+		return nil
+	}
+	if symbol.Kind == "type" {
+		for _, article := range []string{"A ", "An ", "The "} {
+			if strings.HasPrefix(text, article+prefix) {
+				return nil
+			}
+		}
+	}
+	return []*Finding{{
+		Rule:    DocPrefix,
+		Symbol:  symbol,
+		Message: "comment on exported " + symbol.Kind + " " + symbol.Name + ` should be of the form "` + prefix + `..."`,
+	}}
+}
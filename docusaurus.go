@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// DocusaurusSidebarItem is a doc listed in the sidebar written by the
+// docusaurus subcommand.
+type DocusaurusSidebarItem struct {
+	Type  string `json:"type"` // always "doc"
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// mdxEscaper escapes the characters starting JSX expressions in MDX text.
+var mdxEscaper = strings.NewReplacer("{", `\{`, "}", `\}`)
+
+// mdxDoc renders a doc comment as MDX. Unlike Markdown, MDX has no indented
+// code blocks and evaluates braces, so code blocks are fenced and braces
+// escaped. Links to other packages point to pkg.go.dev.
+func mdxDoc(text string) string {
+	var p comment.Parser
+	pr := comment.Printer{
+		HeadingLevel: 3,
+		DocLinkURL: func(link *comment.DocLink) string {
+			return link.DefaultURL("https://pkg.go.dev")
+		},
+	}
+	var b strings.Builder
+	for _, block := range p.Parse(text).Content {
+		if code, ok := block.(*comment.Code); ok {
+			fmt.Fprintf(&b, "```\n%s```\n\n", code.Text)
+			continue
+		}
+		md := string(pr.Markdown(&comment.Doc{Content: []comment.Block{block}}))
+		if _, ok := block.(*comment.Heading); ok {
+			// Keep the heading ID, e.g. {#hdr-Overview}, understood by
+			// Docusaurus.
+			if i := strings.LastIndex(md, " {#"); i >= 0 {
+				md = mdxEscaper.Replace(md[:i]) + md[i:]
+			}
+		} else {
+			md = mdxEscaper.Replace(md)
+		}
+		b.WriteString(md)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// writeDocusaurusMDX renders the documentation of pkg, importable as
+// importPath, as the MDX page id of a Docusaurus docs plugin. Symbols have
+// heading IDs named like the anchors of pkg.go.dev, e.g. #Client.Do.
+func writeDocusaurusMDX(w io.Writer, pkg *extract.Package, id, importPath string) {
+	title, synopsis := pkg.Name, pkg.Synopsis
+	if pkg.Title != "" {
+		title = pkg.Title
+	}
+	if synopsis == "" {
+		synopsis = extract.Synopsis(pkg.Doc)
+	}
+	yamlString := func(s string) string {
+		// JSON strings are YAML double-quoted strings.
+		quoted, _ := json.Marshal(s)
+		return string(quoted)
+	}
+	fmt.Fprintf(w, "---\nid: %s\ntitle: %s\nsidebar_label: %s\n", yamlString(id), yamlString(title), yamlString(importPath))
+	if synopsis != "" {
+		fmt.Fprintf(w, "description: %s\n", yamlString(synopsis))
+	}
+	fmt.Fprintf(w, "---\n\n```go\nimport %q\n```\n\n%s", importPath, mdxDoc(pkg.Doc))
+
+	fmt.Fprintf(w, "## Index\n\n")
+	if len(pkg.Consts) > 0 {
+		fmt.Fprintf(w, "- [Constants](#constants)\n")
+	}
+	if len(pkg.Vars) > 0 {
+		fmt.Fprintf(w, "- [Variables](#variables)\n")
+	}
+	for _, f := range pkg.Funcs {
+		fmt.Fprintf(w, "- [%s](#%s)\n", markdownCode(oneLine(f.Signature)), f.Name)
+	}
+	for _, t := range pkg.Types {
+		fmt.Fprintf(w, "- [type %s](#%s)\n", t.Name, t.Name)
+		for _, f := range t.Funcs {
+			fmt.Fprintf(w, "  - [%s](#%s)\n", markdownCode(oneLine(f.Signature)), f.Name)
+		}
+		for _, m := range t.Methods {
+			fmt.Fprintf(w, "  - [%s](#%s.%s)\n", markdownCode(oneLine(m.Signature)), t.Name, m.Name)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if len(pkg.Consts) > 0 {
+		fmt.Fprintf(w, "## Constants {#constants}\n\n")
+		writeValuesMDX(w, pkg.Consts)
+	}
+	if len(pkg.Vars) > 0 {
+		fmt.Fprintf(w, "## Variables {#variables}\n\n")
+		writeValuesMDX(w, pkg.Vars)
+	}
+	if len(pkg.Funcs) > 0 {
+		fmt.Fprintf(w, "## Functions\n\n")
+		for _, f := range pkg.Funcs {
+			writeFuncMDX(w, f, "###", f.Name)
+		}
+	}
+	if len(pkg.Types) > 0 {
+		fmt.Fprintf(w, "## Types\n\n")
+	}
+	for _, t := range pkg.Types {
+		fmt.Fprintf(w, "### type %s {#%s}\n\n", t.Name, t.Name)
+		if t.Source != "" {
+			fmt.Fprintf(w, "```go\n%s\n```\n\n", t.Source)
+		}
+		fmt.Fprint(w, mdxDoc(t.Doc))
+		writeValuesMDX(w, t.Consts)
+		writeValuesMDX(w, t.Vars)
+		for _, f := range t.Funcs {
+			writeFuncMDX(w, f, "####", f.Name)
+		}
+		for _, m := range t.Methods {
+			writeFuncMDX(w, m, "####", t.Name+"."+m.Name)
+		}
+	}
+}
+
+func writeValuesMDX(w io.Writer, values []*extract.Value) {
+	for _, v := range values {
+		if v.Source != "" {
+			fmt.Fprintf(w, "```go\n%s\n```\n\n", v.Source)
+		} else {
+			fmt.Fprintf(w, "```go\n%s %s\n```\n\n", v.Type, strings.Join(v.Names, ", "))
+		}
+		fmt.Fprint(w, mdxDoc(v.Doc))
+	}
+}
+
+func writeFuncMDX(w io.Writer, f *extract.Func, heading, anchor string) {
+	name := f.Name
+	if f.Recv != "" {
+		name = "(" + strings.Replace(f.Recv, "*", `\*`, -1) + ") " + f.Name
+	}
+	fmt.Fprintf(w, "%s func %s {#%s}\n\n```go\n%s\n```\n\n", heading, name, anchor, f.Signature)
+	fmt.Fprint(w, mdxDoc(f.Doc))
+	if f.Level > 0 {
+		fmt.Fprintf(w, "Promoted from the embedded type %s.\n\n", markdownCode(strings.TrimPrefix(f.Orig, "*")))
+	}
+}
+
+// writeDocusaurusDocs writes the MDX page of every package of pkgs to the
+// directory output, named after its packageKey, and a sidebars.json file
+// holding the sidebar named sidebar, listing the pages by import path. Doc
+// IDs are prefixed with idPrefix, the directory of output relative to the
+// docs directory of the site, e.g. "api/".
+func writeDocusaurusDocs(output, idPrefix, sidebar string, pkgs []*extract.Package) error {
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+	items := []*DocusaurusSidebarItem{}
+	keys := map[string]string{}
+	for _, pkg := range pkgs {
+		path := extract.PackagePath(pkg)
+		key := packageKey(path)
+		if other, ok := keys[key]; ok {
+			return fmt.Errorf("packages %s and %s have the same doc ID %s", other, path, key)
+		}
+		keys[key] = path
+		err := extract.WriteFileAtomic(filepath.Join(output, key+".mdx"), func(w io.Writer) error {
+			writeDocusaurusMDX(w, pkg, key, path)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		items = append(items, &DocusaurusSidebarItem{Type: "doc", ID: idPrefix + key, Label: path})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return extract.WriteFileAtomic(filepath.Join(output, "sidebars.json"), func(w io.Writer) error {
+		return writeJSON(w, map[string]interface{}{sidebar: items}, &outputOptions{Indent: "  "})
+	})
+}
+
+// runDocusaurus implements the docusaurus subcommand.
+func runDocusaurus(args []string) int {
+	flags := flag.NewFlagSet("docusaurus", flag.ExitOnError)
+	output := flags.String("o", "docs/api", "Directory receiving the MDX pages and sidebars.json")
+	idPrefix := flags.String("id-prefix", "api/", "Prefix of the doc IDs: the directory of -o relative to the docs directory of the site")
+	sidebar := flags.String("sidebar", "api", "Name of the sidebar of sidebars.json")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson docusaurus [-o dir] [-id-prefix prefix] [-sidebar name] [-e pattern] directory...")
+		return 2
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := extract.Options{Filter: fileFilter, ExcludeTests: true, Source: true}
+	var pkgs []*extract.Package
+	for _, directory := range directories {
+		pkg, err := extract.Extract(directory, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if err := writeDocusaurusDocs(*output, *idPrefix, *sidebar, pkgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
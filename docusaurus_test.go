@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestMDXDoc(t *testing.T) {
+	got := mdxDoc("Use {braces}.\n\n# Overview\n\nCode:\n\n\tm := map[string]int{}\n")
+	for _, want := range []string{
+		`Use \{braces\}.`,
+		"### Overview {#hdr-Overview}",
+		"```\nm := map[string]int{}\n```",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+}
+
+func TestRunDocusaurus(t *testing.T) {
+	dir := writeModule(t)
+	output := t.TempDir()
+	args := []string{"-o", output, "-id-prefix", "reference/", "-sidebar", "go", filepath.Join(dir, "q"), filepath.Join(dir, "p")}
+	if status := runDocusaurus(args); status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	page, err := os.ReadFile(filepath.Join(output, "example_com_m_p.mdx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"---\nid: \"example_com_m_p\"\ntitle: \"p\"\nsidebar_label: \"example.com/m/p\"\ndescription: \"Package p adds numbers.\"\n---\n",
+		"import \"example.com/m/p\"",
+		"## Constants {#constants}",
+		"### type T {#T}",
+		"#### func (T) Double {#T.Double}",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("missing %q in\n%s", want, page)
+		}
+	}
+
+	sidebarsJSON, err := os.ReadFile(filepath.Join(output, "sidebars.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sidebars map[string][]*DocusaurusSidebarItem
+	if err := json.Unmarshal(sidebarsJSON, &sidebars); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, item := range sidebars["go"] {
+		ids = append(ids, item.ID)
+	}
+	if got, want := strings.Join(ids, " "), "reference/example_com_m_p reference/example_com_m_q"; got != want {
+		t.Errorf("got sidebar %q, want %q", got, want)
+	}
+}
+
+func TestWriteDocusaurusDocsCollision(t *testing.T) {
+	pkgs := []*extract.Package{
+		{Name: "b", ImportPath: "example.com/a/b"},
+		{Name: "b", ImportPath: "example.com/a.b"},
+	}
+	err := writeDocusaurusDocs(t.TempDir(), "api/", "api", pkgs)
+	if err == nil || !strings.Contains(err.Error(), "same doc ID example_com_a_b") {
+		t.Errorf("got error %v, want a doc ID collision", err)
+	}
+}
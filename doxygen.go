@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// doxygenDoc is the root element of a Doxygen compound XML file.
+type doxygenDoc struct {
+	XMLName   xml.Name           `xml:"doxygen"`
+	Version   string             `xml:"version,attr"` // version of godocjson, in place of that of Doxygen
+	Lang      string             `xml:"xml:lang,attr"`
+	Compounds []*doxygenCompound `xml:"compounddef"`
+}
+
+// doxygenCompound describes a namespace, for the package, or a class, for
+// a type.
+type doxygenCompound struct {
+	ID         string              `xml:"id,attr"`
+	Kind       string              `xml:"kind,attr"` // "namespace" or "class"
+	Language   string              `xml:"language,attr"`
+	Name       string              `xml:"compoundname"`
+	InnerClass []*doxygenRef       `xml:"innerclass"`
+	Sections   []*doxygenSection   `xml:"sectiondef"`
+	Brief      *doxygenDescription `xml:"briefdescription"`
+	Detailed   *doxygenDescription `xml:"detaileddescription"`
+	Location   *doxygenLocation    `xml:"location,omitempty"`
+}
+
+type doxygenRef struct {
+	RefID string `xml:"refid,attr"`
+	Prot  string `xml:"prot,attr"`
+	Name  string `xml:",chardata"`
+}
+
+type doxygenSection struct {
+	Kind    string           `xml:"kind,attr"` // e.g. "func" or "var"
+	Members []*doxygenMember `xml:"memberdef"`
+}
+
+type doxygenMember struct {
+	Kind       string              `xml:"kind,attr"` // "function" or "variable"
+	ID         string              `xml:"id,attr"`
+	Prot       string              `xml:"prot,attr"`
+	Static     string              `xml:"static,attr"`
+	Type       string              `xml:"type"`
+	Definition string              `xml:"definition"`
+	ArgsString string              `xml:"argsstring"`
+	Name       string              `xml:"name"`
+	Brief      *doxygenDescription `xml:"briefdescription"`
+	Detailed   *doxygenDescription `xml:"detaileddescription"`
+	Location   *doxygenLocation    `xml:"location,omitempty"`
+}
+
+// doxygenDescription holds paragraphs of a doc comment. Code blocks are
+// paragraphs holding a programlisting.
+type doxygenDescription struct {
+	Paras []*doxygenPara `xml:"para"`
+}
+
+type doxygenPara struct {
+	Text    string          `xml:",chardata"`
+	Listing *doxygenListing `xml:"programlisting,omitempty"`
+}
+
+type doxygenListing struct {
+	Lines []*doxygenCodeline `xml:"codeline"`
+}
+
+type doxygenCodeline struct {
+	Highlight string `xml:"highlight"`
+}
+
+type doxygenLocation struct {
+	File string `xml:"file,attr"`
+	Line int    `xml:"line,attr"`
+}
+
+// doxygenFormatter writes every package in the compound XML format of
+// Doxygen, for Breathe and other tools consuming Doxygen output: the package
+// is a namespace holding its functions, constants and variables, and each
+// type is a class holding its methods, constructors and values.
+func doxygenFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		importPath := extract.PackagePath(pkg)
+		ns := &doxygenCompound{
+			ID:       doxygenID("namespace", importPath),
+			Kind:     "namespace",
+			Language: "Go",
+			Name:     importPath,
+			Brief:    doxygenBrief(pkg.Doc),
+			Detailed: doxygenDetailed(pkg.Doc),
+		}
+		ns.addValues("var", importPath, pkg.Consts, pkg.Vars)
+		ns.addFuncs("func", importPath, pkg.Funcs)
+		dox := &doxygenDoc{Version: extract.ToolVersion(), Lang: "en-US", Compounds: []*doxygenCompound{ns}}
+		for _, t := range pkg.Types {
+			name := importPath + "." + t.Name
+			class := &doxygenCompound{
+				ID:       doxygenID("class", name),
+				Kind:     "class",
+				Language: "Go",
+				Name:     name,
+				Brief:    doxygenBrief(t.Doc),
+				Detailed: doxygenDetailed(t.Doc),
+				Location: doxygenLocationOf(t.Filename, t.Line),
+			}
+			class.addValues("public-attrib", name, t.Consts, t.Vars)
+			class.addFuncs("public-static-func", name, t.Funcs)
+			class.addFuncs("public-func", name, t.Methods)
+			ns.InnerClass = append(ns.InnerClass, &doxygenRef{RefID: class.ID, Prot: "public", Name: name})
+			dox.Compounds = append(dox.Compounds, class)
+		}
+
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(dox); err != nil {
+			return fmt.Errorf("failed to encode XML: %s", err)
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+}
+
+// addValues adds a section of kind listing the constants and variables of
+// values, declared in the namespace or class scope.
+func (c *doxygenCompound) addValues(kind, scope string, values ...[]*extract.Value) {
+	section := &doxygenSection{Kind: kind}
+	for _, vs := range values {
+		for _, v := range vs {
+			for _, name := range v.Names {
+				section.Members = append(section.Members, &doxygenMember{
+					Kind:       "variable",
+					ID:         doxygenID("member", scope+"."+name),
+					Prot:       "public",
+					Static:     "no",
+					Type:       v.Type,
+					Definition: v.Type + " " + name,
+					Name:       name,
+					Brief:      doxygenBrief(v.Doc),
+					Detailed:   doxygenDetailed(v.Doc),
+					Location:   doxygenLocationOf(v.Filename, v.Line),
+				})
+			}
+		}
+	}
+	if len(section.Members) > 0 {
+		c.Sections = append(c.Sections, section)
+	}
+}
+
+// addFuncs adds a section of kind listing funcs, declared in the namespace
+// or class scope.
+func (c *doxygenCompound) addFuncs(kind, scope string, funcs []*extract.Func) {
+	section := &doxygenSection{Kind: kind}
+	for _, f := range funcs {
+		results := make([]string, len(f.Results))
+		for i, r := range f.Results {
+			results[i] = strings.TrimSpace(r.Name + " " + r.Type)
+		}
+		params := make([]string, len(f.Params))
+		for i, p := range f.Params {
+			params[i] = strings.TrimSpace(p.Name + " " + p.Type)
+		}
+		section.Members = append(section.Members, &doxygenMember{
+			Kind:       "function",
+			ID:         doxygenID("member", scope+"."+f.Name),
+			Prot:       "public",
+			Static:     "no",
+			Type:       strings.Join(results, ", "),
+			Definition: oneLine(f.Signature),
+			ArgsString: "(" + strings.Join(params, ", ") + ")",
+			Name:       f.Name,
+			Brief:      doxygenBrief(f.Doc),
+			Detailed:   doxygenDetailed(f.Doc),
+			Location:   doxygenLocationOf(f.Filename, f.Line),
+		})
+	}
+	if len(section.Members) > 0 {
+		c.Sections = append(c.Sections, section)
+	}
+}
+
+// doxygenID returns the identifier of a compound or member of the given
+// kind, made of the characters allowed in Doxygen ids and file names.
+func doxygenID(kind, name string) string {
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	return kind + "_" + id
+}
+
+func doxygenBrief(text string) *doxygenDescription {
+	d := &doxygenDescription{}
+	if s := extract.Synopsis(text); s != "" {
+		d.Paras = append(d.Paras, &doxygenPara{Text: s})
+	}
+	return d
+}
+
+// doxygenDetailed converts a doc comment to paragraphs. Headings and list
+// items become paragraphs of their own.
+func doxygenDetailed(text string) *doxygenDescription {
+	d := &doxygenDescription{}
+	var p comment.Parser
+	var pr comment.Printer
+	for _, block := range p.Parse(text).Content {
+		switch b := block.(type) {
+		case *comment.Code:
+			listing := &doxygenListing{}
+			for _, line := range strings.Split(strings.TrimRight(b.Text, "\n"), "\n") {
+				listing.Lines = append(listing.Lines, &doxygenCodeline{Highlight: line})
+			}
+			d.Paras = append(d.Paras, &doxygenPara{Listing: listing})
+		case *comment.List:
+			for _, item := range b.Items {
+				for _, c := range item.Content {
+					d.Paras = append(d.Paras, &doxygenPara{Text: strings.TrimSpace(string(pr.Text(&comment.Doc{Content: []comment.Block{c}})))})
+				}
+			}
+		default:
+			d.Paras = append(d.Paras, &doxygenPara{Text: strings.TrimSpace(string(pr.Text(&comment.Doc{Content: []comment.Block{b}})))})
+		}
+	}
+	return d
+}
+
+func doxygenLocationOf(filename string, line int) *doxygenLocation {
+	if filename == "" {
+		return nil
+	}
+	return &doxygenLocation{File: filename, Line: line}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDoxygenID(t *testing.T) {
+	tests := []struct {
+		kind, name, want string
+	}{
+		{"namespace", "fmt", "namespace_fmt"},
+		{"class", "net/http.Client", "class_net_http_Client"},
+		{"member", "example.com/m.T.M", "member_example_com_m_T_M"},
+	}
+	for _, test := range tests {
+		if got := doxygenID(test.kind, test.name); got != test.want {
+			t.Errorf("doxygenID(%q, %q) = %q, want %q", test.kind, test.name, got, test.want)
+		}
+	}
+}
+
+func TestDoxygenDetailed(t *testing.T) {
+	tests := []struct {
+		text  string
+		paras []string // "code:" followed by the lines of listings
+	}{
+		{"", nil},
+		{"One.\n\nTwo.\n", []string{"One.", "Two."}},
+		{"List:\n  - a\n  - b\n", []string{"List:", "a", "b"}},
+		{"Code:\n\n\tx := 1\n\ty := 2\n", []string{"Code:", "code:x := 1|y := 2"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, p := range doxygenDetailed(test.text).Paras {
+			if p.Listing != nil {
+				var lines []string
+				for _, l := range p.Listing.Lines {
+					lines = append(lines, l.Highlight)
+				}
+				got = append(got, "code:"+strings.Join(lines, "|"))
+			} else {
+				got = append(got, p.Text)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(test.paras, "\n") {
+			t.Errorf("doxygenDetailed(%q) = %q, want %q", test.text, got, test.paras)
+		}
+	}
+}
+
+func TestDoxygenFormatter(t *testing.T) {
+	pkg := extractSource(t, schemaSource)
+	var b strings.Builder
+	if err := doxygenFormatter(&outputOptions{})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	var dox doxygenDoc
+	if err := xml.Unmarshal([]byte(b.String()), &dox); err != nil {
+		t.Fatal(err)
+	}
+	if len(dox.Compounds) != 2 {
+		t.Fatalf("got %d compounds, want the namespace and class T", len(dox.Compounds))
+	}
+	ns, class := dox.Compounds[0], dox.Compounds[1]
+	if ns.Kind != "namespace" || len(ns.InnerClass) != 1 || ns.InnerClass[0].RefID != class.ID {
+		t.Errorf("got namespace %+v", ns)
+	}
+	if len(ns.Sections) != 1 || ns.Sections[0].Members[0].Name != "Max" {
+		t.Errorf("got namespace sections %+v, want the constant Max", ns.Sections)
+	}
+	if class.Kind != "class" || len(class.Sections) != 1 || class.Sections[0].Kind != "public-static-func" {
+		t.Fatalf("got class %+v, want the constructor F", class)
+	}
+	if f := class.Sections[0].Members[0]; f.Type != "T" || f.ArgsString != "(t T)" {
+		t.Errorf("got F type %q args %q", f.Type, f.ArgsString)
+	}
+}
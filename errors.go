@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync/atomic"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// Exit statuses of the main command. Subcommands define their own.
+const (
+	exitFailure    = 1 // the documentation cannot be produced
+	exitUsage      = 2 // invalid command line
+	exitParseError = 3 // a target package cannot be parsed
+	exitEmpty      = 4 // no package was documented
+	exitPartial    = 5 // files or packages were skipped; the output is written
+	exitStrict     = 6 // warnings were reported with -strict; the output is written
+)
+
+// usageError is an error of the command line found while documenting,
+// reported with the exit status exitUsage.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// warnings counts the warnings reported by warnf, including those of the
+// extraction, checked by -strict.
+var warnings atomic.Int64
+
+// warnf reports a problem that makes the output incomplete without
+// preventing it from being written.
+func warnf(format string, args ...interface{}) {
+	warnings.Add(1)
+	log.Printf("Warning: "+format, args...)
+}
+
+// skipped counts the files and packages left out of the output, which make
+// the program exit with the status exitPartial.
+var skipped atomic.Int64
+
+// skipf reports with warnf n files or packages left out of the output.
+func skipf(n int, format string, args ...interface{}) {
+	skipped.Add(int64(n))
+	warnf(format, args...)
+}
+
+// exitPartially terminates the program with the exit status exitPartial
+// after reporting the n files or packages skipped. The output is written,
+// so this is not reported as a fatal error.
+func exitPartially(n int64) {
+	log.Printf("Warning: %d file(s) or package(s) skipped; the output is written", n)
+	os.Exit(exitPartial)
+}
+
+// fatalf terminates the program with the exit status code after logging
+// the formatted message.
+func fatalf(code int, format string, args ...interface{}) {
+	log.Printf("Fatal: "+format, args...)
+	os.Exit(code)
+}
+
+// exitWithError terminates the program after reporting err, as an error
+// document on stderr with the exit status exitParseError for parse errors.
+func exitWithError(err error) {
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		fatalf(exitUsage, "%s", err)
+	}
+	doc := extract.NewErrorDocument(err)
+	if doc == nil {
+		fatalf(exitFailure, "%s", err)
+	}
+	docJSON, _ := json.MarshalIndent(doc, "", "  ")
+	fmt.Fprintf(os.Stderr, "%s\n", docJSON)
+	os.Exit(exitParseError)
+}
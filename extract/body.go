@@ -0,0 +1,43 @@
+package extract
+
+import (
+	"go/ast"
+)
+
+// LineRange is a range of lines, both inclusive.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// funcBody returns the source text of the body of d, braces included, as
+// written in its file, and the lines it spans. It returns an empty string if
+// d has no body, which go/doc removes unless in PreserveAST mode.
+func (c *Copier) funcBody(d *ast.FuncDecl) (string, *LineRange) {
+	if d.Body == nil {
+		return "", nil
+	}
+	start, end := c.FileSet.Position(d.Body.Lbrace), c.FileSet.Position(d.Body.Rbrace)
+	src, err := c.source(start.Filename)
+	if err != nil || end.Offset >= len(src) {
+		c.Options.warnf("cannot read body of func %s: %v", d.Name.Name, err)
+		return "", nil
+	}
+	return string(src[start.Offset : end.Offset+1]), &LineRange{Start: start.Line, End: end.Line}
+}
+
+// source returns the contents of the file name, read once per Copier.
+func (c *Copier) source(name string) ([]byte, error) {
+	if src, ok := c.sources[name]; ok {
+		return src, nil
+	}
+	src, err := c.Options.files().readFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if c.sources == nil {
+		c.sources = map[string][]byte{}
+	}
+	c.sources[name] = src
+	return src, nil
+}
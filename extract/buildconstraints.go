@@ -0,0 +1,121 @@
+package extract
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// knownOS and knownArch list the GOOS and GOARCH values recognized in file
+// name suffixes, as in go/build.
+var knownOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true,
+	"freebsd": true, "hurd": true, "illumos": true, "ios": true, "js": true,
+	"linux": true, "nacl": true, "netbsd": true, "openbsd": true,
+	"plan9": true, "solaris": true, "wasip1": true, "windows": true,
+	"zos": true,
+}
+
+var knownArch = map[string]bool{
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true,
+	"arm64": true, "arm64be": true, "loong64": true, "mips": true,
+	"mipsle": true, "mips64": true, "mips64le": true, "mips64p32": true,
+	"mips64p32le": true, "ppc": true, "ppc64": true, "ppc64le": true,
+	"riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+}
+
+// collectBuildConstraints returns the build constraints of the files of
+// pkg that have any, by file name, written like a //go:build line without
+// its prefix, e.g. "linux && amd64". They combine the //go:build line, or
+// the legacy // +build lines, with the GOOS and GOARCH suffixes of the file
+// name. It must be called before pkg is passed to doc.New, which removes
+// comments from the AST.
+func collectBuildConstraints(pkg *ast.Package) map[string]string {
+	constraints := map[string]string{}
+	for filename, file := range pkg.Files {
+		expr := fileConstraint(file)
+		if suffix := fileNameConstraint(filename); suffix != nil {
+			if expr == nil {
+				expr = suffix
+			} else {
+				expr = &constraint.AndExpr{X: expr, Y: suffix}
+			}
+		}
+		if expr != nil {
+			constraints[filename] = expr.String()
+		}
+	}
+	return constraints
+}
+
+// fileConstraint returns the constraint of the //go:build line of file, or
+// of its // +build lines, found before the package clause.
+func fileConstraint(file *ast.File) constraint.Expr {
+	var plusBuild constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) {
+				if expr, err := constraint.Parse(c.Text); err == nil {
+					return expr
+				}
+			} else if constraint.IsPlusBuild(c.Text) {
+				expr, err := constraint.Parse(c.Text)
+				if err != nil {
+					continue
+				}
+				if plusBuild == nil {
+					plusBuild = expr
+				} else {
+					plusBuild = &constraint.AndExpr{X: plusBuild, Y: expr}
+				}
+			}
+		}
+	}
+	return plusBuild
+}
+
+// fileNameConstraint returns the constraint implied by the _GOOS, _GOARCH
+// or _GOOS_GOARCH suffix of filename, if any.
+func fileNameConstraint(filename string) constraint.Expr {
+	name := strings.TrimSuffix(filepath.Base(filename), ".go")
+	name = strings.TrimSuffix(name, "_test")
+	// The part before the first underscore, e.g. "linux" in linux.go, is
+	// not a suffix.
+	i := strings.IndexByte(name, '_')
+	if i < 0 {
+		return nil
+	}
+	l := strings.Split(name[i:], "_")
+	if n := len(l); n >= 2 && knownOS[l[n-2]] && knownArch[l[n-1]] {
+		return &constraint.AndExpr{X: &constraint.TagExpr{Tag: l[n-2]}, Y: &constraint.TagExpr{Tag: l[n-1]}}
+	}
+	if last := l[len(l)-1]; knownOS[last] || knownArch[last] {
+		return &constraint.TagExpr{Tag: last}
+	}
+	return nil
+}
+
+// setBuildConstraints sets the BuildConstraints of the symbols of pkg
+// declared in the files of constraints, as collected by
+// collectBuildConstraints.
+func setBuildConstraints(pkg *Package, constraints map[string]string) {
+	if len(constraints) == 0 {
+		return
+	}
+	WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+		expr := constraints[entry.Filename]
+		switch s := symbol.(type) {
+		case *Type:
+			s.BuildConstraints = expr
+		case *Func:
+			s.BuildConstraints = expr
+		case *Value:
+			s.BuildConstraints = expr
+		}
+	})
+}
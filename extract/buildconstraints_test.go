@@ -0,0 +1,84 @@
+package extract
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileNameConstraint(t *testing.T) {
+	tests := []struct {
+		filename, want string
+	}{
+		{"p.go", ""},
+		{"linux.go", ""},
+		{"p_linux.go", "linux"},
+		{"p_arm64.go", "arm64"},
+		{"p_linux_arm64.go", "linux && arm64"},
+		{"p_linux_test.go", "linux"},
+		{"dir/p_windows_amd64_test.go", "windows && amd64"},
+		{"p_other.go", ""},
+		{"p_linux_other.go", ""},
+	}
+	for _, test := range tests {
+		got := ""
+		if expr := fileNameConstraint(test.filename); expr != nil {
+			got = expr.String()
+		}
+		if got != test.want {
+			t.Errorf("fileNameConstraint(%q) = %q, want %q", test.filename, got, test.want)
+		}
+	}
+}
+
+func TestFileConstraint(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"package p\n", ""},
+		{"//go:build linux || darwin\n\npackage p\n", "linux || darwin"},
+		{"// +build linux darwin\n// +build amd64\n\npackage p\n", "(linux || darwin) && amd64"},
+		{"//go:build unix\n// +build linux\n\npackage p\n", "unix"},
+		{"// Package p.\npackage p\n\n//go:build ignore\n", ""},
+	}
+	for _, test := range tests {
+		file, err := parser.ParseFile(token.NewFileSet(), "p.go", test.src, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if expr := fileConstraint(file); expr != nil {
+			got = expr.String()
+		}
+		if got != test.want {
+			t.Errorf("fileConstraint(%q) = %q, want %q", test.src, got, test.want)
+		}
+	}
+}
+
+func TestSetBuildConstraints(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"p.go":       "package p\n\n// F is portable.\nfunc F() {}\n",
+		"p_linux.go": "//go:build cgo\n\npackage p\n\n// T is a type.\ntype T int\n\n// V is a variable.\nvar V T\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pkg, err := Extract(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pkg.Funcs[0].BuildConstraints; got != "" {
+		t.Errorf("got constraints %q on F", got)
+	}
+	if got := pkg.Types[0].BuildConstraints; got != "cgo && linux" {
+		t.Errorf("got constraints %q on T, want %q", got, "cgo && linux")
+	}
+	if got := pkg.Types[0].Vars[0].BuildConstraints; got != "cgo && linux" {
+		t.Errorf("got constraints %q on V, want %q", got, "cgo && linux")
+	}
+}
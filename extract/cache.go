@@ -0,0 +1,141 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cache stores package documents on disk, keyed by a hash of the source
+// files of the package, the extraction options and the godocjson version,
+// so that unchanged packages are not extracted again.
+type Cache struct {
+	Dir string
+}
+
+// DefaultCacheDir returns the cache directory used when none is given.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "godocjson"), nil
+}
+
+// key returns the cache key of the package in directory documented with
+// opts.
+func (c *Cache) key(directory string, opts Options) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "schema %s\ntool %s\n", SchemaVersion, ToolVersion())
+	if exe, err := os.Executable(); err == nil {
+		// Development builds may not record a version.
+		if info, err := os.Stat(exe); err == nil {
+			fmt.Fprintf(h, "executable %s %d\n", info.ModTime(), info.Size())
+		}
+	}
+	abs, err := filepath.Abs(directory)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(h, "directory %s\n", abs)
+	keyOpts := opts
+	// The contents of the files of the overlay are hashed below.
+	keyOpts.Filter, keyOpts.Cache, keyOpts.Imports, keyOpts.Overlay = nil, nil, nil, nil
+	// Applied after the cache.
+	keyOpts.IncludeSymbols, keyOpts.ExcludeSymbols, keyOpts.Implementations = nil, nil, nil
+	keyOpts.VCS, keyOpts.Readme = false, false
+	keyOpts.Warn, keyOpts.Toolchain = nil, nil
+	fmt.Fprintf(h, "options %+v\n", keyOpts)
+	if opts.Toolchain != nil {
+		fmt.Fprintf(h, "toolchain %+v\n", *opts.Toolchain)
+	}
+
+	entries, err := opts.files().readDir(directory)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+		if filter := opts.FileFilter(directory); filter != nil && !filter(info) {
+			continue
+		}
+		data, err := opts.files().readFile(filepath.Join(directory, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "file %s %d\n", entry.Name(), info.Size())
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cacheEntry is the file stored under a cache key: the documented packages
+// and the warnings reported while documenting them, reported again when the
+// entry is reused.
+type cacheEntry struct {
+	Packages []*Package `json:"packages"`
+	Warnings []string   `json:"warnings,omitempty"`
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// get returns the entry stored under key, or nil.
+func (c *Cache) get(key string) *cacheEntry {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Packages == nil {
+		return nil
+	}
+	return &entry
+}
+
+// put stores entry under key.
+func (c *Cache) put(key string, entry *cacheEntry) error {
+	name := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return WriteFileAtomic(name, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(entry)
+	})
+}
+
+// WriteFileAtomic calls write with a temporary file next to name and
+// renames it to name once write succeeds, so readers never observe a
+// partially written file.
+func WriteFileAtomic(name string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
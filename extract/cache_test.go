@@ -0,0 +1,36 @@
+package extract
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestCacheReplaysWarnings checks that the warnings reported while
+// documenting a package are reported again when it is read from the cache.
+func TestCacheReplaysWarnings(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "p")
+	writePackage(t, directory)
+	writeFiles(t, directory, map[string]string{"bad.go": "package p\n\nfunc {\n"})
+	cache := &Cache{Dir: t.TempDir()}
+
+	want := []string{filepath.Join(directory, "bad.go") + " has syntax errors, skipped"}
+	for _, run := range []string{"cold", "warm"} {
+		var warnings []string
+		opts := Options{
+			KeepGoing: true,
+			Cache:     cache,
+			Warn:      func(message string) { warnings = append(warnings, message) },
+		}
+		pkgs, err := ParseDirectoryPackages(directory, opts)
+		if err != nil {
+			t.Fatalf("%s: %s", run, err)
+		}
+		if len(pkgs) != 1 || len(pkgs[0].Funcs) != 1 {
+			t.Errorf("%s: got %d packages, want p and its function", run, len(pkgs))
+		}
+		if !reflect.DeepEqual(warnings, want) {
+			t.Errorf("%s: got warnings %q, want %q", run, warnings, want)
+		}
+	}
+}
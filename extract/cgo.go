@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// CgoExport is a Go function exported to C by a //export directive.
+type CgoExport struct {
+	Name      string `json:"name"`      // name of the function, exported or not, also its C name
+	Signature string `json:"signature"` // Go declaration without body, e.g. "func Callback(x C.int) C.int"
+	Doc       string `json:"doc"`
+	Filename  string `json:"filename"`
+	Line      int    `json:"line"`
+}
+
+// usesCgo reports whether a file of pkg, other than a _test.go file,
+// imports "C".
+func usesCgo(pkg *ast.Package) bool {
+	for filename, file := range pkg.Files {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		for _, spec := range file.Imports {
+			if spec.Path.Value == `"C"` {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectCgoExports returns the functions of pkg with a //export
+// directive, sorted by position. It must be called before pkg is passed to
+// doc.New, which removes unexported functions and doc comments from the
+// AST.
+func collectCgoExports(pkg *ast.Package, fileSet *token.FileSet, sigWidth int) []*CgoExport {
+	var exports []*CgoExport
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+			for _, d := range directivesOf(fn.Doc) {
+				if strings.HasPrefix(d, "export ") {
+					position := fileSet.Position(fn.Name.Pos())
+					exports = append(exports, &CgoExport{
+						Name:      fn.Name.Name,
+						Signature: funcSignature(fn, sigWidth),
+						Doc:       fn.Doc.Text(),
+						Filename:  position.Filename,
+						Line:      position.Line,
+					})
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(exports, func(i, j int) bool {
+		if exports[i].Filename != exports[j].Filename {
+			return exports[i].Filename < exports[j].Filename
+		}
+		return exports[i].Line < exports[j].Line
+	})
+	return exports
+}
+
+// fakeCgoPackage returns a package "C" declaring an opaque type for every
+// C.name selector of files, so that type-checking a cgo package keeps the
+// C types of its declarations, e.g. C.int, instead of invalid types. The C
+// values and functions only used in function bodies are not needed.
+func fakeCgoPackage(files []*ast.File) *types.Package {
+	pkg := types.NewPackage("C", "C")
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			sel, ok := n.(*ast.SelectorExpr)
+			if !ok || !isIdent(sel.X, "C") || pkg.Scope().Lookup(sel.Sel.Name) != nil {
+				return true
+			}
+			obj := types.NewTypeName(token.NoPos, pkg, sel.Sel.Name, nil)
+			types.NewNamed(obj, types.NewStruct(nil, nil), nil)
+			pkg.Scope().Insert(obj)
+			return true
+		})
+	}
+	pkg.MarkComplete()
+	return pkg
+}
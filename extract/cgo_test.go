@@ -0,0 +1,68 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const cgoSource = `package p
+
+// #include <stdlib.h>
+import "C"
+
+// T wraps a C value.
+type T struct{ v C.long }
+
+// Size returns the size of t.
+func (t T) Size() C.size_t { return 0 }
+
+// callback is called from C.
+//
+//export callback
+func callback(x C.int) C.int { return x }
+
+// Exported is called from C too.
+//export Exported
+func Exported() {}
+
+// notExported has no directive.
+func notExported() {}
+`
+
+func TestCgo(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(cgoSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := Extract(dir, Options{MethodSets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pkg.Cgo {
+		t.Error("package not marked as using cgo")
+	}
+	tests := []struct {
+		name, signature string
+		line            int
+	}{
+		{"callback", "func callback(x C.int) C.int", 15},
+		{"Exported", "func Exported()", 19},
+	}
+	if len(pkg.CgoExports) != len(tests) {
+		t.Fatalf("got %d exports, want %d", len(pkg.CgoExports), len(tests))
+	}
+	for i, test := range tests {
+		if e := pkg.CgoExports[i]; e.Name != test.name || e.Signature != test.signature || e.Line != test.line {
+			t.Errorf("got export %s %q at line %d, want %s %q at line %d", e.Name, e.Signature, e.Line, test.name, test.signature, test.line)
+		}
+	}
+	if ms := pkg.Types[0].MethodSet; len(ms) != 1 || ms[0].Signature != "func() C.size_t" {
+		t.Errorf("got method set %+v, want Size with the C result type", ms)
+	}
+
+	pkg = extractSource(t, "package p\n\n//export F\nfunc F() {}\n")
+	if pkg.Cgo || pkg.CgoExports != nil {
+		t.Errorf("got cgo %v and exports %v in a package not importing C", pkg.Cgo, pkg.CgoExports)
+	}
+}
@@ -0,0 +1,178 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CommandFlag is a command line flag defined with the functions of the
+// flag package by a main package, e.g. flag.String("o", "", "output").
+type CommandFlag struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`              // e.g. "string", "bool" or "duration"; "value" for flag.Var, "func" for flag.Func and flag.BoolFunc
+	Default string `json:"default,omitempty"` // default value, unquoted if a string literal, or as written, e.g. "runtime.NumCPU()"
+	Usage   string `json:"usage"`
+}
+
+// flagFuncs maps the functions of the flag package defining flags to the
+// type of the flag and whether they take a pointer to the variable first.
+var flagFuncs = map[string]struct {
+	typ string
+	ptr bool
+}{
+	"Bool":        {"bool", false},
+	"BoolVar":     {"bool", true},
+	"Duration":    {"duration", false},
+	"DurationVar": {"duration", true},
+	"Float64":     {"float64", false},
+	"Float64Var":  {"float64", true},
+	"Int":         {"int", false},
+	"IntVar":      {"int", true},
+	"Int64":       {"int64", false},
+	"Int64Var":    {"int64", true},
+	"String":      {"string", false},
+	"StringVar":   {"string", true},
+	"Uint":        {"uint", false},
+	"UintVar":     {"uint", true},
+	"Uint64":      {"uint64", false},
+	"Uint64Var":   {"uint64", true},
+	"TextVar":     {"value", true},
+	"Var":         {"value", true},
+	"Func":        {"func", false},
+	"BoolFunc":    {"func", false},
+}
+
+// collectCommandFlags returns the flags defined by the functions of the flag
+// package in the files of the main package pkg, outside of _test.go files,
+// sorted by name like flag.PrintDefaults. Flags whose name is not a string
+// literal are skipped, as are those of flag.FlagSet values, usually
+// belonging to subcommands. It must be called before pkg is passed to
+// doc.New, which removes function bodies.
+func collectCommandFlags(pkg *ast.Package) []*CommandFlag {
+	if pkg.Name != "main" {
+		return nil
+	}
+	byName := map[string]*CommandFlag{}
+	for filename, file := range pkg.Files {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		flagName := importName(file, "flag")
+		if flagName == "" {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != flagName {
+				return true
+			}
+			fn, ok := flagFuncs[sel.Sel.Name]
+			if !ok {
+				return true
+			}
+			args := call.Args
+			if fn.ptr {
+				if len(args) == 0 {
+					return true
+				}
+				args = args[1:]
+			}
+			// Arranged as name, default and usage; flag.Var and the func
+			// flags have no default.
+			switch {
+			case sel.Sel.Name == "Var":
+				if len(args) != 2 {
+					return true
+				}
+				args = []ast.Expr{args[0], nil, args[1]}
+			case fn.typ == "func":
+				if len(args) != 3 {
+					return true
+				}
+				args = []ast.Expr{args[0], nil, args[1]}
+			case len(args) != 3:
+				return true
+			}
+			name, ok := stringLiteral(args[0])
+			if !ok || byName[name] != nil {
+				return true
+			}
+			f := &CommandFlag{Name: name, Type: fn.typ, Usage: exprText(args[2])}
+			if args[1] != nil {
+				f.Default = exprText(args[1])
+			}
+			byName[name] = f
+			return true
+		})
+	}
+	var flags []*CommandFlag
+	for _, f := range byName {
+		flags = append(flags, f)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// importName returns the name under which file imports the package path,
+// or "" if it does not.
+func importName(file *ast.File, path string) string {
+	for _, imp := range file.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err != nil || p != path {
+			continue
+		}
+		if imp.Name != nil {
+			if imp.Name.Name == "_" || imp.Name.Name == "." {
+				return ""
+			}
+			return imp.Name.Name
+		}
+		return path[strings.LastIndex(path, "/")+1:]
+	}
+	return ""
+}
+
+// stringLiteral returns the value of x if it is a string literal, or a
+// concatenation of string literals.
+func stringLiteral(x ast.Expr) (string, bool) {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		if x.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(x.Value)
+		return s, err == nil
+	case *ast.BinaryExpr:
+		if x.Op != token.ADD {
+			return "", false
+		}
+		left, ok := stringLiteral(x.X)
+		if !ok {
+			return "", false
+		}
+		right, ok := stringLiteral(x.Y)
+		return left + right, ok
+	case *ast.ParenExpr:
+		return stringLiteral(x.X)
+	}
+	return "", false
+}
+
+// exprText returns the value of x if it is a string literal, or else x as
+// written.
+func exprText(x ast.Expr) string {
+	if s, ok := stringLiteral(x); ok {
+		return s
+	}
+	return types.ExprString(x)
+}
@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandFlags(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.go": `// Tool does things.
+package main
+
+import (
+	"flag"
+	"time"
+)
+
+const name = "dyn"
+
+var (
+	verbose = flag.Bool("v", false, "Print " + "more details")
+	output  string
+	timeout time.Duration
+	level   logLevel
+)
+
+func init() {
+	flag.StringVar(&output, "o", "out", "Output file")
+	flag.DurationVar(&timeout, "timeout", 5*time.Second, "Timeout")
+	flag.Var(&level, "level", "Log level")
+	flag.Func("hook", "Run a hook", func(string) error { return nil })
+	flag.String(name, "", "Skipped: not a literal name")
+	fs := flag.NewFlagSet("sub", flag.ExitOnError)
+	fs.Bool("sub", false, "Skipped: defined on a FlagSet")
+}
+
+type logLevel int
+
+func (l *logLevel) String() string     { return "" }
+func (l *logLevel) Set(s string) error { return nil }
+
+func main() { flag.Parse() }
+`,
+		"workers.go":   "package main\n\nimport (\n\tfl \"flag\"\n\t\"runtime\"\n)\n\nvar workers = fl.Int(\"j\", runtime.NumCPU(), \"Number of workers\")\n",
+		"main_test.go": "package main\n\nimport \"flag\"\n\nvar update = flag.Bool(\"update\", false, \"Skipped: in a test\")\n",
+	})
+	pkg, err := Extract(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*CommandFlag{
+		{Name: "hook", Type: "func", Usage: "Run a hook"},
+		{Name: "j", Type: "int", Default: "runtime.NumCPU()", Usage: "Number of workers"},
+		{Name: "level", Type: "value", Usage: "Log level"},
+		{Name: "o", Type: "string", Default: "out", Usage: "Output file"},
+		{Name: "timeout", Type: "duration", Default: "5 * time.Second", Usage: "Timeout"},
+		{Name: "v", Type: "bool", Default: "false", Usage: "Print more details"},
+	}
+	if !reflect.DeepEqual(pkg.Flags, want) {
+		for _, f := range pkg.Flags {
+			t.Logf("%+v", *f)
+		}
+		t.Errorf("got %d flags, want %d", len(pkg.Flags), len(want))
+	}
+
+	// Flags of other packages are not commands'.
+	pkg = extractSource(t, "package p\n\nimport \"flag\"\n\nvar V = flag.Bool(\"v\", false, \"Verbose\")\n")
+	if pkg.Flags != nil {
+		t.Errorf("got flags %v for package p", pkg.Flags)
+	}
+}
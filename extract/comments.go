@@ -0,0 +1,98 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// DeclComments records the doc comments of declarations before go/doc
+// consumes them, so that comment directives can still be read afterwards.
+// Type specifications without a doc comment of their own are recorded with
+// the doc comment of their declaration.
+type DeclComments map[ast.Node]*ast.CommentGroup
+
+// CollectDeclComments returns the doc comments of the declarations in pkg.
+// It must be called before pkg is passed to doc.New.
+func CollectDeclComments(pkg *ast.Package) DeclComments {
+	comments := DeclComments{}
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				comments[decl] = decl.Doc
+			case *ast.GenDecl:
+				comments[decl] = decl.Doc
+				for _, spec := range decl.Specs {
+					if ts, ok := spec.(*ast.TypeSpec); ok {
+						comments[ts] = ts.Doc
+						if ts.Doc == nil {
+							comments[ts] = decl.Doc
+						}
+					}
+				}
+			}
+		}
+	}
+	return comments
+}
+
+// CollectFuncEnds returns the end positions of the function declarations
+// in pkg, bodies included. It must be called before pkg is passed to
+// doc.New, which removes function bodies unless in PreserveAST mode.
+func CollectFuncEnds(pkg *ast.Package) map[*ast.FuncDecl]token.Pos {
+	ends := map[*ast.FuncDecl]token.Pos{}
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				ends[fn] = fn.End()
+			}
+		}
+	}
+	return ends
+}
+
+// directivesOf returns the directive comments of cg, such as
+// "//go:noinline" or "//export F", without their leading slashes. go/doc
+// leaves them out of doc comments.
+func directivesOf(cg *ast.CommentGroup) []string {
+	if cg == nil {
+		return nil
+	}
+	var directives []string
+	for _, c := range cg.List {
+		if isDirective(c.Text) {
+			directives = append(directives, strings.TrimPrefix(c.Text, "//"))
+		}
+	}
+	return directives
+}
+
+// isDirective reports whether the comment c is a directive: a //line,
+// //extern or //export comment, or a "//tool:name" comment without a space
+// after the slashes, as defined by go/ast.
+func isDirective(c string) bool {
+	if strings.HasPrefix(c, "//line ") || strings.HasPrefix(c, "//extern ") || strings.HasPrefix(c, "//export ") {
+		return true
+	}
+	tool, name, ok := strings.Cut(strings.TrimPrefix(c, "//"), ":")
+	if !strings.HasPrefix(c, "//") || !ok || tool == "" || name == "" {
+		return false
+	}
+	for _, r := range tool {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return 'a' <= name[0] && name[0] <= 'z' || '0' <= name[0] && name[0] <= '9'
+}
+
+// typeSpec returns the specification of the type named name in decl.
+func typeSpec(decl *ast.GenDecl, name string) *ast.TypeSpec {
+	for _, spec := range decl.Specs {
+		if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == name {
+			return ts
+		}
+	}
+	return nil
+}
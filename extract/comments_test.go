@@ -0,0 +1,117 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsDirective(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    bool
+	}{
+		{"//go:noinline", true},
+		{"//go:linkname f runtime.f", true},
+		{"//export F", true},
+		{"//extern f", true},
+		{"//line p.go:10", true},
+		{"//lint:ignore U1000 unused", true},
+		{"// go:noinline", false},
+		{"//Go:noinline", false},
+		{"//go:", false},
+		{"//:name", false},
+		{"//go:Name", false},
+		{"//export", false},
+		{"/*go:noinline*/", false},
+		{"// See https://example.com.", false},
+	}
+	for _, test := range tests {
+		if got := isDirective(test.comment); got != test.want {
+			t.Errorf("isDirective(%q) = %v, want %v", test.comment, got, test.want)
+		}
+	}
+}
+
+func TestDirectives(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+// F is documented.
+//
+//go:noinline
+func F() {}
+
+// T is a type.
+//
+//go:generate stringer -type T
+type T int
+
+// V is a variable.
+//
+//lint:ignore U1000 unused
+var V int
+`)
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"F", pkg.Funcs[0].Directives, []string{"go:noinline"}},
+		{"T", pkg.Types[0].Directives, []string{"go:generate stringer -type T"}},
+		{"V", pkg.Vars[0].Directives, []string{"lint:ignore U1000 unused"}},
+	}
+	for _, test := range tests {
+		if !reflect.DeepEqual(test.got, test.want) {
+			t.Errorf("got directives %q on %s, want %q", test.got, test.name, test.want)
+		}
+	}
+	if doc := pkg.Funcs[0].Doc; doc != "F is documented.\n" {
+		t.Errorf("got doc %q on F", doc)
+	}
+}
+
+func TestOffsets(t *testing.T) {
+	src := `package p
+
+// F has a body.
+func F() int {
+	return 1
+}
+
+// T is a type.
+type T struct{ N int }
+
+type (
+	// A is grouped.
+	A int
+	B string
+)
+
+// C is a constant.
+const C = 1
+
+// M is a method.
+func (T) M() {}
+`
+	pkg := extractSource(t, src)
+	tests := []struct {
+		name        string
+		offset, end int
+		want        string
+	}{
+		{"F", pkg.Funcs[0].Offset, pkg.Funcs[0].EndOffset, "func F() int {\n\treturn 1\n}"},
+		{"T", pkg.Types[2].Offset, pkg.Types[2].EndOffset, "type T struct{ N int }"},
+		{"A", pkg.Types[0].Offset, pkg.Types[0].EndOffset, "A int"},
+		{"B", pkg.Types[1].Offset, pkg.Types[1].EndOffset, "B string"},
+		{"C", pkg.Consts[0].Offset, pkg.Consts[0].EndOffset, "const C = 1"},
+		{"T.M", pkg.Types[2].Methods[0].Offset, pkg.Types[2].Methods[0].EndOffset, "func (T) M() {}"},
+	}
+	for _, test := range tests {
+		if test.offset < 0 || test.end > len(src) || test.offset > test.end {
+			t.Errorf("%s: invalid offsets %d, %d", test.name, test.offset, test.end)
+			continue
+		}
+		if got := src[test.offset:test.end]; got != test.want {
+			t.Errorf("%s: offsets select %q, want %q", test.name, got, test.want)
+		}
+	}
+}
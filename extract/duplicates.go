@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"go/ast"
+	"sort"
+)
+
+// dropDuplicateFuncs removes from pkg the functions and methods declared
+// more than once, which happens when the files of several platforms are
+// parsed together, e.g. roundtrip.go and roundtrip_js.go in net/http. In
+// file name order, the first declaration with a doc comment is kept, or
+// else the first one. go/doc would keep one depending on map iteration
+// order. It must be called before pkg is passed to doc.New.
+func dropDuplicateFuncs(pkg *ast.Package) {
+	filenames := make([]string, 0, len(pkg.Files))
+	for filename := range pkg.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	kept := map[string]*ast.FuncDecl{}
+	for _, filename := range filenames {
+		for _, decl := range pkg.Files[filename].Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Name.Name == "init" || fn.Name.Name == "_" {
+				continue
+			}
+			key := funcKey(fn)
+			if k := kept[key]; k == nil || k.Doc == nil && fn.Doc != nil {
+				kept[key] = fn
+			}
+		}
+	}
+	for _, file := range pkg.Files {
+		decls := file.Decls[:0]
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && kept[funcKey(fn)] != nil && kept[funcKey(fn)] != fn {
+				continue
+			}
+			decls = append(decls, decl)
+		}
+		file.Decls = decls
+	}
+}
+
+// funcKey identifies a function, or a method by its receiver base type and
+// name, e.g. "Transport.RoundTrip".
+func funcKey(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return fn.Name.Name
+	}
+	typ := fn.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+			continue
+		case *ast.ParenExpr:
+			typ = t.X
+			continue
+		case *ast.IndexExpr:
+			typ = t.X
+			continue
+		case *ast.IndexListExpr:
+			typ = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + fn.Name.Name
+		}
+		return "." + fn.Name.Name
+	}
+}
@@ -0,0 +1,73 @@
+package extract
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFuncKey(t *testing.T) {
+	tests := []struct {
+		decl, want string
+	}{
+		{"func F() {}", "F"},
+		{"func (T) M() {}", "T.M"},
+		{"func (t *T) M() {}", "T.M"},
+		{"func (t *(T)) M() {}", "T.M"},
+		{"func (l List[E]) M() {}", "List.M"},
+		{"func (m *Map[K, V]) M() {}", "Map.M"},
+	}
+	for _, test := range tests {
+		file, err := parser.ParseFile(token.NewFileSet(), "p.go", "package p\n\n"+test.decl, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := funcKey(file.Decls[0].(*ast.FuncDecl)); got != test.want {
+			t.Errorf("funcKey(%s) = %q, want %q", test.decl, got, test.want)
+		}
+	}
+}
+
+func TestDropDuplicateFuncs(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		doc   string
+	}{
+		{"first", map[string]string{
+			"a.go": "package p\n\n// F in a.\nfunc F() {}\n",
+			"b.go": "package p\n\n// F in b.\nfunc F() {}\n",
+		}, "F in a.\n"},
+		{"documented", map[string]string{
+			"a.go": "package p\n\nfunc F() {}\n",
+			"b.go": "package p\n\n// F in b.\nfunc F() {}\n",
+		}, "F in b.\n"},
+		{"undocumented", map[string]string{
+			"a.go": "package p\n\nfunc F() {}\n",
+			"b.go": "package p\n\nfunc F() int { return 0 }\n",
+		}, ""},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		for name, src := range test.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < 5; i++ {
+			pkg, err := Extract(dir, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pkg.Funcs) != 1 {
+				t.Fatalf("%s: got %d funcs", test.name, len(pkg.Funcs))
+			}
+			if f := pkg.Funcs[0]; f.Doc != test.doc || test.name == "undocumented" && f.Signature != "func F()" {
+				t.Errorf("%s: kept %q with doc %q, want doc %q", test.name, f.Signature, f.Doc, test.doc)
+			}
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package extract
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"sync"
+)
+
+// PromotedMethod represents an exported method promoted to a type from an
+// embedded type declared in another package.
+type PromotedMethod struct {
+	Name           string `json:"name"`
+	Signature      string `json:"signature"`      // e.g. "func(ctx context.Context) error"
+	From           string `json:"from"`           // embedded type as written, e.g. "sync.Mutex"
+	RecvImportPath string `json:"recvImportPath"` // import path of the package declaring the method
+	Recv           string `json:"recv"`           // type declaring the method, e.g. "Mutex"
+	URL            string `json:"url"`            // upstream documentation of the method
+}
+
+// ImportCache holds the packages imported from source to resolve embedded
+// types. It is safe for concurrent use, so that it can be shared by the
+// extractions running in one process; each package is imported once per
+// module.
+type ImportCache struct {
+	mu       sync.Mutex
+	packages map[[2]string]*importedPackage
+}
+
+type importedPackage struct {
+	once sync.Once
+	pkg  *types.Package
+	err  error
+}
+
+// NewImportCache returns an empty ImportCache.
+func NewImportCache() *ImportCache {
+	return &ImportCache{packages: map[[2]string]*importedPackage{}}
+}
+
+// importFrom returns the package importPath as imported from srcDir by
+// imp, importing it on first use.
+func (c *ImportCache) importFrom(imp types.ImporterFrom, importPath, srcDir string) (*types.Package, error) {
+	// Packages resolve the same way from every directory of a module.
+	root, _ := FindModule(srcDir)
+	if root == "" {
+		root = srcDir
+	}
+	key := [2]string{root, importPath}
+	c.mu.Lock()
+	p, ok := c.packages[key]
+	if !ok {
+		p = &importedPackage{}
+		c.packages[key] = p
+	}
+	c.mu.Unlock()
+	p.once.Do(func() {
+		p.pkg, p.err = imp.ImportFrom(importPath, srcDir, 0)
+	})
+	return p.pkg, p.err
+}
+
+// embeddedResolver imports the packages of embedded types to find the
+// methods they promote.
+type embeddedResolver struct {
+	importer types.ImporterFrom
+	srcDir   string
+	cache    *ImportCache
+	opts     Options
+}
+
+// resolvePromotedMethods fills the PromotedMethods of the types of pkg with
+// the methods promoted from embedded types of other packages. docPkg and
+// astPkg are the go/doc and go/ast packages pkg was created from. Imported
+// packages are looked up in opts.Imports, or in a new cache if nil.
+func resolvePromotedMethods(pkg *Package, docPkg *doc.Package, astPkg *ast.Package, fileSet *token.FileSet, directory string, opts Options) {
+	cache := opts.Imports
+	if cache == nil {
+		cache = NewImportCache()
+	}
+	r := &embeddedResolver{
+		importer: newSourceImporter(fileSet, opts),
+		srcDir:   directory,
+		cache:    cache,
+		opts:     opts,
+	}
+	for i, t := range docPkg.Types {
+		file := astPkg.Files[fileSet.Position(t.Decl.Pos()).Filename]
+		if file == nil {
+			continue
+		}
+		if ts := typeSpec(t.Decl, t.Name); ts != nil {
+			pkg.Types[i].PromotedMethods = r.promoted(file, embeddedTypes(ts.Type))
+		}
+	}
+}
+
+// embeddedTypes returns the embedded fields of a struct type or the embedded
+// interfaces of an interface type.
+func embeddedTypes(x ast.Expr) []ast.Expr {
+	var fields *ast.FieldList
+	switch x := x.(type) {
+	case *ast.StructType:
+		fields = x.Fields
+	case *ast.InterfaceType:
+		fields = x.Methods
+	default:
+		return nil
+	}
+	var embedded []ast.Expr
+	for _, f := range fields.List {
+		if len(f.Names) == 0 {
+			embedded = append(embedded, f.Type)
+		}
+	}
+	return embedded
+}
+
+func (r *embeddedResolver) promoted(file *ast.File, embedded []ast.Expr) []*PromotedMethod {
+	var methods []*PromotedMethod
+	for _, x := range embedded {
+		if star, ok := x.(*ast.StarExpr); ok {
+			x = star.X
+		}
+		switch index := x.(type) {
+		case *ast.IndexExpr:
+			x = index.X
+		case *ast.IndexListExpr:
+			x = index.X
+		}
+		sel, ok := x.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		qualifier, ok := sel.X.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		typesPkg := r.lookup(file, qualifier.Name)
+		if typesPkg == nil {
+			r.opts.warnf("cannot resolve package %s of embedded type %s", qualifier.Name, TypeOf(sel))
+			continue
+		}
+		obj, ok := typesPkg.Scope().Lookup(sel.Sel.Name).(*types.TypeName)
+		if !ok {
+			r.opts.warnf("cannot resolve embedded type %s", TypeOf(sel))
+			continue
+		}
+		mset := types.NewMethodSet(types.NewPointer(obj.Type()))
+		if types.IsInterface(obj.Type()) {
+			mset = types.NewMethodSet(obj.Type())
+		}
+		for i := 0; i < mset.Len(); i++ {
+			fn, ok := mset.At(i).Obj().(*types.Func)
+			if !ok || !fn.Exported() {
+				continue
+			}
+			sig := fn.Type().(*types.Signature)
+			recvPath, recvName := typesPkg.Path(), obj.Name()
+			if recv := sig.Recv(); recv != nil {
+				t := recv.Type()
+				if ptr, ok := t.(*types.Pointer); ok {
+					t = ptr.Elem()
+				}
+				if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+					recvPath, recvName = named.Obj().Pkg().Path(), named.Obj().Name()
+				}
+			}
+			methods = append(methods, &PromotedMethod{
+				Name:           fn.Name(),
+				Signature:      types.TypeString(sig, (*types.Package).Name),
+				From:           TypeOf(sel),
+				RecvImportPath: recvPath,
+				Recv:           recvName,
+				URL:            "https://pkg.go.dev/" + recvPath + "#" + recvName + "." + fn.Name(),
+			})
+		}
+	}
+	return methods
+}
+
+// lookup returns the package imported by file under the name qualifier.
+// Imports whose last path element is qualifier are tried first, since
+// determining the name of other packages requires importing them.
+func (r *embeddedResolver) lookup(file *ast.File, qualifier string) *types.Package {
+	var likely, unlikely []string
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case spec.Name != nil && spec.Name.Name == qualifier:
+			return r.importPackage(importPath)
+		case spec.Name != nil:
+		case path.Base(importPath) == qualifier:
+			likely = append(likely, importPath)
+		default:
+			unlikely = append(unlikely, importPath)
+		}
+	}
+	for _, importPath := range append(likely, unlikely...) {
+		if typesPkg := r.importPackage(importPath); typesPkg != nil && typesPkg.Name() == qualifier {
+			return typesPkg
+		}
+	}
+	return nil
+}
+
+func (r *embeddedResolver) importPackage(importPath string) *types.Package {
+	typesPkg, err := r.cache.importFrom(r.importer, importPath, r.srcDir)
+	if err != nil {
+		r.opts.warnf("cannot import %s: %s", importPath, err)
+	}
+	return typesPkg
+}
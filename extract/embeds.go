@@ -0,0 +1,113 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Embed is a variable initialized with files of the package directory by a
+// //go:embed directive.
+type Embed struct {
+	Name     string   `json:"name"`     // variable, exported or not
+	Type     string   `json:"type"`     // type as written: "embed.FS", "string" or "[]byte"
+	Patterns []string `json:"patterns"` // patterns of the directives, e.g. "templates/*.html"
+	Filename string   `json:"filename"`
+	Line     int      `json:"line"`
+}
+
+// collectEmbeds returns the variables of pkg declared with //go:embed
+// directives, outside of _test.go files, sorted by position. It must be
+// called before pkg is passed to doc.New, which removes unexported
+// variables and comments from the AST.
+func collectEmbeds(pkg *ast.Package, fileSet *token.FileSet) []*Embed {
+	var embeds []*Embed
+	for filename, file := range pkg.Files {
+		if strings.HasSuffix(filename, "_test.go") {
+			continue
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				cg := vs.Doc
+				if cg == nil && !gen.Lparen.IsValid() {
+					cg = gen.Doc
+				}
+				var patterns []string
+				for _, d := range directivesOf(cg) {
+					if args := strings.TrimPrefix(d, "go:embed"); args != d && (args == "" || args[0] == ' ' || args[0] == '\t') {
+						patterns = append(patterns, parseEmbedPatterns(args)...)
+					}
+				}
+				if len(patterns) == 0 || len(vs.Names) != 1 || vs.Type == nil {
+					continue
+				}
+				position := fileSet.Position(vs.Names[0].Pos())
+				embeds = append(embeds, &Embed{
+					Name:     vs.Names[0].Name,
+					Type:     types.ExprString(vs.Type),
+					Patterns: patterns,
+					Filename: position.Filename,
+					Line:     position.Line,
+				})
+			}
+		}
+	}
+	sort.Slice(embeds, func(i, j int) bool {
+		if embeds[i].Filename != embeds[j].Filename {
+			return embeds[i].Filename < embeds[j].Filename
+		}
+		return embeds[i].Line < embeds[j].Line
+	})
+	return embeds
+}
+
+// parseEmbedPatterns splits the arguments of a //go:embed directive into
+// patterns, unquoting those written as Go string literals.
+func parseEmbedPatterns(args string) []string {
+	var patterns []string
+	for args = strings.TrimSpace(args); args != ""; args = strings.TrimSpace(args) {
+		var pattern string
+		switch args[0] {
+		case '"', '`':
+			end := quotedEnd(args)
+			if end < 0 {
+				// Unterminated, kept as written.
+				return append(patterns, args)
+			}
+			pattern, args = args[:end], args[end:]
+			if unquoted, err := strconv.Unquote(pattern); err == nil {
+				pattern = unquoted
+			}
+		default:
+			end := strings.IndexAny(args, " \t")
+			if end < 0 {
+				end = len(args)
+			}
+			pattern, args = args[:end], args[end:]
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// quotedEnd returns the index following the string literal s starts with,
+// or -1 if it is not terminated.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && s[0] == '"':
+			i++
+		case s[i] == s[0]:
+			return i + 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEmbedPatterns(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"", nil},
+		{" a.txt", []string{"a.txt"}},
+		{" templates/*.html\tstatic", []string{"templates/*.html", "static"}},
+		{` "with space.txt" b`, []string{"with space.txt", "b"}},
+		{" `raw dir` \"esc\\\"aped\"", []string{"raw dir", `esc"aped`}},
+		{` "unterminated`, []string{`"unterminated`}},
+	}
+	for _, test := range tests {
+		if got := parseEmbedPatterns(test.args); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseEmbedPatterns(%q) = %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+func TestCollectEmbeds(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+import "embed"
+
+//go:embed templates/*.html
+//go:embed static
+var content embed.FS
+
+var (
+	// Version is embedded.
+	//go:embed VERSION
+	Version string
+
+	//go:embed logo.png
+	logo []byte
+
+	notEmbedded string
+)
+
+//go:embedded foo
+var other string
+`)
+	var got []string
+	for _, e := range pkg.Embeds {
+		got = append(got, fmt.Sprintf("%s %s %q %d", e.Name, e.Type, e.Patterns, e.Line))
+	}
+	want := []string{
+		`content embed.FS ["templates/*.html" "static"] 7`,
+		`Version string ["VERSION"] 12`,
+		`logo []byte ["logo.png"] 15`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got embeds\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
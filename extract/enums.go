@@ -0,0 +1,206 @@
+package extract
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/doc"
+	"go/token"
+	"strings"
+)
+
+// Enum is a block of constants of a type enumerating its values with iota.
+type Enum struct {
+	Members []*EnumMember `json:"members"` // in declaration order
+}
+
+// EnumMember is a constant of an enumeration.
+type EnumMember struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"` // e.g. "3" or "4096"; absent if it cannot be computed without type-checking
+	Doc   string `json:"doc,omitempty"`   // doc comment, or line comment, of the constant
+}
+
+// enumDecl is an enumeration found by collectEnums.
+type enumDecl struct {
+	typeName string
+	members  []*EnumMember
+}
+
+// collectEnums returns the const declarations of pkg forming an
+// enumeration: a typed sequence of one constant per line, whose first value
+// uses iota and is implicitly repeated by the following lines. It must be
+// called before pkg is passed to doc.New, which removes unexported
+// constants and would change the values of iota.
+func collectEnums(pkg *ast.Package) map[*ast.GenDecl]*enumDecl {
+	enums := map[*ast.GenDecl]*enumDecl{}
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+				if e := enumOf(gen); e != nil {
+					enums[gen] = e
+				}
+			}
+		}
+	}
+	return enums
+}
+
+func enumOf(decl *ast.GenDecl) *enumDecl {
+	if len(decl.Specs) < 2 {
+		return nil
+	}
+	first := decl.Specs[0].(*ast.ValueSpec)
+	typ, ok := first.Type.(*ast.Ident)
+	if !ok || len(first.Values) != 1 || !usesIota(first.Values[0]) {
+		return nil
+	}
+	e := &enumDecl{typeName: typ.Name}
+	known := map[string]constant.Value{}
+	var expr ast.Expr
+	for i, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		if len(vs.Names) != 1 {
+			return nil
+		}
+		switch {
+		case vs.Type == nil && len(vs.Values) == 0:
+			// Implicit repetition of the previous expression.
+		case isIdent(vs.Type, typ.Name) && len(vs.Values) == 1:
+			expr = vs.Values[0]
+		default:
+			return nil
+		}
+		name := vs.Names[0].Name
+		if name == "_" {
+			continue
+		}
+		member := &EnumMember{Name: name, Doc: strings.TrimSpace(vs.Doc.Text())}
+		if member.Doc == "" {
+			member.Doc = strings.TrimSpace(vs.Comment.Text())
+		}
+		if v, ok := evalConst(expr, int64(i), known); ok {
+			known[name] = v
+			member.Value = v.ExactString()
+		}
+		e.members = append(e.members, member)
+	}
+	return e
+}
+
+func isIdent(x ast.Expr, name string) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+// usesIota reports whether x refers to iota.
+func usesIota(x ast.Expr) bool {
+	found := false
+	ast.Inspect(x, func(n ast.Node) bool {
+		found = found || isIdent(asExpr(n), "iota")
+		return !found
+	})
+	return found
+}
+
+func asExpr(n ast.Node) ast.Expr {
+	x, _ := n.(ast.Expr)
+	return x
+}
+
+// evalConst evaluates the constant expression x for the given value of
+// iota, with the values of the constants declared before it in known. Only
+// literals, iota, known constants, operators and conversions are
+// supported.
+func evalConst(x ast.Expr, iota int64, known map[string]constant.Value) (constant.Value, bool) {
+	switch x := x.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(x.Value, x.Kind, 0)
+		return v, v.Kind() != constant.Unknown
+	case *ast.Ident:
+		if x.Name == "iota" {
+			return constant.MakeInt64(iota), true
+		}
+		v, ok := known[x.Name]
+		return v, ok
+	case *ast.ParenExpr:
+		return evalConst(x.X, iota, known)
+	case *ast.CallExpr:
+		// Conversion to a type of the package, e.g. Kind(iota).
+		if _, ok := x.Fun.(*ast.Ident); !ok || len(x.Args) != 1 {
+			return nil, false
+		}
+		return evalConst(x.Args[0], iota, known)
+	case *ast.UnaryExpr:
+		v, ok := evalConst(x.X, iota, known)
+		if !ok || !numeric(v) || x.Op == token.XOR && v.Kind() != constant.Int {
+			return nil, false
+		}
+		if x.Op != token.ADD && x.Op != token.SUB && x.Op != token.XOR {
+			return nil, false
+		}
+		return constant.UnaryOp(x.Op, v, 0), true
+	case *ast.BinaryExpr:
+		a, ok := evalConst(x.X, iota, known)
+		if !ok {
+			return nil, false
+		}
+		b, ok := evalConst(x.Y, iota, known)
+		if !ok || !numeric(a) || !numeric(b) {
+			return nil, false
+		}
+		switch x.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(b)
+			if !ok || a.Kind() != constant.Int || s > 1<<10 {
+				return nil, false
+			}
+			return constant.Shift(a, x.Op, uint(s)), true
+		case token.QUO, token.REM:
+			if constant.Sign(b) == 0 {
+				return nil, false
+			}
+		case token.ADD, token.SUB, token.MUL, token.AND, token.OR, token.XOR, token.AND_NOT:
+		default:
+			return nil, false
+		}
+		op := x.Op
+		if op == token.QUO && a.Kind() == constant.Int && b.Kind() == constant.Int {
+			op = token.QUO_ASSIGN // integer division
+		}
+		if (op == token.REM || op == token.AND || op == token.OR || op == token.XOR || op == token.AND_NOT) && (a.Kind() != constant.Int || b.Kind() != constant.Int) {
+			return nil, false
+		}
+		return constant.BinaryOp(a, op, b), true
+	}
+	return nil, false
+}
+
+func numeric(v constant.Value) bool {
+	return v.Kind() == constant.Int || v.Kind() == constant.Float
+}
+
+// attachEnums sets the Enum of the types of pkg declaring enumerations in
+// enums, as collected by collectEnums. docPkg is the go/doc package pkg was
+// created from. Only the constants documented in pkg are listed.
+func attachEnums(pkg *Package, docPkg *doc.Package, enums map[*ast.GenDecl]*enumDecl) {
+	for i, t := range docPkg.Types {
+		for _, v := range t.Consts {
+			e := enums[v.Decl]
+			if e == nil || e.typeName != t.Name {
+				continue
+			}
+			documented := map[string]bool{}
+			for _, name := range v.Names {
+				documented[name] = true
+			}
+			if pkg.Types[i].Enum == nil {
+				pkg.Types[i].Enum = &Enum{Members: []*EnumMember{}}
+			}
+			for _, m := range e.members {
+				if documented[m.Name] {
+					pkg.Types[i].Enum.Members = append(pkg.Types[i].Enum.Members, m)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package extract
+
+import (
+	"go/constant"
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+func TestEvalConst(t *testing.T) {
+	known := map[string]constant.Value{"K": constant.MakeInt64(8)}
+	tests := []struct {
+		expr string
+		iota int64
+		want string // empty if not computed
+	}{
+		{"iota", 3, "3"},
+		{"iota + 1", 3, "4"},
+		{"1 << iota", 4, "16"},
+		{"1 << (10 * iota)", 2, "1048576"},
+		{"Kind(iota)", 2, "2"},
+		{"-iota", 2, "-2"},
+		{"^iota", 0, "-1"},
+		{"K * iota", 2, "16"},
+		{"7 / 2", 0, "3"},
+		{"iota / 0", 1, ""},
+		{"1.5 * iota", 2, "3"},
+		{`"s"`, 0, `"s"`},
+		{`"s" + "t"`, 0, ""},
+		{"Unknown + iota", 1, ""},
+		{"len(x)", 1, ""},
+		{"1 << 2000", 0, ""},
+		{"!iota", 0, ""},
+	}
+	for _, test := range tests {
+		x, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		if v, ok := evalConst(x, test.iota, known); ok {
+			got = v.ExactString()
+		}
+		if got != test.want {
+			t.Errorf("evalConst(%s) with iota %d = %q, want %q", test.expr, test.iota, got, test.want)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	tests := []struct {
+		name, src string
+		members   []string // "name=value doc"
+	}{
+		{"iota", "type E int\n\nconst (\n\tA E = iota // first\n\tB\n\tC\n)", []string{"A=0 first", "B=1 ", "C=2 "}},
+		{"skip", "type E int\n\nconst (\n\t_ E = iota\n\tA\n\tb\n\tC\n)", []string{"A=1 ", "C=3 "}},
+		{"flags", "type E uint\n\nconst (\n\tA E = 1 << iota\n\tB\n\t// C is third.\n\tC\n)", []string{"A=1 ", "B=2 ", "C=4 C is third."}},
+		{"restart", "type E int\n\nconst (\n\tA E = iota * 10\n\tB\n\tC E = 100\n\tD\n)", []string{"A=0 ", "B=10 ", "C=100 ", "D=100 "}},
+		{"single", "type E int\n\nconst (\n\tA E = iota\n)", nil},
+		{"untyped", "type E int\n\nconst (\n\tA = iota\n\tB\n)", nil},
+		{"no iota", "type E int\n\nconst (\n\tA E = 1\n\tB\n)", nil},
+		{"other type", "type E int\n\ntype F int\n\nconst (\n\tA E = iota\n\tB F = 2\n)", nil},
+		{"unknown", "type E int\n\nconst (\n\tA E = iota + len(\"ab\")\n\tB\n)", []string{"A= ", "B= "}},
+	}
+	for _, test := range tests {
+		pkg := extractSource(t, "package p\n\n"+test.src+"\n")
+		var got []string
+		if e := pkg.Types[0].Enum; e != nil {
+			for _, m := range e.Members {
+				got = append(got, m.Name+"="+m.Value+" "+m.Doc)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(test.members, "\n") {
+			t.Errorf("%s: got members %q, want %q", test.name, got, test.members)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package extract
+
+import (
+	"errors"
+	"go/scanner"
+)
+
+// ErrorDocument is written to stderr in place of the documentation when
+// the target packages cannot be parsed.
+type ErrorDocument struct {
+	Type   string         `json:"type"` // always "error"
+	Errors []*SourceError `json:"errors"`
+}
+
+// SourceError is an error at a position of a source file.
+type SourceError struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// NewErrorDocument returns the error document reporting the syntax errors
+// of err, or nil if err is not a parse error.
+func NewErrorDocument(err error) *ErrorDocument {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		var single *scanner.Error
+		if !errors.As(err, &single) {
+			return nil
+		}
+		list = scanner.ErrorList{single}
+	}
+	doc := &ErrorDocument{Type: "error", Errors: []*SourceError{}}
+	for _, e := range list {
+		doc.Errors = append(doc.Errors, &SourceError{
+			File:    e.Pos.Filename,
+			Line:    e.Pos.Line,
+			Column:  e.Pos.Column,
+			Message: e.Msg,
+		})
+	}
+	return doc
+}
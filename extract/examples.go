@@ -0,0 +1,164 @@
+package extract
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Example represents an example function of a _test.go file.
+type Example struct {
+	Name        string `json:"name"`             // example function name, e.g. "ExampleT_M_second"
+	Symbol      string `json:"symbol,omitempty"` // exemplified symbol, e.g. "T.M"; empty for package examples
+	Suffix      string `json:"suffix,omitempty"` // e.g. "second"
+	Doc         string `json:"doc"`
+	Code        string `json:"code"`
+	Output      string `json:"output"`
+	Unordered   bool   `json:"unordered"`
+	EmptyOutput bool   `json:"emptyOutput"` // expects empty output
+	Filename    string `json:"filename"`
+	Line        int    `json:"line"`
+}
+
+// collectExamples returns the examples of the _test.go files of pkgs, in
+// file order. It must be called before doc.New, which removes function
+// bodies.
+func collectExamples(fileSet *token.FileSet, pkgs map[string]*ast.Package) []*doc.Example {
+	var names []string
+	files := map[string]*ast.File{}
+	for _, pkg := range pkgs {
+		for name, f := range pkg.Files {
+			if strings.HasSuffix(name, "_test.go") {
+				names = append(names, name)
+				files[name] = f
+			}
+		}
+	}
+	sort.Strings(names)
+	var examples []*doc.Example
+	for _, name := range names {
+		examples = append(examples, doc.Examples(files[name])...)
+	}
+	return examples
+}
+
+// attachExamples adds examples to pkg, associated with the functions, types
+// and methods they exemplify the way go/doc does: ExampleF documents F,
+// ExampleT documents T and ExampleT_M documents the method M of T, each
+// with an optional _suffix starting with a lower-case letter. Examples of
+// unknown symbols are dropped, as go/doc does.
+func attachExamples(pkg *Package, fileSet *token.FileSet, examples []*doc.Example, opts Options) {
+	ids := map[string]*[]string{}
+	symbols := map[string]string{}
+	for _, f := range pkg.Funcs {
+		ids[f.Name], symbols[f.Name] = &f.Examples, f.Name
+	}
+	for _, t := range pkg.Types {
+		ids[t.Name], symbols[t.Name] = &t.Examples, t.Name
+		for _, f := range t.Funcs {
+			ids[f.Name], symbols[f.Name] = &f.Examples, f.Name
+		}
+		for _, m := range t.Methods {
+			id := t.Name + "_" + m.Name
+			ids[id], symbols[id] = &m.Examples, t.Name+"."+m.Name
+		}
+	}
+
+	for _, ex := range examples {
+		e := newExample(fileSet, ex, opts)
+		if ex.Name == "" || ex.Name[0] == '_' {
+			if ex.Name != "" && !isExampleSuffix(ex.Name[1:]) {
+				continue
+			}
+			e.Suffix = strings.TrimPrefix(ex.Name, "_")
+			pkg.Examples = append(pkg.Examples, e)
+			continue
+		}
+		for i := len(ex.Name); i >= 0; i = strings.LastIndexByte(ex.Name[:i], '_') {
+			prefix, suffix, ok := splitExampleName(ex.Name, i)
+			if !ok {
+				continue
+			}
+			if refs, ok := ids[prefix]; ok {
+				e.Symbol, e.Suffix = symbols[prefix], suffix
+				*refs = append(*refs, e.Name)
+				pkg.Examples = append(pkg.Examples, e)
+				break
+			}
+		}
+	}
+}
+
+// splitExampleName splits s at the underscore at index i, if the part after
+// it is a valid suffix.
+func splitExampleName(s string, i int) (prefix, suffix string, ok bool) {
+	if i == len(s) {
+		return s, "", true
+	}
+	if i == len(s)-1 {
+		return "", "", false
+	}
+	prefix, suffix = s[:i], s[i+1:]
+	return prefix, suffix, isExampleSuffix(suffix)
+}
+
+func isExampleSuffix(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && unicode.IsLower(r)
+}
+
+func newExample(fileSet *token.FileSet, ex *doc.Example, opts Options) *Example {
+	position := fileSet.Position(ex.Code.Pos())
+	return &Example{
+		Name:        "Example" + ex.Name,
+		Doc:         ex.Doc,
+		Code:        exampleCode(fileSet, ex, opts),
+		Output:      ex.Output,
+		Unordered:   ex.Unordered,
+		EmptyOutput: ex.EmptyOutput,
+		Filename:    position.Filename,
+		Line:        position.Line,
+	}
+}
+
+// outputComment matches the comment holding the expected output of an
+// example, as recognized by go/doc.
+var outputComment = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// exampleCode prints the body of an example without its braces and output
+// comment, unindented once, like godoc shows it.
+func exampleCode(fileSet *token.FileSet, ex *doc.Example, opts Options) string {
+	var comments []*ast.CommentGroup
+	for _, cg := range ex.Comments {
+		if !outputComment.MatchString(cg.Text()) {
+			comments = append(comments, cg)
+		}
+	}
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, fileSet, &printer.CommentedNode{Node: ex.Code, Comments: comments}); err != nil {
+		opts.warnf("cannot print example %s: %s", ex.Name, err)
+		return ""
+	}
+	code := buf.String()
+	if _, ok := ex.Code.(*ast.BlockStmt); ok {
+		code = strings.TrimSuffix(strings.TrimPrefix(code, "{"), "}")
+		code = strings.Trim(code, "\n")
+		if code == "" {
+			return ""
+		}
+		lines := strings.Split(code, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimPrefix(line, "\t")
+		}
+		code = strings.Join(lines, "\n") + "\n"
+	}
+	return code
+}
@@ -0,0 +1,1173 @@
+// Package extract extracts the documentation of Go packages as
+// json-annotated values, written as JSON documents by the godocjson command.
+package extract
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Func represents a function declaration.
+type Func struct {
+	Doc               string      `json:"doc"`
+	Name              string      `json:"name"`
+	PackageName       string      `json:"packageName"`
+	PackageImportPath string      `json:"packageImportPath"`
+	Type              string      `json:"type"`
+	Filename          string      `json:"filename"`
+	Line              int         `json:"line"`
+	Offset            int         `json:"offset"`    // byte offset of the declaration in its file
+	EndOffset         int         `json:"endOffset"` // byte offset following the declaration, body included
+	Params            []FuncParam `json:"parameters"`
+	Results           []FuncParam `json:"results"`
+	Signature         string      `json:"signature"`                  // declaration without body, e.g. "func (t *T) Name(a int) error"
+	Page              string      `json:"page,omitempty"`             // output page assigned by a godocjson:page directive
+	BuildConstraints  string      `json:"buildConstraints,omitempty"` // build constraints of the file declaring it, e.g. "linux && amd64"
+	Platforms         []string    `json:"platforms,omitempty"`        // with Options.Platforms, the platforms declaring it unless all do, e.g. "linux/amd64"
+	Directives        []string    `json:"directives,omitempty"`       // directive comments of the declaration, e.g. "go:noinline"
+	Import            string      `json:"import,omitempty"`           // import statement of the package, e.g. `import "example.com/mod/pkg"`
+	Source            string      `json:"source,omitempty"`           // declaration as printed by gofmt, without body
+	Body              string      `json:"body,omitempty"`             // body as written, braces included
+	Examples          []string    `json:"examples,omitempty"`         // names of the examples of the function, see Package.Examples
+	BodyLines         *LineRange  `json:"bodyLines,omitempty"`
+
+	// methods
+	// (for functions, these fields have the respective zero value)
+	Recv  string `json:"recv"`  // actual   receiver "T" or "*T"
+	Orig  string `json:"orig"`  // original receiver "T" or "*T"
+	Level int    `json:"level"` // embedding level; 0 means not embedded
+}
+
+// SchemaVersion identifies the layout of the JSON documents produced by
+// godocjson. The minor version is incremented when fields are added; the
+// major version is incremented when fields are removed, renamed or change
+// meaning.
+const SchemaVersion = "1.38"
+
+// Package represents a package declaration.
+type Package struct {
+	SchemaVersion string             `json:"schemaVersion"`
+	Type          string             `json:"type"`
+	Doc           string             `json:"doc"`
+	Name          string             `json:"name"`
+	ImportPath    string             `json:"importPath"`
+	Imports       []string           `json:"imports"`
+	Filenames     []string           `json:"filenames"`
+	Notes         map[string][]*Note `json:"notes"`
+	// DEPRECATED. For backward compatibility Bugs is still populated,
+	// but all new code should use Notes instead.
+	Bugs []string `json:"bugs"`
+
+	// declarations
+	Consts []*Value `json:"consts"`
+	Types  []*Type  `json:"types"`
+	Vars   []*Value `json:"vars"`
+	Funcs  []*Func  `json:"funcs"`
+
+	Services   []*Service       `json:"services,omitempty"`   // gRPC services generated by protoc-gen-go-grpc
+	Errors     []*SentinelError `json:"errors,omitempty"`     // exported error variables created with errors.New
+	Embeds     []*Embed         `json:"embeds,omitempty"`     // variables initialized by //go:embed directives
+	Cgo        bool             `json:"cgo,omitempty"`        // imports "C"; C types are kept as written, e.g. "C.int"
+	CgoExports []*CgoExport     `json:"cgoExports,omitempty"` // functions exported to C by //export directives
+	Flags      []*CommandFlag   `json:"flags,omitempty"`      // command line flags of main packages defined with the flag package
+	Import     *Import          `json:"import,omitempty"`     // how to import the package; absent for commands, test packages and packages outside of modules and GOPATH
+	Metadata   *Metadata        `json:"metadata"`             // how the documentation was extracted
+	Trimmed    []string         `json:"trimmed,omitempty"`    // options that dropped members of the document, "fields" or "omitempty"; required members may be missing
+	Module     *Module          `json:"module,omitempty"`     // module containing the package, read from its go.mod
+	License    *License         `json:"license,omitempty"`    // license of the module
+	Examples   []*Example       `json:"examples,omitempty"`   // examples of _test.go files, including those of the external test package
+
+	// Synopsis is the first sentence of Doc, unless overridden like Title
+	// and FrontMatter by the doc.json marker file of the package directory.
+	Title       string                 `json:"title,omitempty"`
+	Synopsis    string                 `json:"synopsis,omitempty"`
+	FrontMatter map[string]interface{} `json:"frontMatter,omitempty"`
+
+	Readme string `json:"readme,omitempty"` // README of the package directory, with -readme
+
+	// Diagnostics lists the syntax errors of the files skipped with
+	// -keep-going.
+	Diagnostics []*SourceError `json:"diagnostics,omitempty"`
+}
+
+// Note represents a note comment.
+type Note struct {
+	Pos      token.Pos `json:"pos"`
+	End      token.Pos `json:"end"`                // position range of the comment containing the marker
+	UID      string    `json:"uid"`                // uid found with the marker
+	Body     string    `json:"body"`               // note body text
+	Filename string    `json:"filename,omitempty"` // file of the comment containing the marker
+	Line     int       `json:"line,omitempty"`     // line of the marker
+}
+
+// Type represents a type declaration.
+type Type struct {
+	PackageName       string   `json:"packageName"`
+	PackageImportPath string   `json:"packageImportPath"`
+	Doc               string   `json:"doc"`
+	Name              string   `json:"name"`
+	Type              string   `json:"type"`
+	Kind              string   `json:"kind"`              // e.g. "struct", "interface" or "alias", see typeKind
+	Underlying        string   `json:"underlying"`        // type expression of the declaration, e.g. "map[string]int"
+	IsAlias           bool     `json:"isAlias"`           // declared as type A = B
+	AliasOf           string   `json:"aliasOf,omitempty"` // aliased type of alias declarations, e.g. "time.Duration"
+	Enum              *Enum    `json:"enum,omitempty"`    // constants enumerating the values of the type with iota
+	Filename          string   `json:"filename"`
+	Line              int      `json:"line"`
+	Offset            int      `json:"offset"`                     // byte offset of the declaration in its file
+	EndOffset         int      `json:"endOffset"`                  // byte offset following the declaration
+	Page              string   `json:"page,omitempty"`             // output page assigned by a godocjson:page directive
+	BuildConstraints  string   `json:"buildConstraints,omitempty"` // build constraints of the file declaring it, e.g. "linux && amd64"
+	Platforms         []string `json:"platforms,omitempty"`        // with Options.Platforms, the platforms declaring it unless all do, e.g. "linux/amd64"
+	Directives        []string `json:"directives,omitempty"`       // directive comments of the declaration, e.g. "go:linkname"
+	Import            string   `json:"import,omitempty"`           // import statement of the package
+	Source            string   `json:"source,omitempty"`           // declaration as printed by gofmt
+	Examples          []string `json:"examples,omitempty"`         // names of the examples of the type, see Package.Examples
+	// Decl              *ast.GenDecl
+
+	// TypeParams lists the type parameters of generic types, in order, and
+	// Instantiations the generic types instantiated in the declaration:
+	// in its type, including fields and embedded types, and in the
+	// constraints of its type parameters.
+	TypeParams     []*TypeParam     `json:"typeParams,omitempty"`
+	Instantiations []*Instantiation `json:"instantiations,omitempty"`
+
+	// associated declarations
+	Consts  []*Value `json:"consts"`  // sorted list of constants of (mostly) this type
+	Vars    []*Value `json:"vars"`    // sorted list of variables of (mostly) this type
+	Funcs   []*Func  `json:"funcs"`   // sorted list of functions returning this type
+	Methods []*Func  `json:"methods"` // sorted list of methods (including embedded ones) of this type
+
+	PromotedMethods []*PromotedMethod `json:"promotedMethods,omitempty"` // methods promoted from embedded types of other packages
+
+	Fields []*Field `json:"fields,omitempty"` // exported fields of struct types
+
+	// With -implements, Implements lists the interfaces of the documented
+	// packages implemented by the type or a pointer to it, and
+	// ImplementedBy the types implementing the interface, prefixed with
+	// "*" when only their pointer does. Names are qualified by import path,
+	// e.g. "io.Reader".
+	Implements    []string `json:"implements,omitempty"`
+	ImplementedBy []string `json:"implementedBy,omitempty"`
+
+	// With -method-sets, MethodSet lists the exported methods of the
+	// method set of the type T, promoted methods included, and
+	// PtrMethodSet those of *T. Interface types have no PtrMethodSet.
+	MethodSet    []*MethodSetEntry `json:"methodSet,omitempty"`
+	PtrMethodSet []*MethodSetEntry `json:"ptrMethodSet,omitempty"`
+}
+
+// Value represents a value declaration.
+type Value struct {
+	PackageName       string   `json:"packageName"`
+	PackageImportPath string   `json:"packageImportPath"`
+	Doc               string   `json:"doc"`
+	Names             []string `json:"names"` // var or const names in declaration order
+	Type              string   `json:"type"`
+	Filename          string   `json:"filename"`
+	Line              int      `json:"line"`
+	Offset            int      `json:"offset"`                     // byte offset of the declaration in its file
+	EndOffset         int      `json:"endOffset"`                  // byte offset following the declaration
+	Page              string   `json:"page,omitempty"`             // output page assigned by a godocjson:page directive
+	BuildConstraints  string   `json:"buildConstraints,omitempty"` // build constraints of the file declaring it, e.g. "linux && amd64"
+	Platforms         []string `json:"platforms,omitempty"`        // with Options.Platforms, the platforms declaring it unless all do, e.g. "linux/amd64"
+	Directives        []string `json:"directives,omitempty"`       // directive comments of the declaration, e.g. "go:linkname"
+	Import            string   `json:"import,omitempty"`           // import statement of the package
+	Source            string   `json:"source,omitempty"`           // declaration as printed by gofmt
+	// Decl              *ast.GenDecl
+
+	// Instantiations lists the generic types instantiated in the types of
+	// the declaration and by the composite literals of its values.
+	Instantiations []*Instantiation `json:"instantiations,omitempty"`
+}
+
+// TypeParam represents a type parameter of a generic type.
+type TypeParam struct {
+	Name       string `json:"name"`
+	Constraint string `json:"constraint"` // e.g. "any" or "~int | ~string"
+}
+
+// FuncParam represents a parameter to a function.
+type FuncParam struct {
+	Type           string           `json:"type"`
+	Name           string           `json:"name"`
+	Instantiations []*Instantiation `json:"instantiations,omitempty"` // generic types instantiated in Type
+	Channels       []*Channel       `json:"channels,omitempty"`       // channel types found in Type
+	Doc            string           `json:"doc,omitempty"`            // description found in the doc comment of the function, see ParamDocHeuristics
+}
+
+// Instantiation represents a generic type instantiated with type arguments,
+// such as List[string].
+type Instantiation struct {
+	Type     string   `json:"type"`              // instantiated type, e.g. "pkg.List[string]"
+	Generic  string   `json:"generic"`           // name of the generic type, e.g. "List"
+	Package  string   `json:"package,omitempty"` // import path of the package of the generic type, e.g. "example.com/pkg"; empty for local types
+	TypeArgs []string `json:"typeArgs"`          // type arguments in order
+}
+
+// Channel represents a channel type, such as <-chan *Event.
+type Channel struct {
+	Type     string `json:"type"`               // channel type, e.g. "<-chan *Event"
+	Dir      string `json:"dir"`                // "send", "recv" or "both"
+	Elem     string `json:"elem"`               // element type, e.g. "*Event"
+	ElemType string `json:"elemType,omitempty"` // named type referenced by Elem, e.g. "Event"; empty for predeclared and unnamed types
+	Package  string `json:"package,omitempty"`  // package qualifier of ElemType, e.g. "pkg"; empty for local types
+}
+
+// TypeOf returns the Go syntax of the type expression x, an ast.Expr, such
+// as "map [string][]*T".
+func TypeOf(x interface{}) string {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return x.String()
+	case *ast.ArrayType:
+		return "[]" + TypeOf(x.Elt)
+	case *ast.Field:
+		return x.Names[0].Name + " " + TypeOf(x.Type)
+	case *ast.StructType:
+		fields := make([]string, x.Fields.NumFields())
+		for i, f := range x.Fields.List {
+			fields[i] = TypeOf(f.Type)
+		}
+		return fmt.Sprintf("struct{%s}", strings.Join(fields, ","))
+	case *ast.InterfaceType:
+		methods := make([]string, x.Methods.NumFields())
+		for i, m := range x.Methods.List {
+			methods[i] = TypeOf(m.Type)
+		}
+		return fmt.Sprintf("interface{%s}", strings.Join(methods, ","))
+	case *ast.SelectorExpr:
+		return TypeOf(x.X) + "." + x.Sel.Name
+	case *ast.Ellipsis:
+		return "..." + TypeOf(x.Elt)
+	case *ast.StarExpr:
+		return "*" + TypeOf(x.X)
+	case *ast.ParenExpr:
+		return "(" + TypeOf(x.X) + ")"
+	case *ast.IndexExpr:
+		return TypeOf(x.X) + "[" + TypeOf(x.Index) + "]"
+	case *ast.IndexListExpr:
+		indices := make([]string, len(x.Indices))
+		for i, index := range x.Indices {
+			indices[i] = TypeOf(index)
+		}
+		return fmt.Sprintf("%s[%s]", TypeOf(x.X), strings.Join(indices, ","))
+	case *ast.FuncType:
+		params := make([]string, x.Params.NumFields())
+		for i, p := range x.Params.List {
+			params[i] = TypeOf(p.Type)
+		}
+		var results []string
+		if x.Results != nil {
+			results = make([]string, x.Results.NumFields())
+			for i, r := range x.Results.List {
+				results[i] = TypeOf(r.Type)
+			}
+		}
+		return fmt.Sprintf("func(%s)%s", strings.Join(params, ","), strings.Join(results, ","))
+	case *ast.MapType:
+		return fmt.Sprintf("map [%s]%s", TypeOf(x.Key), TypeOf(x.Value))
+	case *ast.ChanType:
+		if x.Dir == ast.SEND {
+			return fmt.Sprintf("chan<- %s", TypeOf(x.Value))
+		} else if x.Dir == ast.RECV {
+			return fmt.Sprintf("<-chan %s", TypeOf(x.Value))
+		} else {
+			return fmt.Sprintf("chan %s", TypeOf(x.Value))
+		}
+	case ast.Expr:
+		// Expressions without a dedicated case print as written.
+		return types.ExprString(x)
+	default:
+		panic(fmt.Sprintf("Unknown type %+v", x))
+	}
+}
+
+// instantiationsOf returns the generic type instantiations found in the type
+// expression x, outermost first. The package qualifiers of generic types
+// are looked up in importPaths, see resolveImportPaths; those missing are
+// kept as written.
+func instantiationsOf(x ast.Node, importPaths map[*ast.Ident]string) []*Instantiation {
+	var insts []*Instantiation
+	ast.Inspect(x, func(n ast.Node) bool {
+		var generic ast.Expr
+		var args []ast.Expr
+		switch n := n.(type) {
+		case *ast.IndexExpr:
+			generic, args = n.X, []ast.Expr{n.Index}
+		case *ast.IndexListExpr:
+			generic, args = n.X, n.Indices
+		default:
+			return true
+		}
+		inst := &Instantiation{
+			Type:     TypeOf(n),
+			TypeArgs: make([]string, len(args)),
+		}
+		switch g := generic.(type) {
+		case *ast.Ident:
+			inst.Generic = g.Name
+		case *ast.SelectorExpr:
+			inst.Generic = g.Sel.Name
+			inst.Package = TypeOf(g.X)
+			if id, ok := g.X.(*ast.Ident); ok && importPaths[id] != "" {
+				inst.Package = importPaths[id]
+			}
+		default:
+			inst.Generic = TypeOf(g)
+		}
+		for i, arg := range args {
+			inst.TypeArgs[i] = TypeOf(arg)
+		}
+		insts = append(insts, inst)
+		return true
+	})
+	return insts
+}
+
+// channelsOf returns the channel types found in the type expression x,
+// outermost first.
+func channelsOf(x ast.Expr) []*Channel {
+	var chans []*Channel
+	ast.Inspect(x, func(n ast.Node) bool {
+		c, ok := n.(*ast.ChanType)
+		if !ok {
+			return true
+		}
+		ch := &Channel{Type: TypeOf(c), Dir: "both", Elem: TypeOf(c.Value)}
+		switch c.Dir {
+		case ast.SEND:
+			ch.Dir = "send"
+		case ast.RECV:
+			ch.Dir = "recv"
+		}
+		// Follow pointers, slices and arrays to the named element type.
+		elem := c.Value
+		for done := false; !done; {
+			switch e := elem.(type) {
+			case *ast.StarExpr:
+				elem = e.X
+			case *ast.ArrayType:
+				elem = e.Elt
+			case *ast.ParenExpr:
+				elem = e.X
+			case *ast.IndexExpr:
+				elem = e.X
+			case *ast.IndexListExpr:
+				elem = e.X
+			default:
+				done = true
+			}
+		}
+		switch e := elem.(type) {
+		case *ast.Ident:
+			if types.Universe.Lookup(e.Name) == nil {
+				ch.ElemType = e.Name
+			}
+		case *ast.SelectorExpr:
+			ch.ElemType = e.Sel.Name
+			ch.Package = TypeOf(e.X)
+		}
+		chans = append(chans, ch)
+		return true
+	})
+	return chans
+}
+
+func processFuncDecl(d *ast.FuncDecl, fun *Func, importPaths map[*ast.Ident]string) {
+	fun.Params = make([]FuncParam, 0)
+	for _, f := range d.Type.Params.List {
+		t := TypeOf(f.Type)
+		for _, name := range f.Names {
+			fun.Params = append(fun.Params, FuncParam{
+				Type:           t,
+				Name:           name.String(),
+				Instantiations: instantiationsOf(f.Type, importPaths),
+				Channels:       channelsOf(f.Type),
+			})
+		}
+	}
+	fun.Results = make([]FuncParam, 0)
+	if d.Type.Results != nil {
+		for _, f := range d.Type.Results.List {
+			t := TypeOf(f.Type)
+			if len(f.Names) == 0 {
+				// For case func foo() Type
+				fun.Results = append(fun.Results, FuncParam{
+					Type:           t,
+					Instantiations: instantiationsOf(f.Type, importPaths),
+					Channels:       channelsOf(f.Type),
+				})
+			} else {
+				// For case func foo() (name, name Type)
+				for _, name := range f.Names {
+					fun.Results = append(fun.Results, FuncParam{
+						Type:           t,
+						Name:           name.String(),
+						Instantiations: instantiationsOf(f.Type, importPaths),
+						Channels:       channelsOf(f.Type),
+					})
+				}
+			}
+		}
+	}
+}
+
+// A Copier produces json-annotated objects from the GoDoc objects of one
+// package.
+type Copier struct {
+	PackageName       string
+	PackageImportPath string
+	FileSet           *token.FileSet
+	// Comments holds the doc comments of the declarations of the package,
+	// collected before doc.New consumed them.
+	Comments DeclComments
+	// FuncEnds holds the end positions of the function declarations of the
+	// package, bodies included, collected before doc.New removed the
+	// bodies; see CollectFuncEnds.
+	FuncEnds map[*ast.FuncDecl]token.Pos
+	// ImportPaths holds the import paths of the package names qualifying
+	// types, resolved before doc.New; see resolveImportPaths.
+	ImportPaths map[*ast.Ident]string
+	Options     Options
+
+	sources map[string][]byte // file contents read for function bodies
+}
+
+// NewCopier returns a Copier for the objects of pkg.
+func NewCopier(pkg *doc.Package, fileSet *token.FileSet, comments DeclComments, opts Options) *Copier {
+	return &Copier{
+		PackageName:       pkg.Name,
+		PackageImportPath: pkg.ImportPath,
+		FileSet:           fileSet,
+		Comments:          comments,
+		Options:           opts,
+	}
+}
+
+// offsets returns the byte offsets of start and end in their file.
+func (c *Copier) offsets(start, end token.Pos) (int, int) {
+	return c.FileSet.Position(start).Offset, c.FileSet.Position(end).Offset
+}
+
+// CopyFuncs produces a json-annotated array of Func objects from an array of GoDoc Func objects.
+func (c *Copier) CopyFuncs(f []*doc.Func) []*Func {
+	newFuncs := make([]*Func, len(f))
+	for i, n := range f {
+		position := c.FileSet.Position(n.Decl.Pos())
+		if !position.IsValid() {
+			c.Options.warnf("no position for func %s", n.Name)
+		}
+		newFuncs[i] = &Func{
+			Doc:               n.Doc,
+			Name:              n.Name,
+			PackageName:       c.PackageName,
+			PackageImportPath: c.PackageImportPath,
+			Type:              "func",
+			Orig:              n.Orig,
+			Recv:              n.Recv,
+			Level:             n.Level,
+			Filename:          position.Filename,
+			Line:              position.Line,
+			Signature:         funcSignature(n.Decl, c.Options.SigWidth),
+			Page:              pageOf(c.Comments[n.Decl]),
+			Directives:        directivesOf(c.Comments[n.Decl]),
+		}
+		end, ok := c.FuncEnds[n.Decl]
+		if !ok {
+			end = n.Decl.End()
+		}
+		newFuncs[i].Offset, newFuncs[i].EndOffset = c.offsets(n.Decl.Pos(), end)
+		processFuncDecl(n.Decl, newFuncs[i], c.ImportPaths)
+		if c.Options.Source {
+			newFuncs[i].Source = c.declSource(n.Decl)
+		}
+		if c.Options.Bodies {
+			newFuncs[i].Body, newFuncs[i].BodyLines = c.funcBody(n.Decl)
+		}
+	}
+	return newFuncs
+}
+
+// CopyValues produces a json-annotated array of Value objects from an array of GoDoc Value objects.
+func (c *Copier) CopyValues(v []*doc.Value) []*Value {
+	newConsts := make([]*Value, len(v))
+	for i, v := range v {
+		position := c.FileSet.Position(v.Decl.TokPos)
+		if !position.IsValid() {
+			c.Options.warnf("no position for %s %s", v.Decl.Tok, strings.Join(v.Names, ", "))
+		}
+		newConsts[i] = &Value{
+			Doc:               v.Doc,
+			Names:             v.Names,
+			PackageName:       c.PackageName,
+			PackageImportPath: c.PackageImportPath,
+			Type:              v.Decl.Tok.String(),
+			Filename:          position.Filename,
+			Line:              position.Line,
+			Page:              pageOf(c.Comments[v.Decl]),
+			Directives:        directivesOf(c.Comments[v.Decl]),
+			Instantiations:    c.valueInstantiations(v.Decl),
+		}
+		newConsts[i].Offset, newConsts[i].EndOffset = c.offsets(v.Decl.Pos(), v.Decl.End())
+		if c.Options.Source {
+			newConsts[i].Source = c.declSource(v.Decl)
+		}
+	}
+	return newConsts
+}
+
+// valueInstantiations returns the generic types instantiated in the types
+// of the specifications of decl, and by the composite literals of their
+// values, e.g. List[int]{}. Other index expressions of values, such as
+// a[i] or generic function instantiations, are left out.
+func (c *Copier) valueInstantiations(decl *ast.GenDecl) []*Instantiation {
+	var insts []*Instantiation
+	for _, spec := range decl.Specs {
+		vs, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if vs.Type != nil {
+			insts = append(insts, instantiationsOf(vs.Type, c.ImportPaths)...)
+		}
+		for _, value := range vs.Values {
+			ast.Inspect(value, func(n ast.Node) bool {
+				if lit, ok := n.(*ast.CompositeLit); ok && lit.Type != nil {
+					insts = append(insts, instantiationsOf(lit.Type, c.ImportPaths)...)
+				}
+				return true
+			})
+		}
+	}
+	return insts
+}
+
+// typeParams returns the type parameters of the generic type ts, or nil.
+func typeParams(ts *ast.TypeSpec) []*TypeParam {
+	if ts.TypeParams == nil {
+		return nil
+	}
+	var params []*TypeParam
+	for _, f := range ts.TypeParams.List {
+		for _, name := range f.Names {
+			params = append(params, &TypeParam{Name: name.Name, Constraint: types.ExprString(f.Type)})
+		}
+	}
+	return params
+}
+
+// CopyPackage produces a json-annotated Package object from a GoDoc Package object.
+func (c *Copier) CopyPackage(pkg *doc.Package) Package {
+	newPkg := Package{
+		SchemaVersion: SchemaVersion,
+		Type:          "package",
+		Doc:           pkg.Doc,
+		Name:          pkg.Name,
+		ImportPath:    pkg.ImportPath,
+		Imports:       pkg.Imports,
+		Filenames:     pkg.Filenames,
+		Bugs:          pkg.Bugs,
+		Synopsis:      Synopsis(pkg.Doc),
+	}
+
+	newPkg.Notes = map[string][]*Note{}
+	for key, value := range pkg.Notes {
+		notes := make([]*Note, len(value))
+		for i, note := range value {
+			notes[i] = &Note{
+				Pos:  note.Pos,
+				End:  note.End,
+				UID:  note.UID,
+				Body: note.Body,
+			}
+		}
+		newPkg.Notes[key] = notes
+	}
+
+	newPkg.Consts = c.CopyValues(pkg.Consts)
+	newPkg.Funcs = c.CopyFuncs(pkg.Funcs)
+
+	decls := make([]*ast.GenDecl, len(pkg.Types))
+	for i, t := range pkg.Types {
+		decls[i] = t.Decl
+	}
+	lookup := typeSpecLookup(decls)
+	newPkg.Types = make([]*Type, len(pkg.Types))
+	for i, t := range pkg.Types {
+		newPkg.Types[i] = &Type{
+			Name:              t.Name,
+			PackageName:       pkg.Name,
+			PackageImportPath: pkg.ImportPath,
+			Type:              "type",
+			Consts:            c.CopyValues(t.Consts),
+			Doc:               t.Doc,
+			Funcs:             c.CopyFuncs(t.Funcs),
+			Methods:           c.CopyFuncs(t.Methods),
+			Vars:              c.CopyValues(t.Vars),
+		}
+		if ts := typeSpec(t.Decl, t.Name); ts != nil {
+			position := c.FileSet.Position(ts.Name.Pos())
+			newPkg.Types[i].Filename, newPkg.Types[i].Line = position.Filename, position.Line
+			if t.Decl.Lparen.IsValid() {
+				newPkg.Types[i].Offset, newPkg.Types[i].EndOffset = c.offsets(ts.Pos(), ts.End())
+			} else {
+				newPkg.Types[i].Offset, newPkg.Types[i].EndOffset = c.offsets(t.Decl.Pos(), t.Decl.End())
+			}
+			newPkg.Types[i].Page = pageOf(c.Comments[ts])
+			newPkg.Types[i].Directives = directivesOf(c.Comments[ts])
+			newPkg.Types[i].Kind = typeKind(ts, lookup)
+			newPkg.Types[i].Underlying = types.ExprString(ts.Type)
+			newPkg.Types[i].TypeParams = typeParams(ts)
+			if ts.TypeParams != nil {
+				newPkg.Types[i].Instantiations = instantiationsOf(ts.TypeParams, c.ImportPaths)
+			}
+			newPkg.Types[i].Instantiations = append(newPkg.Types[i].Instantiations, instantiationsOf(ts.Type, c.ImportPaths)...)
+			if ts.Assign.IsValid() {
+				newPkg.Types[i].IsAlias = true
+				newPkg.Types[i].AliasOf = newPkg.Types[i].Underlying
+			}
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				newPkg.Types[i].Fields = c.CopyFields(st)
+			}
+			if c.Options.Source {
+				newPkg.Types[i].Source = c.typeSource(ts)
+			}
+		}
+	}
+
+	newPkg.Vars = c.CopyValues(pkg.Vars)
+	return newPkg
+}
+
+// GetExcludeFilter returns a filter for parser.ParseDir excluding the files
+// whose name matches any of the regular expressions patterns, or nil if
+// there is none.
+func GetExcludeFilter(patterns ...string) (func(os.FileInfo) bool, error) {
+	var res []*regexp.Regexp
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclusion pattern %q: %s", pattern, err)
+		}
+		res = append(res, re)
+	}
+	if len(res) == 0 {
+		// Returning nil by default results no filtering
+		return nil, nil
+	}
+	return func(info os.FileInfo) bool {
+		for _, re := range res {
+			if re.MatchString(info.Name()) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// Options controls how packages are parsed and documented.
+//
+// Extract and ParseDirectoryPackages are safe for concurrent use,
+// including with the same Options: every call parses its files into its
+// own token.FileSet, and the Cache and Imports fields may be shared between
+// calls. Every call reports its warnings to its own Warn function and
+// imports packages with its own Toolchain.
+type Options struct {
+	// Filter selects the files to parse; nil selects all files.
+	Filter func(os.FileInfo) bool
+	// ResolveEmbedded lists the methods promoted from embedded types of
+	// other packages, which requires importing those packages.
+	ResolveEmbedded bool
+	// SigWidth is the length beyond which function signatures are written
+	// with one parameter per line; 0 never wraps them.
+	SigWidth int
+	// Cache, if set, stores the documentation of packages and reuses it
+	// while their files do not change.
+	Cache *Cache
+	// Imports, if set, holds the packages imported by ResolveEmbedded
+	// across calls; otherwise each call imports them again. Calls sharing
+	// it should select the same Toolchain and build context.
+	Imports *ImportCache
+	// Mode holds the go/doc mode bits used to document packages, e.g.
+	// doc.AllDecls to include unexported declarations.
+	Mode doc.Mode
+	// Readme includes the README file of the package directory.
+	Readme bool
+	// VCS records the revision of the enclosing git repository in the
+	// metadata of packages.
+	VCS bool
+	// Source includes the declaration of every symbol, as printed by gofmt.
+	Source bool
+	// Bodies includes the body of every function as written, which implies
+	// the doc.PreserveAST mode.
+	Bodies bool
+	// SkipGenerated ignores files marked with the standard
+	// "// Code generated ... DO NOT EDIT." comment.
+	SkipGenerated bool
+	// Notes lists the upper-case note markers, such as TODO, collected
+	// from comments in any case and with or without a uid. DropUnknownNotes
+	// drops the notes of other markers found by go/doc.
+	Notes            []string
+	DropUnknownNotes bool
+	// KeepGoing skips the files with syntax errors, reported as the
+	// diagnostics of the package, instead of failing.
+	KeepGoing bool
+	// ExcludeTests ignores _test.go files. Otherwise the external test
+	// package of a directory, if any, is documented too.
+	ExcludeTests bool
+	// IncludeSymbols, if set, keeps only the symbols whose name matches,
+	// and ExcludeSymbols, if set, removes those whose name matches. Methods
+	// are named "Type.Method".
+	IncludeSymbols, ExcludeSymbols *regexp.Regexp
+	// MethodSets computes the method sets of T and *T for every type T,
+	// which requires type-checking the package and importing its
+	// dependencies.
+	MethodSets bool
+	// Implementations, if set, lists the implementations of interfaces
+	// among the documented packages on their types.
+	Implementations *Implementations
+	// GOOS, GOARCH and BuildTags, if any is set, select the files whose
+	// build constraints and file name suffixes, such as _windows.go, match
+	// them, like the go command does. Empty GOOS and GOARCH stand for those
+	// of build.Default. Otherwise every file is parsed.
+	GOOS, GOARCH string
+	BuildTags    []string
+	// Platforms, if set, documents the package once for each platform, in
+	// place of GOOS and GOARCH, and merges the results, listing on the
+	// symbols missing on some platforms those declaring them.
+	Platforms []Platform
+	// ParamDocs selects the heuristics extracting the descriptions of
+	// parameters from the doc comment of functions; 0 extracts none.
+	ParamDocs ParamDocHeuristics
+	// Overlay, if set, replaces the contents of the source files keyed by
+	// their absolute path, such as the unsaved buffers of an editor, and
+	// adds those missing; files with nil contents are deleted. See
+	// ReadOverlay.
+	Overlay map[string][]byte
+	// Toolchain, if set, is the Go installation whose standard library is
+	// imported from source, checked with its language version. Otherwise
+	// the GOROOT of build.Default is used.
+	Toolchain *Toolchain
+	// Warn, if set, receives the warnings about problems that make the
+	// documentation incomplete without preventing it from being produced.
+	// Otherwise they are written to the standard logger. Concurrent calls
+	// sharing it call it concurrently.
+	Warn func(message string)
+
+	fsys fs.FS // set by ParseFS
+}
+
+// files returns the source files read with opts.
+func (opts Options) files() sourceFS {
+	return sourceFS{opts.fsys, opts.Overlay}
+}
+
+// BuildContext returns the build context selecting files, or nil if every
+// file is parsed.
+func (opts Options) BuildContext() *build.Context {
+	if opts.GOOS == "" && opts.GOARCH == "" && len(opts.BuildTags) == 0 {
+		return nil
+	}
+	ctx := build.Default
+	if opts.GOOS != "" {
+		ctx.GOOS = opts.GOOS
+	}
+	if opts.GOARCH != "" {
+		ctx.GOARCH = opts.GOARCH
+	}
+	if (ctx.GOOS != build.Default.GOOS || ctx.GOARCH != build.Default.GOARCH) && os.Getenv("CGO_ENABLED") != "1" {
+		// The go command disables cgo when cross-compiling.
+		ctx.CgoEnabled = false
+	}
+	ctx.BuildTags = opts.BuildTags
+	return &ctx
+}
+
+// ImportContext returns the build context locating the packages imported
+// from source: that of BuildContext, or build.Default, with the GOROOT of
+// Toolchain if set.
+func (opts Options) ImportContext() *build.Context {
+	ctx := opts.BuildContext()
+	if ctx == nil {
+		defaultCtx := build.Default
+		ctx = &defaultCtx
+	}
+	if opts.Toolchain != nil {
+		ctx.GOROOT = opts.Toolchain.GOROOT
+	}
+	return ctx
+}
+
+// goVersion returns the language version packages are type-checked with,
+// that of Toolchain, or "" for the latest one.
+func (opts Options) goVersion() string {
+	if opts.Toolchain == nil || opts.Toolchain.GoVersion == "" {
+		return ""
+	}
+	return opts.Toolchain.LanguageVersion()
+}
+
+// FileFilter returns the filter selecting the files of directory to parse.
+func (opts Options) FileFilter(directory string) func(os.FileInfo) bool {
+	ctx := opts.BuildContext()
+	if !opts.ExcludeTests && !opts.SkipGenerated && ctx == nil {
+		return opts.Filter
+	}
+	if ctx != nil {
+		ctx = opts.files().context(ctx)
+	}
+	return func(info os.FileInfo) bool {
+		if opts.ExcludeTests && strings.HasSuffix(info.Name(), "_test.go") {
+			return false
+		}
+		if opts.Filter != nil && !opts.Filter(info) {
+			return false
+		}
+		if ctx != nil {
+			if match, err := ctx.MatchFile(directory, info.Name()); err == nil && !match {
+				return false
+			}
+		}
+		return !opts.SkipGenerated || !isGenerated(opts.files(), filepath.Join(directory, info.Name()))
+	}
+}
+
+// isGenerated reports whether the Go file name starts with a
+// "// Code generated ... DO NOT EDIT." comment.
+func isGenerated(files sourceFS, name string) bool {
+	src, err := files.readFile(name)
+	if err != nil {
+		return false
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.PackageClauseOnly|parser.ParseComments)
+	// Files that fail to parse are left to report their errors.
+	return err == nil && ast.IsGenerated(f)
+}
+
+// Extract parses the Go package in directory and returns its
+// documentation, or nil if the directory contains no Go files or is
+// excluded by a marker file. External test packages are only returned for
+// directories holding no other package; see ParseDirectoryPackages.
+func Extract(directory string, opts Options) (*Package, error) {
+	pkgs, err := ParseDirectoryPackages(directory, opts)
+	if err != nil || len(pkgs) == 0 {
+		return nil, err
+	}
+	return pkgs[0], nil
+}
+
+// ParseDirectoryPackages parses the Go package in directory, followed by
+// its external test package unless opts.ExcludeTests is set, and returns
+// their documentation. It returns no packages if the directory contains no
+// Go files or is excluded by a marker file.
+func ParseDirectoryPackages(directory string, opts Options) ([]*Package, error) {
+	if len(opts.Platforms) > 0 {
+		return parsePlatformPackages(directory, opts)
+	}
+	marker, err := opts.files().readMarker(directory)
+	if err != nil {
+		return nil, err
+	}
+	if marker != nil && marker.Exclude {
+		return nil, nil
+	}
+	pkgs, err := parseCachedDirectory(directory, opts)
+	if err != nil || len(pkgs) == 0 {
+		return nil, err
+	}
+	// Read after the cache, which does not track changes to go.mod,
+	// license and README files, and repositories.
+	var module *Module
+	var license *License
+	if root, _ := opts.files().findModule(directory); root != "" {
+		if module, err = opts.files().readModule(root); err != nil {
+			return nil, err
+		}
+		license = opts.files().detectLicense(root)
+	}
+	var vcs *VCS
+	if opts.VCS {
+		vcs = readVCS(directory, opts)
+	}
+	var readme string
+	if opts.Readme {
+		if readme, err = opts.files().readReadme(directory); err != nil {
+			return nil, err
+		}
+	}
+	for _, pkg := range pkgs {
+		pkg.Module, pkg.License, pkg.Readme = module, license, readme
+		if pkg.Metadata != nil {
+			pkg.Metadata.VCS = vcs
+		}
+		if marker != nil {
+			marker.apply(pkg)
+		}
+		opts.Implementations.apply(directory, pkg)
+		filterSymbols(pkg, opts.IncludeSymbols, opts.ExcludeSymbols)
+	}
+	return pkgs, nil
+}
+
+// parseCachedDirectory is parseDirectory going through opts.Cache, if set.
+// The warnings of cached packages are reported again when they are reused.
+func parseCachedDirectory(directory string, opts Options) ([]*Package, error) {
+	if opts.Cache == nil {
+		return parseDirectory(directory, opts)
+	}
+	key, err := opts.Cache.key(directory, opts)
+	if err != nil {
+		return nil, err
+	}
+	if entry := opts.Cache.get(key); entry != nil {
+		for _, warning := range entry.Warnings {
+			opts.warnf("%s", warning)
+		}
+		return entry.Packages, nil
+	}
+	var warnings []string
+	recorded := opts
+	recorded.Warn = func(message string) {
+		warnings = append(warnings, message)
+		opts.warnf("%s", message)
+	}
+	pkgs, err := parseDirectory(directory, recorded)
+	if err != nil || len(pkgs) == 0 {
+		return pkgs, err
+	}
+	if err := opts.Cache.put(key, &cacheEntry{Packages: pkgs, Warnings: warnings}); err != nil {
+		opts.warnf("cannot cache %s: %s", directory, err)
+	}
+	return pkgs, nil
+}
+
+// ParseDir parses the files of directory documented by
+// ParseDirectoryPackages with opts, with their comments, and returns their
+// packages by name. Packages whose files are all excluded by their build
+// constraints are left out when there are others. With opts.KeepGoing,
+// files with syntax errors are skipped.
+func ParseDir(fileSet *token.FileSet, directory string, opts Options) (map[string]*ast.Package, error) {
+	astPkgs, _, err := parseDir(fileSet, directory, opts)
+	return astPkgs, err
+}
+
+// parseDir is ParseDir, also returning the syntax errors of the files
+// skipped with opts.KeepGoing.
+func parseDir(fileSet *token.FileSet, directory string, opts Options) (map[string]*ast.Package, []*SourceError, error) {
+	var astPkgs map[string]*ast.Package
+	var diagnostics []*SourceError
+	if opts.KeepGoing || opts.fsys != nil || opts.Overlay != nil {
+		var err error
+		if astPkgs, diagnostics, err = parseDirFiles(fileSet, directory, opts); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var firstError error
+		astPkgs, firstError = parser.ParseDir(fileSet, directory, opts.FileFilter(directory), parser.ParseComments|parser.AllErrors)
+		if firstError != nil {
+			return nil, nil, firstError
+		}
+	}
+	if len(astPkgs) > 1 {
+		dropIgnoredPackages(astPkgs, directory, opts)
+	}
+	return astPkgs, diagnostics, nil
+}
+
+// parseDirectory documents the package in directory and its external test
+// package, in that order.
+func parseDirectory(directory string, opts Options) ([]*Package, error) {
+	fileSet := token.NewFileSet()
+	astPkgs, diagnostics, err := parseDir(fileSet, directory, opts)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(astPkgs))
+	for name := range astPkgs {
+		names = append(names, name)
+	}
+	// The external test package foo_test sorts after foo.
+	sort.Strings(names)
+	if len(names) > 2 || len(names) == 2 && names[1] != names[0]+"_test" {
+		return nil, fmt.Errorf("multiple packages found in directory %s", directory)
+	}
+	// Collected before doc.New, which removes function bodies.
+	examples := collectExamples(fileSet, astPkgs)
+	var pkgs []*Package
+	for _, name := range names {
+		pkg := astPkgs[name]
+		dropDuplicateFuncs(pkg)
+		comments := CollectDeclComments(pkg)
+		// Collected before doc.New, which removes function bodies.
+		funcEnds := CollectFuncEnds(pkg)
+		// Collected before doc.New, which removes unexported constants.
+		enums := collectEnums(pkg)
+		// Collected before doc.New, which removes comments from the AST.
+		constraints := collectBuildConstraints(pkg)
+		embeds := collectEmbeds(pkg, fileSet)
+		// Collected before doc.New, which removes function bodies.
+		flags := collectCommandFlags(pkg)
+		cgo := usesCgo(pkg)
+		var cgoExports []*CgoExport
+		if cgo {
+			cgoExports = collectCgoExports(pkg, fileSet, opts.SigWidth)
+		}
+		var notes map[string][]*Note
+		if len(opts.Notes) > 0 {
+			notes = newNoteMarkers(opts.Notes).collect(pkg)
+		}
+		// Resolved before doc.New, which filters the AST.
+		importPaths := resolveImportPaths(pkg, fileSet)
+		var typesPkg *types.Package
+		if opts.MethodSets {
+			typesPkg = checkPackage(pkg, fileSet, directory, opts)
+		}
+		mode := opts.Mode
+		if opts.Bodies {
+			mode |= doc.PreserveAST
+		}
+		docPkg := doc.New(pkg, directory, mode)
+		if strings.HasSuffix(name, "_test") && hasExternalTests(pkg) {
+			// Named like go list names external test packages. Without a
+			// known import path, the absolute directory keeps "." from
+			// turning into "._test".
+			importPath, ok := opts.files().resolveImportPath(directory)
+			if !ok {
+				importPath = directory
+				if abs, err := opts.files().abs(directory); err == nil {
+					importPath = filepath.ToSlash(abs)
+				}
+			}
+			docPkg.ImportPath = importPath + "_test"
+		}
+		copier := NewCopier(docPkg, fileSet, comments, opts)
+		copier.FuncEnds = funcEnds
+		copier.ImportPaths = importPaths
+		cleanedPkg := copier.CopyPackage(docPkg)
+		cleanedPkg.Services = detectServices(docPkg)
+		cleanedPkg.Errors = detectSentinelErrors(docPkg, fileSet)
+		cleanedPkg.Embeds = embeds
+		cleanedPkg.Flags = flags
+		cleanedPkg.Cgo, cleanedPkg.CgoExports = cgo, cgoExports
+		attachEnums(&cleanedPkg, docPkg, enums)
+		setBuildConstraints(&cleanedPkg, constraints)
+		if opts.ParamDocs != 0 {
+			setParamDocs(&cleanedPkg, opts.ParamDocs)
+		}
+		cleanedPkg.Metadata = &Metadata{Mode: modeNames(mode), Build: buildConstraintsOf(opts.BuildContext()), Tool: ReadBuildInfo()}
+		if importPath, ok := opts.files().resolveImportPath(directory); ok {
+			setImports(&cleanedPkg, importPath, opts.ImportContext().GOROOT)
+		}
+		if len(opts.Notes) > 0 || opts.DropUnknownNotes {
+			cleanedPkg.Notes = filterNotes(cleanedPkg.Notes, notes, opts.Notes, opts.DropUnknownNotes)
+		}
+		setNotePositions(&cleanedPkg, fileSet)
+		if opts.ResolveEmbedded {
+			resolvePromotedMethods(&cleanedPkg, docPkg, pkg, fileSet, directory, opts)
+		}
+		if opts.MethodSets {
+			setMethodSets(&cleanedPkg, typesPkg, opts)
+		}
+		if len(pkgs) == 0 {
+			// Examples of the external test package document this package.
+			attachExamples(&cleanedPkg, fileSet, examples, opts)
+			cleanedPkg.Diagnostics = diagnostics
+		}
+		pkgs = append(pkgs, &cleanedPkg)
+	}
+	return pkgs, nil
+}
+
+// parseDirFiles is parser.ParseDir reading the files of opts. With
+// opts.KeepGoing, it skips the files with syntax errors instead of failing;
+// their errors are returned as diagnostics, and reported as warnings.
+func parseDirFiles(fileSet *token.FileSet, directory string, opts Options) (map[string]*ast.Package, []*SourceError, error) {
+	filter := opts.FileFilter(directory)
+	entries, err := opts.files().readDir(directory)
+	if err != nil {
+		return nil, nil, err
+	}
+	astPkgs := map[string]*ast.Package{}
+	var diagnostics []*SourceError
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		if filter != nil {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, nil, err
+			}
+			if !filter(info) {
+				continue
+			}
+		}
+		filename := filepath.Join(directory, entry.Name())
+		src, err := opts.files().readFile(filename)
+		if err != nil {
+			return nil, nil, err
+		}
+		file, err := parser.ParseFile(fileSet, filename, src, parser.ParseComments|parser.AllErrors)
+		if err != nil {
+			doc := NewErrorDocument(err)
+			if doc == nil || !opts.KeepGoing {
+				return nil, nil, err
+			}
+			opts.warnf("%s has syntax errors, skipped", filename)
+			diagnostics = append(diagnostics, doc.Errors...)
+			continue
+		}
+		name := file.Name.Name
+		pkg, ok := astPkgs[name]
+		if !ok {
+			pkg = &ast.Package{Name: name, Files: map[string]*ast.File{}}
+			astPkgs[name] = pkg
+		}
+		pkg.Files[filename] = file
+	}
+	return astPkgs, diagnostics, nil
+}
+
+// dropIgnoredPackages removes from astPkgs the packages whose files are all
+// excluded by their build constraints, such as the package main of programs
+// tagged "//go:build ignore" that generate the code of a package.
+func dropIgnoredPackages(astPkgs map[string]*ast.Package, directory string, opts Options) {
+	ctx := opts.files().context(opts.ImportContext())
+	for name, pkg := range astPkgs {
+		ignored := true
+		for filename := range pkg.Files {
+			if match, err := ctx.MatchFile(directory, filepath.Base(filename)); err != nil || match {
+				ignored = false
+				break
+			}
+		}
+		if ignored {
+			delete(astPkgs, name)
+		}
+	}
+}
+
+// hasExternalTests reports whether every file of pkg is a _test.go file.
+func hasExternalTests(pkg *ast.Package) bool {
+	for name := range pkg.Files {
+		if !strings.HasSuffix(name, "_test.go") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// extractSource documents the package of src, written to p.go in a new
+// directory.
+func extractSource(t *testing.T, src string) *Package {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := Extract(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+// writeFiles writes files, by slash-separated name, below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInstantiationsOf(t *testing.T) {
+	for _, test := range []struct {
+		expr string
+		want []string // type, package.generic and type arguments
+	}{
+		{"int", nil},
+		{"List[string]", []string{"List[string] .List string"}},
+		{"list.List[*T]", []string{"list.List[*T] list.List *T"}},
+		{"Map[K,V]", []string{"Map[K,V] .Map K V"}},
+		{"[]Set[Pair[int,bool]]", []string{"Set[Pair[int,bool]] .Set Pair[int,bool]", "Pair[int,bool] .Pair int bool"}},
+		{"*Opt[T]", []string{"Opt[T] .Opt T"}},
+	} {
+		x, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := TypeOf(x); got != test.expr {
+			t.Errorf("TypeOf(%s) = %s", test.expr, got)
+		}
+		var got []string
+		for _, inst := range instantiationsOf(x, nil) {
+			got = append(got, fmt.Sprintf("%s %s.%s %s", inst.Type, inst.Package, inst.Generic, strings.Join(inst.TypeArgs, " ")))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("instantiationsOf(%s) = %q, want %q", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestGenericInstantiations(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+import (
+	"container/list"
+	yaml "gopkg.in/yaml.v3"
+	"example.com/go-set/v2"
+)
+
+type Number interface{ ~int | ~float64 }
+
+type List[T any, N Number] struct {
+	Pair[T, N]
+	Items list.List[T]
+	Node  yaml.Node[N]
+}
+
+type Pair[K comparable, V any] struct{}
+
+type Set set.Set[string]
+
+var Empty = Pair[int, bool]{}
+`)
+	inst := func(insts []*Instantiation) string {
+		var got []string
+		for _, inst := range insts {
+			got = append(got, fmt.Sprintf("%s %s.%s %s", inst.Type, inst.Package, inst.Generic, strings.Join(inst.TypeArgs, " ")))
+		}
+		return strings.Join(got, "|")
+	}
+	types := map[string]*Type{}
+	for _, typ := range pkg.Types {
+		types[typ.Name] = typ
+	}
+	var params []string
+	for _, param := range types["List"].TypeParams {
+		params = append(params, param.Name+" "+param.Constraint)
+	}
+	if got, want := strings.Join(params, "|"), "T any|N Number"; got != want {
+		t.Errorf("List type parameters = %q, want %q", got, want)
+	}
+	for _, test := range []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"type List", inst(types["List"].Instantiations), "Pair[T,N] .Pair T N|list.List[T] container/list.List T|yaml.Node[N] gopkg.in/yaml.v3.Node N"},
+		{"type Set", inst(types["Set"].Instantiations), "set.Set[string] example.com/go-set/v2.Set string"},
+		{"var Empty", inst(pkg.Vars[0].Instantiations), "Pair[int,bool] .Pair int bool"},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s instantiations = %q, want %q", test.name, test.got, test.want)
+		}
+	}
+	var fields []string
+	for _, f := range types["List"].Fields {
+		fields = append(fields, f.Name+" "+inst(f.Instantiations))
+	}
+	if got, want := strings.Join(fields, "|"), "Pair Pair[T,N] .Pair T N|Items list.List[T] container/list.List T|Node yaml.Node[N] gopkg.in/yaml.v3.Node N"; got != want {
+		t.Errorf("List fields = %q, want %q", got, want)
+	}
+}
+
+func TestAssumedPackageName(t *testing.T) {
+	for _, test := range []struct {
+		importPath string
+		want       string
+	}{
+		{"fmt", "fmt"},
+		{"container/list", "list"},
+		{"gopkg.in/yaml.v3", "yaml"},
+		{"example.com/mod/v2", "mod"},
+		{"github.com/mattn/go-sqlite3", "sqlite3"},
+		{"example.com/go-set/v2", "set"},
+		{"example.com/kebab-case", "kebab"},
+	} {
+		if got := assumedPackageName(test.importPath); got != test.want {
+			t.Errorf("assumedPackageName(%q) = %q, want %q", test.importPath, got, test.want)
+		}
+	}
+}
+
+func TestChannelsOf(t *testing.T) {
+	for _, test := range []struct {
+		expr string
+		want []string // type, direction, element, package.elemType
+	}{
+		{"int", nil},
+		{"chan int", []string{"chan int|both|int|."}},
+		{"<-chan *Event", []string{"<-chan *Event|recv|*Event|.Event"}},
+		{"chan<- []pkg.Msg", []string{"chan<- []pkg.Msg|send|[]pkg.Msg|pkg.Msg"}},
+		{"chan List[T]", []string{"chan List[T]|both|List[T]|.List"}},
+		{"chan error", []string{"chan error|both|error|."}},
+		{"func(chan chan bool)", []string{"chan chan bool|both|chan bool|.", "chan bool|both|bool|."}},
+	} {
+		x, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, ch := range channelsOf(x) {
+			got = append(got, fmt.Sprintf("%s|%s|%s|%s.%s", ch.Type, ch.Dir, ch.Elem, ch.Package, ch.ElemType))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("channelsOf(%s) = %q, want %q", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestEmbeddingLevel(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+// T embeds inner, whose methods are promoted.
+type T struct {
+	inner
+}
+
+type inner struct{}
+
+// M is promoted to T.
+func (inner) M() {}
+
+// N is declared by T.
+func (T) N() {}
+`)
+	var got []string
+	for _, m := range pkg.Types[0].Methods {
+		got = append(got, fmt.Sprintf("%s %d %s", m.Name, m.Level, m.Orig))
+	}
+	if want := []string{"M 1 inner", "N 0 T"}; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got methods %q, want %q", got, want)
+	}
+	b, err := json.Marshal(pkg.Types[0].Methods[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"level":1`) {
+		t.Errorf("level missing from %s", b)
+	}
+}
+
+func TestGetExcludeFilter(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		excluded []string
+		included []string
+	}{
+		{[]string{"_gen.go$"}, []string{"a_gen.go"}, []string{"a.go"}},
+		{[]string{"^z", "[,;]"}, []string{"z.go", "a,b.go", "a;b.go"}, []string{"a.go"}},
+		{[]string{"", "x{2,}"}, []string{"axx.go"}, []string{"ax.go"}},
+	}
+	for _, test := range tests {
+		filter, err := GetExcludeFilter(test.patterns...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range test.excluded {
+			if filter(fileInfo(name)) {
+				t.Errorf("%q does not exclude %s", test.patterns, name)
+			}
+		}
+		for _, name := range test.included {
+			if !filter(fileInfo(name)) {
+				t.Errorf("%q excludes %s", test.patterns, name)
+			}
+		}
+	}
+	if filter, err := GetExcludeFilter(); filter != nil || err != nil {
+		t.Errorf("got a filter or error %v without patterns", err)
+	}
+	if _, err := GetExcludeFilter("a", "("); err == nil {
+		t.Error("got no error for an invalid pattern")
+	}
+}
+
+// fileInfo is the os.FileInfo of a file named name.
+type fileInfo string
+
+func (name fileInfo) Name() string       { return string(name) }
+func (name fileInfo) Size() int64        { return 0 }
+func (name fileInfo) Mode() os.FileMode  { return 0644 }
+func (name fileInfo) ModTime() time.Time { return time.Time{} }
+func (name fileInfo) IsDir() bool        { return false }
+func (name fileInfo) Sys() interface{}   { return nil }
+
+// TestParseDirectoryPackagesConcurrently documents packages concurrently,
+// half of them with a toolchain whose io.Reader declares another method,
+// and checks that every call reports its own warnings and resolves
+// embedded types with its own toolchain.
+func TestParseDirectoryPackagesConcurrently(t *testing.T) {
+	root := t.TempDir()
+	fake := &Toolchain{GOROOT: filepath.Join(root, "goroot"), GoVersion: "go1.22.1"}
+	writeFiles(t, fake.GOROOT, map[string]string{
+		"src/io/io.go": "package io\n\ntype Reader interface {\n\tFakeRead() error\n}\n",
+	})
+
+	const n = 8
+	directories := make([]string, n)
+	for i := range directories {
+		directories[i] = filepath.Join(root, fmt.Sprintf("p%d", i))
+		writeFiles(t, directories[i], map[string]string{
+			"p.go":   "package p\n\nimport \"io\"\n\n// T reads.\ntype T struct {\n\tio.Reader\n}\n",
+			"bad.go": "package p\n\nfunc {\n",
+		})
+	}
+	goroot, env := build.Default.GOROOT, os.Getenv("GOROOT")
+
+	warnings := make([][]string, n)
+	pkgs := make([][]*Package, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i, directory := range directories {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := Options{
+				KeepGoing:       true,
+				ResolveEmbedded: true,
+				Warn:            func(message string) { warnings[i] = append(warnings[i], message) },
+			}
+			if i%2 == 1 {
+				opts.Toolchain = fake
+			}
+			pkgs[i], errs[i] = ParseDirectoryPackages(directory, opts)
+		}()
+	}
+	wg.Wait()
+
+	for i, directory := range directories {
+		if errs[i] != nil {
+			t.Fatalf("%s: %s", directory, errs[i])
+		}
+		want := filepath.Join(directory, "bad.go") + " has syntax errors, skipped"
+		if len(warnings[i]) != 1 || warnings[i][0] != want {
+			t.Errorf("%s: got warnings %q, want %q", directory, warnings[i], want)
+		}
+		method := "Read"
+		if i%2 == 1 {
+			method = "FakeRead"
+		}
+		promoted := pkgs[i][0].Types[0].PromotedMethods
+		if len(promoted) != 1 || promoted[0].Name != method {
+			t.Errorf("%s: got promoted methods %+v, want %s", directory, promoted, method)
+		}
+	}
+	if build.Default.GOROOT != goroot || os.Getenv("GOROOT") != env {
+		t.Errorf("the GOROOT of the process changed")
+	}
+}
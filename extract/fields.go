@@ -0,0 +1,128 @@
+package extract
+
+import (
+	"go/ast"
+	"strconv"
+)
+
+// Field represents a field of a struct type.
+type Field struct {
+	Name           string            `json:"name"`
+	Embedded       bool              `json:"embedded"` // embedded field, named after its type
+	Type           string            `json:"type"`
+	Doc            string            `json:"doc"`
+	Comment        string            `json:"comment,omitempty"` // line comment
+	Tag            string            `json:"tag,omitempty"`     // raw tag, without quotes
+	Tags           map[string]string `json:"tags,omitempty"`    // tag values by key, e.g. "json": "name,omitempty"
+	Filename       string            `json:"filename"`
+	Line           int               `json:"line"`
+	Instantiations []*Instantiation  `json:"instantiations,omitempty"` // generic types instantiated in Type
+	Channels       []*Channel        `json:"channels,omitempty"`       // channel types found in Type
+}
+
+// CopyFields produces json-annotated Field objects from the fields of a
+// struct type. go/doc has already removed its unexported fields.
+func (c *Copier) CopyFields(st *ast.StructType) []*Field {
+	fields := []*Field{}
+	for _, f := range st.Fields.List {
+		position := c.FileSet.Position(f.Pos())
+		field := Field{
+			Type:           TypeOf(f.Type),
+			Doc:            f.Doc.Text(),
+			Comment:        f.Comment.Text(),
+			Filename:       position.Filename,
+			Line:           position.Line,
+			Instantiations: instantiationsOf(f.Type, c.ImportPaths),
+			Channels:       channelsOf(f.Type),
+		}
+		if f.Tag != nil {
+			field.Tag, _ = strconv.Unquote(f.Tag.Value)
+			field.Tags = parseStructTag(field.Tag)
+		}
+		if len(f.Names) == 0 {
+			field.Name = embeddedName(f.Type)
+			field.Embedded = true
+			fields = append(fields, &field)
+			continue
+		}
+		for _, name := range f.Names {
+			named := field
+			named.Name = name.Name
+			fields = append(fields, &named)
+		}
+	}
+	return fields
+}
+
+// embeddedName returns the field name of an embedded type, e.g. "Mutex"
+// for *sync.Mutex.
+func embeddedName(x ast.Expr) string {
+	switch t := x.(type) {
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(t.X)
+	case *ast.IndexListExpr:
+		return embeddedName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return TypeOf(x)
+}
+
+// parseStructTag parses tag with the conventions of reflect.StructTag into
+// values by key. As with reflect.StructTag.Lookup, the first value of a
+// repeated key wins. Parsing stops at the first malformed pair, and nil is
+// returned when no pair could be parsed.
+func parseStructTag(tag string) map[string]string {
+	var tags map[string]string
+	for tag != "" {
+		// Skip leading space.
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		if tag == "" {
+			break
+		}
+
+		// Scan to colon. A space, a quote or a control character is a
+		// syntax error.
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Scan quoted string to find value.
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		value, err := strconv.Unquote(tag[:i+1])
+		if err != nil {
+			break
+		}
+		tag = tag[i+1:]
+		if tags == nil {
+			tags = map[string]string{}
+		}
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
+	}
+	return tags
+}
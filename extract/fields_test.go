@@ -0,0 +1,57 @@
+package extract
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseStructTag(t *testing.T) {
+	for _, test := range []struct {
+		tag  string
+		want map[string]string
+	}{
+		{``, nil},
+		{`json:"name,omitempty"`, map[string]string{"json": "name,omitempty"}},
+		{`json:"a" xml:"b"`, map[string]string{"json": "a", "xml": "b"}},
+		{`json:"a" json:"b"`, map[string]string{"json": "a"}},
+		{`json:"a\"b"`, map[string]string{"json": `a"b`}},
+		{`  yaml:"x"  `, map[string]string{"yaml": "x"}},
+		{`json:"a" bad xml:"b"`, map[string]string{"json": "a"}},
+		{`json:a`, nil},
+		{`json:"unterminated`, nil},
+	} {
+		if got := parseStructTag(test.tag); fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("parseStructTag(%q) = %v, want %v", test.tag, got, test.want)
+		}
+	}
+}
+
+func TestCopyFields(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+// T has fields.
+type T struct {
+	// A is documented.
+	A, B int `+"`json:\"ab\"`"+`
+	*Embedded
+	C chan<- string // C is commented.
+	hidden int
+}
+
+type Embedded struct{}
+`)
+	var got []string
+	for _, f := range pkg.Types[1].Fields {
+		got = append(got, fmt.Sprintf("%s %s %q %q %v %d", f.Name, f.Type, f.Doc+f.Comment, f.Tag, f.Tags, len(f.Channels)))
+	}
+	want := []string{
+		`A int "A is documented.\n" "json:\"ab\"" map[json:ab] 0`,
+		`B int "A is documented.\n" "json:\"ab\"" map[json:ab] 0`,
+		`Embedded *Embedded "" "" map[] 0`,
+		`C chan<- string "C is commented.\n" "" map[] 1`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got fields\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
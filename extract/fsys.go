@@ -0,0 +1,204 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// sourceFS reads the files of packages: from the operating system if fsys
+// is nil, as in the zero value, and otherwise from fsys, where paths are
+// relative to its root. The files of overlay, keyed by absolute path,
+// replace those read; nil contents delete them.
+type sourceFS struct {
+	fsys    fs.FS
+	overlay map[string][]byte
+}
+
+// fsName returns the name of the file path in fsys.
+func fsName(path string) string {
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// overlaid returns the contents of name in s.overlay, if any.
+func (s sourceFS) overlaid(name string) ([]byte, bool) {
+	if s.overlay == nil {
+		return nil, false
+	}
+	abs, err := s.abs(name)
+	if err != nil {
+		return nil, false
+	}
+	data, ok := s.overlay[abs]
+	return data, ok
+}
+
+func (s sourceFS) readFile(name string) ([]byte, error) {
+	if data, ok := s.overlaid(name); ok {
+		if data == nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		return data, nil
+	}
+	if s.fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(s.fsys, fsName(name))
+}
+
+func (s sourceFS) readDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	var err error
+	if s.fsys == nil {
+		entries, err = os.ReadDir(name)
+	} else {
+		entries, err = fs.ReadDir(s.fsys, fsName(name))
+	}
+	if s.overlay == nil || err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return entries, err
+	}
+	dir, absErr := s.abs(name)
+	if absErr != nil {
+		return entries, err
+	}
+	byName := map[string]fs.DirEntry{}
+	for _, entry := range entries {
+		byName[entry.Name()] = entry
+	}
+	for path, data := range s.overlay {
+		if filepath.Dir(path) != dir {
+			continue
+		}
+		if base := filepath.Base(path); data == nil {
+			delete(byName, base)
+		} else {
+			byName[base] = overlayFile{base, int64(len(data))}
+		}
+	}
+	if len(byName) == 0 {
+		// Directories holding no file of the overlay keep their error.
+		return entries, err
+	}
+	entries = entries[:0]
+	for _, entry := range byName {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (s sourceFS) stat(name string) (fs.FileInfo, error) {
+	if data, ok := s.overlaid(name); ok {
+		if data == nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+		}
+		return overlayFile{filepath.Base(name), int64(len(data))}, nil
+	}
+	if s.fsys == nil {
+		return os.Stat(name)
+	}
+	return fs.Stat(s.fsys, fsName(name))
+}
+
+// abs returns the absolute path of directory: relative to the current
+// directory for the operating system, and to the root of fsys otherwise.
+func (s sourceFS) abs(directory string) (string, error) {
+	if s.fsys == nil {
+		return filepath.Abs(directory)
+	}
+	return filepath.Clean(directory), nil
+}
+
+// context returns ctx, opening the files it matches with s.
+func (s sourceFS) context(ctx *build.Context) *build.Context {
+	if s.fsys == nil && s.overlay == nil {
+		return ctx
+	}
+	c := *ctx
+	c.OpenFile = func(name string) (io.ReadCloser, error) {
+		data, err := s.readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return &c
+}
+
+// overlayFile is the fs.DirEntry and fs.FileInfo of a file of an overlay.
+type overlayFile struct {
+	name string
+	size int64
+}
+
+func (f overlayFile) Name() string               { return f.name }
+func (f overlayFile) Size() int64                { return f.size }
+func (f overlayFile) Mode() fs.FileMode          { return 0644 }
+func (f overlayFile) Type() fs.FileMode          { return 0 }
+func (f overlayFile) ModTime() time.Time         { return time.Time{} }
+func (f overlayFile) IsDir() bool                { return false }
+func (f overlayFile) Sys() interface{}           { return nil }
+func (f overlayFile) Info() (fs.FileInfo, error) { return f, nil }
+
+// ReadOverlay reads an overlay in the JSON format of the -overlay flag of
+// the go command, {"Replace": {"file.go": "replacement.go"}}, mapping
+// source files to the file holding their contents, or to "" to delete
+// them. It returns the contents of the files keyed by absolute path, as
+// expected by Options.Overlay.
+func ReadOverlay(filename string) (map[string][]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var overlay struct {
+		Replace map[string]string
+	}
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("%s: %s", filename, err)
+	}
+	files := map[string][]byte{}
+	for path, replacement := range overlay.Replace {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		files[abs] = nil
+		if replacement == "" {
+			continue
+		}
+		if files[abs], err = os.ReadFile(replacement); err != nil {
+			return nil, err
+		}
+		if files[abs] == nil {
+			// Empty replacements are empty files, not deleted ones.
+			files[abs] = []byte{}
+		}
+	}
+	return files, nil
+}
+
+// ParseFS is ParseDirectoryPackages for the package in directory of fsys,
+// a slash-separated path relative to its root such as "." or "internal/x",
+// so that embedded sources, archives and test fixtures are documented
+// without touching the file system. Every file is read from fsys, including
+// the go.mod, license and README files, and file names are reported as
+// paths in fsys, which also key the files of opts.Overlay. The options
+// reading files or packages out of fsys are ignored: Cache, VCS,
+// ResolveEmbedded, MethodSets and Implementations.
+func ParseFS(fsys fs.FS, directory string, opts Options) ([]*Package, error) {
+	if !fs.ValidPath(directory) {
+		return nil, fmt.Errorf("invalid directory %q in file system", directory)
+	}
+	opts.fsys = fsys
+	opts.Cache, opts.VCS = nil, false
+	opts.ResolveEmbedded, opts.MethodSets, opts.Implementations = false, false, nil
+	return ParseDirectoryPackages(directory, opts)
+}
@@ -0,0 +1,111 @@
+package extract
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":          {Data: []byte("module example.com/m\n\ngo 1.22\n")},
+		"m.go":            {Data: []byte("// Package m is the root.\npackage m\n\n// F is a function.\nfunc F() {}\n")},
+		"m_test.go":       {Data: []byte("package m\n")},
+		"sub/sub.go":      {Data: []byte("// Package sub is below.\npackage sub\n\n// T is a type.\ntype T int\n")},
+		"sub/sub_arm.go":  {Data: []byte("package sub\n\n// Arm is for arm.\nconst Arm = 1\n")},
+		"empty/README.md": {Data: []byte("# Empty\n")},
+	}
+	tests := []struct {
+		directory  string
+		opts       Options
+		importPath string
+		filenames  []string
+		err        bool
+	}{
+		{".", Options{}, "example.com/m", []string{"m.go", "m_test.go"}, false},
+		{".", Options{ExcludeTests: true}, "example.com/m", []string{"m.go"}, false},
+		{"sub", Options{}, "example.com/m/sub", []string{"sub/sub.go", "sub/sub_arm.go"}, false},
+		{"sub", Options{GOOS: "linux", GOARCH: "amd64"}, "example.com/m/sub", []string{"sub/sub.go"}, false},
+		{"empty", Options{}, "", nil, true},
+		{"../x", Options{}, "", nil, true},
+		{"/sub", Options{}, "", nil, true},
+	}
+	for _, test := range tests {
+		pkgs, err := ParseFS(fsys, test.directory, test.opts)
+		if (err != nil || len(pkgs) == 0) != test.err {
+			t.Errorf("ParseFS(%q): got %d packages and error %v", test.directory, len(pkgs), err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		pkg := pkgs[0]
+		if pkg.Import == nil {
+			t.Errorf("ParseFS(%q): got no import path", test.directory)
+			continue
+		}
+		if pkg.Import.Path != test.importPath || !reflect.DeepEqual(pkg.Filenames, test.filenames) {
+			t.Errorf("ParseFS(%q): got import path %q and files %q, want %q and %q",
+				test.directory, pkg.Import.Path, pkg.Filenames, test.importPath, test.filenames)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"p/a.go":        "package p\n\n// A is on disk.\nfunc A() {}\n",
+		"p/b.go":        "package p\n\n// B is on disk.\nfunc B() {}\n",
+		"p/c.go":        "package p\n\n// C is on disk.\nfunc C() {}\n",
+		"buffers/a.go":  "package p\n\n// A is unsaved.\nfunc A() {}\n",
+		"buffers/d.go":  "package p\n\n// D is new.\nfunc D() {}\n",
+		"buffers/e.go":  "package e\n\n// E is in a new directory.\nfunc E() {}\n",
+		"buffers/empty": "",
+		"overlay.json": `{"Replace": {
+			"p/a.go": "buffers/a.go",
+			"p/b.go": "",
+			"p/c.go": "buffers/empty",
+			"p/d.go": "buffers/d.go",
+			"new/e.go": "buffers/e.go"
+		}}`,
+	})
+	t.Chdir(dir)
+	overlay, err := ReadOverlay("overlay.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := overlay[filepath.Join(dir, "p", "b.go")]; !ok || data != nil {
+		t.Errorf("got %q for the deleted b.go, want nil", data)
+	}
+	if data := overlay[filepath.Join(dir, "p", "c.go")]; data == nil || len(data) != 0 {
+		t.Errorf("got %q for the emptied c.go, want empty contents", data)
+	}
+
+	tests := []struct {
+		directory string
+		funcs     []string // "name doc"
+	}{
+		// The emptied c.go has no package clause and is skipped.
+		{"p", []string{"A A is unsaved.\n", "D D is new.\n"}},
+		{"new", []string{"E E is in a new directory.\n"}},
+	}
+	for _, test := range tests {
+		pkgs, err := ParseDirectoryPackages(test.directory, Options{Overlay: overlay, KeepGoing: true})
+		if err != nil {
+			t.Errorf("%s: %s", test.directory, err)
+			continue
+		}
+		if len(pkgs) != 1 {
+			t.Errorf("%s: got %d packages", test.directory, len(pkgs))
+			continue
+		}
+		var got []string
+		for _, f := range pkgs[0].Funcs {
+			got = append(got, f.Name+" "+f.Doc)
+		}
+		if !reflect.DeepEqual(got, test.funcs) {
+			t.Errorf("%s: got funcs %q, want %q", test.directory, got, test.funcs)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"go/build"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ModulePath returns the module path declared in directory/go.mod, or "" if
+// there is none.
+func ModulePath(directory string) string {
+	return sourceFS{}.modulePath(directory)
+}
+
+func (s sourceFS) modulePath(directory string) string {
+	data, err := s.readFile(filepath.Join(directory, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// FindModule returns the root directory and path of the module containing
+// directory, or empty strings if directory is not part of a module.
+func FindModule(directory string) (root string, modPath string) {
+	return sourceFS{}.findModule(directory)
+}
+
+func (s sourceFS) findModule(directory string) (root string, modPath string) {
+	dir, err := s.abs(directory)
+	if err != nil {
+		return "", ""
+	}
+	for {
+		if modPath := s.modulePath(dir); modPath != "" {
+			return dir, modPath
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", ""
+		}
+		dir = parent
+	}
+}
+
+// ImportPathOf returns the import path of the package in directory, derived
+// from the enclosing module or GOPATH directory. Otherwise, the
+// slash-separated directory is returned, identifying the package but not
+// importable; see ResolveImportPath.
+func ImportPathOf(directory string) string {
+	return sourceFS{}.importPathOf(directory)
+}
+
+func (s sourceFS) importPathOf(directory string) string {
+	if importPath, ok := s.resolveImportPath(directory); ok {
+		return importPath
+	}
+	return filepath.ToSlash(filepath.Clean(directory))
+}
+
+// ResolveImportPath returns the import path of the package in directory,
+// derived from the enclosing module, or else from the GOPATH directory
+// holding it, and whether it is known.
+func ResolveImportPath(directory string) (string, bool) {
+	return sourceFS{}.resolveImportPath(directory)
+}
+
+func (s sourceFS) resolveImportPath(directory string) (string, bool) {
+	abs, err := s.abs(directory)
+	if err != nil {
+		return "", false
+	}
+	root, modPath := s.findModule(directory)
+	if root == "" {
+		if s.fsys != nil {
+			return "", false
+		}
+		return gopathImportPath(abs)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == "." {
+		return modPath, true
+	}
+	if modPath == "std" {
+		// The standard library module has no import path prefix.
+		return filepath.ToSlash(rel), true
+	}
+	return path.Join(modPath, filepath.ToSlash(rel)), true
+}
+
+// gopathImportPath returns the import path of the directory dir, absolute,
+// if it is below the src directory of a GOPATH entry.
+func gopathImportPath(dir string) (string, bool) {
+	for _, gopath := range filepath.SplitList(build.Default.GOPATH) {
+		rel, err := filepath.Rel(filepath.Join(gopath, "src"), dir)
+		if err == nil && rel != "." && filepath.IsLocal(rel) {
+			return filepath.ToSlash(rel), true
+		}
+	}
+	return "", false
+}
+
+// Module holds the metadata of a module read from its go.mod file.
+type Module struct {
+	Path      string         `json:"path"`
+	GoVersion string         `json:"goVersion,omitempty"` // version of the go directive, e.g. "1.22"
+	Toolchain string         `json:"toolchain,omitempty"` // toolchain directive, e.g. "go1.22.1"
+	Require   []*Requirement `json:"require"`
+}
+
+// Requirement is a module required by a go.mod file.
+type Requirement struct {
+	Path     string `json:"path"`
+	Version  string `json:"version"`
+	Indirect bool   `json:"indirect"`
+}
+
+// readModule reads the go.mod file of the module rooted at directory.
+func (s sourceFS) readModule(directory string) (*Module, error) {
+	data, err := s.readFile(filepath.Join(directory, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	mod := &Module{Require: []*Requirement{}}
+	inRequire := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		indirect := false
+		if i := strings.Index(line, "//"); i >= 0 {
+			indirect = strings.TrimSpace(line[i+2:]) == "indirect"
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if inRequire {
+			if fields[0] == ")" {
+				inRequire = false
+			} else if len(fields) >= 2 {
+				mod.Require = append(mod.Require, &Requirement{Path: strings.Trim(fields[0], `"`), Version: fields[1], Indirect: indirect})
+			}
+			continue
+		}
+		switch {
+		case fields[0] == "module" && len(fields) >= 2:
+			mod.Path = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) >= 2:
+			mod.GoVersion = fields[1]
+		case fields[0] == "toolchain" && len(fields) >= 2:
+			mod.Toolchain = fields[1]
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+		case fields[0] == "require" && len(fields) >= 3:
+			mod.Require = append(mod.Require, &Requirement{Path: strings.Trim(fields[1], `"`), Version: fields[2], Indirect: indirect})
+		}
+	}
+	return mod, scanner.Err()
+}
@@ -0,0 +1,67 @@
+package extract
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writePackage writes a package p holding an exported function to dir.
+func writePackage(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte("package p\n\n// F does nothing.\nfunc F() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveImportPath(t *testing.T) {
+	root := t.TempDir()
+	gopath := filepath.Join(root, "gopath")
+	defer func(old string) { build.Default.GOPATH = old }(build.Default.GOPATH)
+	build.Default.GOPATH = gopath
+
+	mod := filepath.Join(root, "mod")
+	writePackage(t, filepath.Join(mod, "sub", "p"))
+	if err := os.WriteFile(filepath.Join(mod, "go.mod"), []byte("module example.com/mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	writePackage(t, filepath.Join(gopath, "src", "example.org", "p"))
+	writePackage(t, filepath.Join(root, "plain", "p"))
+
+	for _, test := range []struct {
+		dir  string
+		want string
+		ok   bool
+	}{
+		{filepath.Join(mod, "sub", "p"), "example.com/mod/sub/p", true},
+		{mod, "example.com/mod", true},
+		{filepath.Join(gopath, "src", "example.org", "p"), "example.org/p", true},
+		{filepath.Join(root, "plain", "p"), "", false},
+	} {
+		got, ok := ResolveImportPath(test.dir)
+		if got != test.want || ok != test.ok {
+			t.Errorf("ResolveImportPath(%s) = %q, %v, want %q, %v", test.dir, got, ok, test.want, test.ok)
+		}
+	}
+
+	// Outside of modules and GOPATH, no import is suggested: the directory
+	// name would be taken for a standard library package.
+	pkg, err := Extract(filepath.Join(root, "plain", "p"), Options{ExcludeTests: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Import != nil {
+		t.Errorf("got import %+v outside of modules and GOPATH, want none", pkg.Import)
+	}
+	pkg, err = Extract(filepath.Join(gopath, "src", "example.org", "p"), Options{ExcludeTests: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Import == nil || pkg.Import.Path != "example.org/p" {
+		t.Errorf("got import %+v in GOPATH, want example.org/p", pkg.Import)
+	}
+}
@@ -0,0 +1,211 @@
+package extract
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Service represents a gRPC service whose client and server were generated
+// by protoc-gen-go-grpc.
+type Service struct {
+	Name     string           `json:"name"`               // e.g. "Greeter"
+	FullName string           `json:"fullName,omitempty"` // fully-qualified protobuf name, e.g. "helloworld.Greeter"
+	Client   string           `json:"client"`             // client interface type, e.g. "GreeterClient"
+	Server   string           `json:"server"`             // server interface type, e.g. "GreeterServer"
+	Methods  []*ServiceMethod `json:"methods"`
+	Messages []string         `json:"messages"` // request and response types of the methods declared in the package
+}
+
+// ServiceMethod represents a method of a gRPC service.
+type ServiceMethod struct {
+	Name            string `json:"name"`
+	Request         string `json:"request"`  // request message type, e.g. "HelloRequest"
+	Response        string `json:"response"` // response message type, e.g. "HelloReply"
+	ClientStreaming bool   `json:"clientStreaming"`
+	ServerStreaming bool   `json:"serverStreaming"`
+}
+
+const grpcImportPath = "google.golang.org/grpc"
+
+// detectServices returns the gRPC services of a package generated by
+// protoc-gen-go-grpc, recognized by a FooClient interface, a FooServer
+// interface and a RegisterFooServer function.
+func detectServices(pkg *doc.Package) []*Service {
+	importsGRPC := false
+	for _, imp := range pkg.Imports {
+		importsGRPC = importsGRPC || imp == grpcImportPath
+	}
+	if !importsGRPC {
+		return nil
+	}
+
+	interfaces := map[string]*ast.InterfaceType{}
+	funcs := map[string]bool{}
+	for _, f := range pkg.Funcs {
+		funcs[f.Name] = true
+	}
+	for _, t := range pkg.Types {
+		if ts := typeSpec(t.Decl, t.Name); ts != nil {
+			if it, ok := ts.Type.(*ast.InterfaceType); ok {
+				interfaces[t.Name] = it
+			}
+		}
+		for _, f := range t.Funcs {
+			funcs[f.Name] = true
+		}
+	}
+	fullNames := serviceNames(pkg)
+
+	var services []*Service
+	for name, client := range interfaces {
+		service := strings.TrimSuffix(name, "Client")
+		if service == name || interfaces[service+"Server"] == nil || !funcs["Register"+service+"Server"] {
+			continue
+		}
+		s := &Service{
+			Name:     service,
+			FullName: fullNames[service],
+			Client:   name,
+			Server:   service + "Server",
+			Methods:  []*ServiceMethod{},
+			Messages: []string{},
+		}
+		messages := map[string]bool{}
+		for _, m := range client.Methods.List {
+			ft, ok := m.Type.(*ast.FuncType)
+			if !ok || len(m.Names) == 0 {
+				continue
+			}
+			method := serviceMethod(m.Names[0].Name, ft, interfaces)
+			s.Methods = append(s.Methods, method)
+			for _, msg := range []string{method.Request, method.Response} {
+				if msg != "" && !strings.Contains(msg, ".") && !messages[msg] {
+					messages[msg] = true
+					s.Messages = append(s.Messages, msg)
+				}
+			}
+		}
+		sort.Strings(s.Messages)
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// serviceMethod describes the client method name of type ft.
+func serviceMethod(name string, ft *ast.FuncType, interfaces map[string]*ast.InterfaceType) *ServiceMethod {
+	m := &ServiceMethod{Name: name}
+	params := ft.Params.List
+	// Unary and server streaming methods take (ctx, in *Request, opts...).
+	if len(params) == 3 {
+		m.Request = messageName(params[1].Type)
+	}
+	if ft.Results == nil || len(ft.Results.List) == 0 {
+		return m
+	}
+	result := ft.Results.List[0].Type
+	switch r := result.(type) {
+	case *ast.StarExpr:
+		m.Response = messageName(r)
+	case *ast.Ident:
+		// Stream interfaces of older protoc-gen-go-grpc versions, e.g.
+		// Greeter_SayHelloClient.
+		stream := interfaces[r.Name]
+		if stream == nil {
+			break
+		}
+		for _, sm := range stream.Methods.List {
+			sft, ok := sm.Type.(*ast.FuncType)
+			if !ok || len(sm.Names) == 0 {
+				continue
+			}
+			switch sm.Names[0].Name {
+			case "Send":
+				m.ClientStreaming = true
+				if len(sft.Params.List) > 0 {
+					m.Request = messageName(sft.Params.List[0].Type)
+				}
+			case "Recv":
+				m.ServerStreaming = true
+				fallthrough
+			case "CloseAndRecv":
+				if sft.Results != nil && len(sft.Results.List) > 0 {
+					m.Response = messageName(sft.Results.List[0].Type)
+				}
+			}
+		}
+	default:
+		// Generic stream types of newer protoc-gen-go-grpc versions, e.g.
+		// grpc.BidiStreamingClient[Request, Response].
+		var base ast.Expr
+		var args []ast.Expr
+		switch r := result.(type) {
+		case *ast.IndexExpr:
+			base, args = r.X, []ast.Expr{r.Index}
+		case *ast.IndexListExpr:
+			base, args = r.X, r.Indices
+		}
+		sel, ok := base.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		switch sel.Sel.Name {
+		case "ServerStreamingClient":
+			m.ServerStreaming = true
+		case "ClientStreamingClient":
+			m.ClientStreaming = true
+		case "BidiStreamingClient":
+			m.ClientStreaming, m.ServerStreaming = true, true
+		}
+		if len(args) == 2 {
+			m.Request = messageName(args[0])
+		}
+		m.Response = messageName(args[len(args)-1])
+	}
+	return m
+}
+
+// messageName returns the name of a message type used as *Message.
+func messageName(x ast.Expr) string {
+	if star, ok := x.(*ast.StarExpr); ok {
+		x = star.X
+	}
+	return TypeOf(x)
+}
+
+// serviceNames returns the fully-qualified protobuf names of the services,
+// read from the ServiceName field of their Foo_ServiceDesc variable.
+func serviceNames(pkg *doc.Package) map[string]string {
+	names := map[string]string{}
+	for _, v := range pkg.Vars {
+		for _, spec := range v.Decl.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, name := range vs.Names {
+				service := strings.TrimSuffix(name.Name, "_ServiceDesc")
+				if service == name.Name || i >= len(vs.Values) {
+					continue
+				}
+				lit, ok := vs.Values[i].(*ast.CompositeLit)
+				if !ok {
+					continue
+				}
+				for _, elt := range lit.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if key, _ := kv.Key.(*ast.Ident); ok && key != nil && key.Name == "ServiceName" {
+						if value, ok := kv.Value.(*ast.BasicLit); ok && value.Kind == token.STRING {
+							names[service], _ = strconv.Unquote(value.Value)
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,118 @@
+package extract
+
+import (
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Implementations records which types of a set of documented packages
+// implement which interfaces of the set.
+type Implementations struct {
+	// Indexed by package directory, then by type name.
+	implements    map[string]map[string][]string
+	implementedBy map[string]map[string][]string
+}
+
+// implTypeName is an exported, non-generic type declared by a package of the
+// documented set.
+type implTypeName struct {
+	name string // import path and type name, e.g. "io.Reader"
+	dir  string
+	obj  *types.TypeName
+}
+
+// BuildImplementations type-checks the packages in directories, imported
+// from source with the toolchain and build context of opts, and finds the
+// concrete types implementing the non-empty interfaces among them. Packages
+// that cannot be type-checked are skipped with a warning of opts.
+func BuildImplementations(directories []string, opts Options) *Implementations {
+	imp := newSourceImporter(token.NewFileSet(), opts)
+	var concrete, interfaces []*implTypeName
+	for _, directory := range directories {
+		importPath := ImportPathOf(directory)
+		dir := filepath.Clean(directory)
+		// Imported under its import path inside a module, so that the
+		// packages of the set importing it share its types.
+		path := importPath
+		if root, _ := FindModule(directory); root == "" {
+			path = "."
+		}
+		typesPkg, err := imp.ImportFrom(path, directory, 0)
+		if err != nil {
+			opts.warnf("cannot type-check %s: %s", directory, err)
+			continue
+		}
+		scope := typesPkg.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || !obj.Exported() || obj.IsAlias() {
+				continue
+			}
+			if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+				continue
+			}
+			t := &implTypeName{name: importPath + "." + name, dir: dir, obj: obj}
+			if iface, ok := obj.Type().Underlying().(*types.Interface); !ok {
+				concrete = append(concrete, t)
+			} else if iface.NumMethods() > 0 {
+				interfaces = append(interfaces, t)
+			}
+		}
+	}
+
+	impls := &Implementations{
+		implements:    map[string]map[string][]string{},
+		implementedBy: map[string]map[string][]string{},
+	}
+	for _, t := range concrete {
+		for _, i := range interfaces {
+			iface := i.obj.Type().Underlying().(*types.Interface)
+			implementer := t.name
+			if !types.Implements(t.obj.Type(), iface) {
+				if !types.Implements(types.NewPointer(t.obj.Type()), iface) {
+					continue
+				}
+				implementer = "*" + t.name
+			}
+			impls.add(impls.implements, t.dir, t.obj.Name(), i.name)
+			impls.add(impls.implementedBy, i.dir, i.obj.Name(), implementer)
+		}
+	}
+	return impls
+}
+
+func (impls *Implementations) add(index map[string]map[string][]string, dir, typeName, name string) {
+	if index[dir] == nil {
+		index[dir] = map[string][]string{}
+	}
+	index[dir][typeName] = append(index[dir][typeName], name)
+}
+
+// apply sets the Implements and ImplementedBy lists of the types of pkg,
+// documented from directory.
+func (impls *Implementations) apply(directory string, pkg *Package) {
+	if impls == nil || strings.HasSuffix(pkg.ImportPath, "_test") {
+		return
+	}
+	dir := filepath.Clean(directory)
+	for _, t := range pkg.Types {
+		t.Implements = sortedTypeNames(impls.implements[dir][t.Name])
+		t.ImplementedBy = sortedTypeNames(impls.implementedBy[dir][t.Name])
+	}
+}
+
+// sortedTypeNames returns a copy of names sorted by type name, ignoring
+// the "*" of pointer types.
+func sortedTypeNames(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	names = append([]string(nil), names...)
+	sort.Slice(names, func(i, j int) bool {
+		return strings.TrimPrefix(names[i], "*") < strings.TrimPrefix(names[j], "*")
+	})
+	return names
+}
@@ -0,0 +1,70 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSortedTypeNames(t *testing.T) {
+	tests := []struct {
+		names, want []string
+	}{
+		{nil, nil},
+		{[]string{"b.T"}, []string{"b.T"}},
+		{[]string{"*b.T", "a.U", "c.V"}, []string{"a.U", "*b.T", "c.V"}},
+	}
+	for _, test := range tests {
+		if got := sortedTypeNames(test.names); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("sortedTypeNames(%q) = %q, want %q", test.names, got, test.want)
+		}
+	}
+}
+
+func TestImplementations(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"go.mod": "module example.com/m\n",
+		"i/i.go": "package i\n\ntype Reader interface{ Read() int }\n\ntype Empty interface{}\n",
+		"c/c.go": "package c\n\ntype V int\n\nfunc (V) Read() int { return 0 }\n\ntype P struct{}\n\nfunc (*P) Read() int { return 0 }\n\ntype N int\n",
+	} {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The source importer resolves import paths in the working directory.
+	t.Chdir(dir)
+	directories := []string{filepath.Join(dir, "i"), filepath.Join(dir, "c")}
+	opts := Options{Implementations: BuildImplementations(directories, Options{})}
+
+	tests := []struct {
+		dir, typeName           string
+		implements, implemented []string
+	}{
+		{"i", "Reader", nil, []string{"*example.com/m/c.P", "example.com/m/c.V"}},
+		{"i", "Empty", nil, nil},
+		{"c", "V", []string{"example.com/m/i.Reader"}, nil},
+		{"c", "P", []string{"example.com/m/i.Reader"}, nil},
+		{"c", "N", nil, nil},
+	}
+	for _, test := range tests {
+		pkg, err := Extract(filepath.Join(dir, test.dir), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, typ := range pkg.Types {
+			if typ.Name != test.typeName {
+				continue
+			}
+			if !reflect.DeepEqual(typ.Implements, test.implements) || !reflect.DeepEqual(typ.ImplementedBy, test.implemented) {
+				t.Errorf("%s implements %q and is implemented by %q, want %q and %q",
+					test.typeName, typ.Implements, typ.ImplementedBy, test.implements, test.implemented)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package extract
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+)
+
+// sourceImporter imports packages from source, like the "source" compiler
+// of go/importer, but locates them with its own build context instead of
+// build.Default, so that calls may select different toolchains. Imports of
+// "C" are faked rather than processed by cgo.
+type sourceImporter struct {
+	ctx       *build.Context
+	fileSet   *token.FileSet
+	goVersion string
+	packages  map[string]*types.Package // by import path; nil while importing
+}
+
+// newSourceImporter returns an importer of the packages located by the
+// import context of opts, parsed into fileSet.
+func newSourceImporter(fileSet *token.FileSet, opts Options) *sourceImporter {
+	return &sourceImporter{
+		ctx:       opts.ImportContext(),
+		fileSet:   fileSet,
+		goVersion: opts.goVersion(),
+		packages:  map[string]*types.Package{},
+	}
+}
+
+func (p *sourceImporter) Import(path string) (*types.Package, error) {
+	return p.ImportFrom(path, ".", 0)
+}
+
+func (p *sourceImporter) ImportFrom(path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	if abs, err := filepath.Abs(srcDir); err == nil {
+		srcDir = abs
+	}
+	bp, err := p.ctx.Import(path, srcDir, 0)
+	if err != nil {
+		return nil, err
+	}
+	if bp.ImportPath == "unsafe" {
+		return types.Unsafe, nil
+	}
+	if pkg, ok := p.packages[bp.ImportPath]; ok {
+		if pkg == nil {
+			return nil, fmt.Errorf("import cycle through package %q", bp.ImportPath)
+		}
+		return pkg, nil
+	}
+	p.packages[bp.ImportPath] = nil
+	pkg, err := p.check(bp)
+	if err != nil {
+		delete(p.packages, bp.ImportPath)
+		return nil, err
+	}
+	p.packages[bp.ImportPath] = pkg
+	return pkg, nil
+}
+
+// check parses and type-checks the files of bp, ignoring function bodies.
+func (p *sourceImporter) check(bp *build.Package) (*types.Package, error) {
+	var files []*ast.File
+	for _, name := range slices.Concat(bp.GoFiles, bp.CgoFiles) {
+		file, err := parser.ParseFile(p.fileSet, filepath.Join(bp.Dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	var firstErr error
+	conf := types.Config{
+		Importer:         p,
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		GoVersion:        p.goVersion,
+		Sizes:            types.SizesFor(p.ctx.Compiler, p.ctx.GOARCH),
+		Error: func(err error) {
+			if terr, ok := err.(types.Error); firstErr == nil && (!ok || !terr.Soft) {
+				firstErr = err
+			}
+		},
+	}
+	pkg, _ := conf.Check(bp.ImportPath, p.fileSet, files, nil)
+	if firstErr != nil {
+		return nil, fmt.Errorf("type-checking package %q failed (%v)", bp.ImportPath, firstErr)
+	}
+	return pkg, nil
+}
@@ -0,0 +1,140 @@
+package extract
+
+import (
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PackagePath returns the import path of pkg, derived from its module, or
+// the directory recorded as its ImportPath by go/doc when it has none.
+func PackagePath(pkg *Package) string {
+	if pkg.Import != nil {
+		return pkg.Import.Path
+	}
+	return pkg.ImportPath
+}
+
+// Import describes how consumers import a package.
+type Import struct {
+	Path      string `json:"path"`
+	Statement string `json:"statement"`        // e.g. `import "example.com/mod/pkg"`
+	Alias     string `json:"alias,omitempty"`  // suggested alias, used by Statement
+	Reason    string `json:"reason,omitempty"` // why Alias is suggested
+}
+
+var (
+	stdNamesMu sync.Mutex
+	stdNames   = map[string]map[string]bool{} // by GOROOT
+)
+
+// stdPackageNames returns the last elements of the import paths of the
+// standard library in goroot, e.g. "errors" or "template".
+func stdPackageNames(goroot string) map[string]bool {
+	stdNamesMu.Lock()
+	defer stdNamesMu.Unlock()
+	if names, ok := stdNames[goroot]; ok {
+		return names
+	}
+	names := map[string]bool{}
+	src := filepath.Join(goroot, "src")
+	filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		switch name := d.Name(); {
+		case p == src:
+		case name == "internal" || name == "vendor" || name == "testdata" || name == "cmd" && filepath.Dir(p) == src:
+			return filepath.SkipDir
+		default:
+			names[name] = true
+		}
+		return nil
+	})
+	stdNames[goroot] = names
+	return names
+}
+
+// IsStdImportPath reports whether importPath belongs to the standard
+// library, whose import paths have no dot in their first element.
+func IsStdImportPath(importPath string) bool {
+	return !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".")
+}
+
+// suggestImport returns how to import the package name with the given
+// import path. An alias is suggested when name differs from the last
+// element of the path, or when it shadows a package of the standard
+// library in goroot.
+func suggestImport(importPath, name, goroot string) *Import {
+	imp := &Import{Path: importPath}
+	base := path.Base(importPath)
+	switch {
+	case name != base:
+		imp.Alias = name
+		imp.Reason = "package name " + name + " differs from the import path"
+	case !IsStdImportPath(importPath) && stdPackageNames(goroot)[name]:
+		imp.Alias = aliasOf(importPath)
+		imp.Reason = "package name " + name + " conflicts with a standard library package"
+	}
+	imp.Statement = "import " + strconv.Quote(importPath)
+	if imp.Alias != "" {
+		imp.Statement = "import " + imp.Alias + " " + strconv.Quote(importPath)
+	}
+	return imp
+}
+
+// aliasOf returns an alias for importPath made of its last two elements,
+// e.g. "moderrors" for example.com/mod/errors.
+func aliasOf(importPath string) string {
+	elems := strings.Split(importPath, "/")
+	if len(elems) > 2 {
+		elems = elems[len(elems)-2:]
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.Join(elems, "")) {
+		if r == '_' || r >= 'a' && r <= 'z' || b.Len() > 0 && r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	alias := b.String()
+	if !token.IsIdentifier(alias) {
+		return path.Base(importPath)
+	}
+	return alias
+}
+
+// setImports sets the import suggestion of pkg, importable as importPath,
+// and the import statement of its symbols, suggesting aliases for the
+// standard library in goroot. Commands and external test packages cannot
+// be imported and are left unchanged.
+func setImports(pkg *Package, importPath, goroot string) {
+	if pkg.Name == "main" || strings.HasSuffix(pkg.Name, "_test") {
+		return
+	}
+	pkg.Import = suggestImport(importPath, pkg.Name, goroot)
+	statement := pkg.Import.Statement
+	values := func(values []*Value) {
+		for _, v := range values {
+			v.Import = statement
+		}
+	}
+	funcs := func(funcs []*Func) {
+		for _, f := range funcs {
+			f.Import = statement
+		}
+	}
+	values(pkg.Consts)
+	values(pkg.Vars)
+	funcs(pkg.Funcs)
+	for _, t := range pkg.Types {
+		t.Import = statement
+		values(t.Consts)
+		values(t.Vars)
+		funcs(t.Funcs)
+		funcs(t.Methods)
+	}
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"fmt"
+)
+
+// IndexEntry locates a documented symbol within its package document.
+type IndexEntry struct {
+	Kind              string `json:"kind"` // "package", "const", "var", "type", "func" or "method"
+	Name              string `json:"name"` // symbol name; methods are qualified by their type, e.g. "T.Method"
+	PackageName       string `json:"packageName"`
+	PackageImportPath string `json:"packageImportPath"`
+	Filename          string `json:"filename"`
+	Line              int    `json:"line"`
+	Pointer           string `json:"pointer"` // JSON pointer to the symbol in the package document
+}
+
+// BuildIndex returns the flat list of symbols documented in pkg.
+func BuildIndex(pkg *Package) []*IndexEntry {
+	var index []*IndexEntry
+	WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+		index = append(index, entry)
+	})
+	return index
+}
+
+// WalkSymbols calls fn for every symbol documented in pkg, in document
+// order, with its index entry and its *Package, *Type, *Func or *Value.
+// Values declaring several names are visited once per name.
+func WalkSymbols(pkg *Package, fn func(entry *IndexEntry, symbol interface{})) {
+	fn(&IndexEntry{
+		Kind:              "package",
+		Name:              pkg.Name,
+		PackageName:       pkg.Name,
+		PackageImportPath: pkg.ImportPath,
+		Pointer:           "",
+	}, pkg)
+	walkValues(pkg.Consts, "/consts", fn)
+	walkValues(pkg.Vars, "/vars", fn)
+	walkFuncs(pkg.Funcs, "", "/funcs", fn)
+	for i, t := range pkg.Types {
+		pointer := fmt.Sprintf("/types/%d", i)
+		fn(&IndexEntry{
+			Kind:              "type",
+			Name:              t.Name,
+			PackageName:       t.PackageName,
+			PackageImportPath: t.PackageImportPath,
+			Filename:          t.Filename,
+			Line:              t.Line,
+			Pointer:           pointer,
+		}, t)
+		walkValues(t.Consts, pointer+"/consts", fn)
+		walkValues(t.Vars, pointer+"/vars", fn)
+		walkFuncs(t.Funcs, "", pointer+"/funcs", fn)
+		walkFuncs(t.Methods, t.Name+".", pointer+"/methods", fn)
+	}
+}
+
+func walkValues(values []*Value, pointer string, fn func(entry *IndexEntry, symbol interface{})) {
+	for i, v := range values {
+		for _, name := range v.Names {
+			fn(&IndexEntry{
+				Kind:              v.Type,
+				Name:              name,
+				PackageName:       v.PackageName,
+				PackageImportPath: v.PackageImportPath,
+				Filename:          v.Filename,
+				Line:              v.Line,
+				Pointer:           fmt.Sprintf("%s/%d", pointer, i),
+			}, v)
+		}
+	}
+}
+
+func walkFuncs(funcs []*Func, prefix string, pointer string, fn func(entry *IndexEntry, symbol interface{})) {
+	kind := "func"
+	if prefix != "" {
+		kind = "method"
+	}
+	for i, f := range funcs {
+		fn(&IndexEntry{
+			Kind:              kind,
+			Name:              prefix + f.Name,
+			PackageName:       f.PackageName,
+			PackageImportPath: f.PackageImportPath,
+			Filename:          f.Filename,
+			Line:              f.Line,
+			Pointer:           fmt.Sprintf("%s/%d", pointer, i),
+		}, f)
+	}
+}
@@ -0,0 +1,76 @@
+package extract
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// License is the license of the module containing a package.
+type License struct {
+	SPDX string `json:"spdx"` // SPDX identifier, e.g. "MIT", or "NOASSERTION" if not recognized
+	File string `json:"file"` // license file, relative to the module root
+}
+
+// licenseFiles lists the names of license files, in order of preference.
+var licenseFiles = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "LICENCE.txt",
+	"COPYING", "COPYING.md", "COPYING.txt", "UNLICENSE",
+}
+
+// licensePatterns recognizes common licenses by phrases of their texts,
+// normalized by normalizeLicense. More specific licenses come first.
+var licensePatterns = []struct {
+	id      string
+	phrases []string
+}{
+	{"AGPL-3.0", []string{"gnu affero general public license", "version 3"}},
+	{"LGPL-3.0", []string{"gnu lesser general public license", "version 3"}},
+	{"LGPL-2.1", []string{"gnu lesser general public license", "version 2.1"}},
+	{"GPL-3.0", []string{"gnu general public license", "version 3"}},
+	{"GPL-2.0", []string{"gnu general public license", "version 2"}},
+	{"Apache-2.0", []string{"apache license", "version 2.0"}},
+	{"MPL-2.0", []string{"mozilla public license", "2.0"}},
+	{"BSL-1.0", []string{"boost software license - version 1.0"}},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}},
+	{"MIT", []string{"permission is hereby granted, free of charge, to any person obtaining a copy"}},
+	{"ISC", []string{"distribute this software for any purpose with or without fee is hereby granted"}},
+	{"Unlicense", []string{"this is free and unencumbered software released into the public domain"}},
+	{"CC0-1.0", []string{"cc0 1.0 universal"}},
+}
+
+var licenseSpace = regexp.MustCompile(`[\s*#>]+`)
+
+// normalizeLicense lowercases text and collapses whitespace and markup.
+func normalizeLicense(text string) string {
+	return licenseSpace.ReplaceAllString(strings.ToLower(text), " ")
+}
+
+// detectLicense returns the license of the module rooted at directory, or
+// nil if it has no license file.
+func (s sourceFS) detectLicense(directory string) *License {
+	for _, name := range licenseFiles {
+		data, err := s.readFile(filepath.Join(directory, name))
+		if err != nil {
+			continue
+		}
+		return &License{SPDX: identifyLicense(string(data)), File: name}
+	}
+	return nil
+}
+
+// identifyLicense returns the SPDX identifier of the license text.
+func identifyLicense(text string) string {
+	text = normalizeLicense(text)
+	for _, p := range licensePatterns {
+		matched := true
+		for _, phrase := range p.phrases {
+			matched = matched && strings.Contains(text, phrase)
+		}
+		if matched {
+			return p.id
+		}
+	}
+	return "NOASSERTION"
+}
@@ -0,0 +1,70 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Marker files give per-directory control over the documentation of a
+// package, without changing the command line.
+const (
+	// ignoreMarker excludes the package of its directory.
+	ignoreMarker = ".godocjson-ignore"
+	// metadataMarker holds a DirectoryMarker in JSON.
+	metadataMarker = "doc.json"
+)
+
+// DirectoryMarker holds the settings read from the marker files of a
+// directory.
+type DirectoryMarker struct {
+	// Exclude excludes the package from the output.
+	Exclude bool `json:"exclude"`
+	// Title and Synopsis override those of the package.
+	Title    string `json:"title"`
+	Synopsis string `json:"synopsis"`
+	// FrontMatter is copied to the frontMatter of the package.
+	FrontMatter map[string]interface{} `json:"frontMatter"`
+}
+
+// ReadMarker returns the marker of directory, or nil if it has no marker
+// files.
+func ReadMarker(directory string) (*DirectoryMarker, error) {
+	return sourceFS{}.readMarker(directory)
+}
+
+func (s sourceFS) readMarker(directory string) (*DirectoryMarker, error) {
+	var marker *DirectoryMarker
+	data, err := s.readFile(filepath.Join(directory, metadataMarker))
+	if err == nil {
+		marker = &DirectoryMarker{}
+		if err := json.Unmarshal(data, marker); err != nil {
+			return nil, fmt.Errorf("%s: %s", filepath.Join(directory, metadataMarker), err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if _, err := s.stat(filepath.Join(directory, ignoreMarker)); err == nil {
+		if marker == nil {
+			marker = &DirectoryMarker{}
+		}
+		marker.Exclude = true
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return marker, nil
+}
+
+// apply overrides the fields of pkg set by the marker.
+func (m *DirectoryMarker) apply(pkg *Package) {
+	if m.Title != "" {
+		pkg.Title = m.Title
+	}
+	if m.Synopsis != "" {
+		pkg.Synopsis = m.Synopsis
+	}
+	if len(m.FrontMatter) > 0 {
+		pkg.FrontMatter = m.FrontMatter
+	}
+}
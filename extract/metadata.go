@@ -0,0 +1,55 @@
+package extract
+
+import (
+	"go/build"
+	"go/doc"
+)
+
+// Metadata describes how the documentation of a package was extracted.
+type Metadata struct {
+	Mode      []string          `json:"mode"`                // go/doc mode bits, e.g. "AllDecls"
+	Build     *BuildConstraints `json:"build,omitempty"`     // platform and tags selecting the files, with -goos, -goarch or -tags
+	Platforms []string          `json:"platforms,omitempty"` // platforms merged, with -platforms, e.g. "linux/amd64"
+	VCS       *VCS              `json:"vcs,omitempty"`       // source revision, with -vcs
+	Tool      *BuildInfo        `json:"tool"`                // build of godocjson that produced the document
+}
+
+// BuildConstraints are the platform and build tags the files of a package
+// were selected for.
+type BuildConstraints struct {
+	GOOS   string   `json:"goos"`
+	GOARCH string   `json:"goarch"`
+	Tags   []string `json:"tags"`
+}
+
+func buildConstraintsOf(ctx *build.Context) *BuildConstraints {
+	if ctx == nil {
+		return nil
+	}
+	tags := ctx.BuildTags
+	if tags == nil {
+		tags = []string{}
+	}
+	return &BuildConstraints{GOOS: ctx.GOOS, GOARCH: ctx.GOARCH, Tags: tags}
+}
+
+// docModes lists the go/doc mode bits settable from the command line.
+var docModes = []struct {
+	mode doc.Mode
+	name string
+}{
+	{doc.AllDecls, "AllDecls"},
+	{doc.AllMethods, "AllMethods"},
+	{doc.PreserveAST, "PreserveAST"},
+}
+
+// modeNames returns the names of the bits set in mode.
+func modeNames(mode doc.Mode) []string {
+	names := []string{}
+	for _, m := range docModes {
+		if mode&m.mode != 0 {
+			names = append(names, m.name)
+		}
+	}
+	return names
+}
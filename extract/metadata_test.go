@@ -0,0 +1,53 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestBuildConstraints(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"p.go":           "package p\n",
+		"p_linux.go":     "package p\n",
+		"p_windows.go":   "package p\n",
+		"p_arm64.go":     "package p\n",
+		"integration.go": "//go:build integration\n\npackage p\n",
+		"unix.go":        "//go:build unix && !integration\n\npackage p\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		opts  Options
+		files []string
+		build *BuildConstraints
+	}{
+		{Options{}, []string{"integration.go", "p.go", "p_arm64.go", "p_linux.go", "p_windows.go", "unix.go"}, nil},
+		{Options{GOOS: "linux", GOARCH: "amd64"}, []string{"p.go", "p_linux.go", "unix.go"}, &BuildConstraints{"linux", "amd64", []string{}}},
+		{Options{GOOS: "windows", GOARCH: "arm64"}, []string{"p.go", "p_arm64.go", "p_windows.go"}, &BuildConstraints{"windows", "arm64", []string{}}},
+		{Options{GOOS: "linux", GOARCH: "amd64", BuildTags: []string{"integration"}}, []string{"integration.go", "p.go", "p_linux.go"},
+			&BuildConstraints{"linux", "amd64", []string{"integration"}}},
+	}
+	for _, test := range tests {
+		pkg, err := Extract(dir, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var files []string
+		for _, filename := range pkg.Filenames {
+			files = append(files, filepath.Base(filename))
+		}
+		sort.Strings(files)
+		if !reflect.DeepEqual(files, test.files) {
+			t.Errorf("%+v: got files %q, want %q", test.opts, files, test.files)
+		}
+		if !reflect.DeepEqual(pkg.Metadata.Build, test.build) {
+			t.Errorf("%+v: got build %+v, want %+v", test.opts, pkg.Metadata.Build, test.build)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// MethodSetEntry is a method of the method set of a type.
+type MethodSetEntry struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"` // e.g. "func(p []byte) (n int, err error)"
+	// Recv is the type declaring the method, e.g. "*Buffer", qualified by
+	// its package name when declared in another package.
+	Recv     string `json:"recv"`
+	Promoted bool   `json:"promoted,omitempty"` // promoted from an embedded field
+}
+
+// cachedImporter imports packages from source through an ImportCache.
+// Imports of "C" resolve to cgo, if set.
+type cachedImporter struct {
+	importer types.ImporterFrom
+	srcDir   string
+	cache    *ImportCache
+	cgo      *types.Package
+}
+
+func (c *cachedImporter) Import(path string) (*types.Package, error) {
+	return c.ImportFrom(path, c.srcDir, 0)
+}
+
+func (c *cachedImporter) ImportFrom(path, srcDir string, mode types.ImportMode) (*types.Package, error) {
+	if path == "C" && c.cgo != nil {
+		return c.cgo, nil
+	}
+	return c.cache.importFrom(c.importer, path, srcDir)
+}
+
+// checkPackage type-checks the declarations of astPkg, ignoring function
+// bodies, and returns the resulting package. Type errors are ignored, so
+// that the types that do check are still available. Imported packages are
+// looked up in opts.Imports, or in a new cache if nil, and checked with the
+// toolchain of opts. It must be called before go/doc filters the AST.
+func checkPackage(astPkg *ast.Package, fileSet *token.FileSet, directory string, opts Options) *types.Package {
+	cache := opts.Imports
+	if cache == nil {
+		cache = NewImportCache()
+	}
+	// In file name order, so that duplicate declarations resolve alike
+	// from run to run.
+	filenames := make([]string, 0, len(astPkg.Files))
+	for filename := range astPkg.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	var files []*ast.File
+	for _, filename := range filenames {
+		files = append(files, astPkg.Files[filename])
+	}
+	imp := &cachedImporter{
+		importer: newSourceImporter(fileSet, opts),
+		srcDir:   directory,
+		cache:    cache,
+	}
+	if usesCgo(astPkg) {
+		imp.cgo = fakeCgoPackage(files)
+	}
+	conf := types.Config{
+		Importer:         imp,
+		IgnoreFuncBodies: true,
+		GoVersion:        opts.goVersion(),
+		Error:            func(err error) {},
+	}
+	typesPkg, _ := conf.Check(ImportPathOf(directory), fileSet, files, nil)
+	return typesPkg
+}
+
+// setMethodSets fills the MethodSet and PtrMethodSet of the types of pkg
+// from typesPkg, the type-checked package.
+func setMethodSets(pkg *Package, typesPkg *types.Package, opts Options) {
+	if typesPkg == nil {
+		return
+	}
+	for _, t := range pkg.Types {
+		obj, ok := typesPkg.Scope().Lookup(t.Name).(*types.TypeName)
+		if !ok {
+			opts.warnf("cannot compute the method set of %s", t.Name)
+			continue
+		}
+		t.MethodSet = methodSetEntries(obj.Type(), typesPkg)
+		if !types.IsInterface(obj.Type()) {
+			t.PtrMethodSet = methodSetEntries(types.NewPointer(obj.Type()), typesPkg)
+		}
+	}
+}
+
+// methodSetEntries returns the exported methods of the method set of t.
+func methodSetEntries(t types.Type, pkg *types.Package) []*MethodSetEntry {
+	qualifier := func(p *types.Package) string {
+		if p == pkg {
+			return ""
+		}
+		return p.Name()
+	}
+	entries := []*MethodSetEntry{}
+	mset := types.NewMethodSet(t)
+	for i := 0; i < mset.Len(); i++ {
+		sel := mset.At(i)
+		fn, ok := sel.Obj().(*types.Func)
+		if !ok || !fn.Exported() {
+			continue
+		}
+		sig := fn.Type().(*types.Signature)
+		recv := types.TypeString(t, qualifier)
+		if r := sig.Recv(); r != nil {
+			recv = types.TypeString(r.Type(), qualifier)
+		}
+		entries = append(entries, &MethodSetEntry{
+			Name:      fn.Name(),
+			Signature: types.TypeString(types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic()), qualifier),
+			Recv:      recv,
+			Promoted:  len(sel.Index()) > 1,
+		})
+	}
+	return entries
+}
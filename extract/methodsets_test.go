@@ -0,0 +1,76 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMethodSets(t *testing.T) {
+	dir := t.TempDir()
+	src := `package p
+
+type Inner struct{}
+
+func (Inner) A()  {}
+func (*Inner) B() {}
+func (Inner) b()  {}
+
+type T struct{ Inner }
+
+func (T) C(x int) error { return nil }
+func (*T) D()           {}
+
+type I interface{ E() }
+`
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := Extract(dir, Options{MethodSets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries are written "Recv.Name Signature", with a "+" for promoted
+	// methods.
+	tests := []struct {
+		typeName      string
+		methods, ptrs []string
+	}{
+		{"Inner", []string{"Inner.A func()"}, []string{"Inner.A func()", "*Inner.B func()"}},
+		{"T", []string{"+Inner.A func()", "T.C func(x int) error"},
+			[]string{"+Inner.A func()", "+*Inner.B func()", "T.C func(x int) error", "*T.D func()"}},
+		{"I", []string{"I.E func()"}, nil},
+	}
+	format := func(entries []*MethodSetEntry) []string {
+		var lines []string
+		for _, e := range entries {
+			line := fmt.Sprintf("%s.%s %s", e.Recv, e.Name, e.Signature)
+			if e.Promoted {
+				line = "+" + line
+			}
+			lines = append(lines, line)
+		}
+		return lines
+	}
+	for _, test := range tests {
+		var typ *Type
+		for _, ty := range pkg.Types {
+			if ty.Name == test.typeName {
+				typ = ty
+			}
+		}
+		if typ == nil {
+			t.Errorf("missing type %s", test.typeName)
+			continue
+		}
+		if got := format(typ.MethodSet); !reflect.DeepEqual(got, test.methods) {
+			t.Errorf("method set of %s = %q, want %q", test.typeName, got, test.methods)
+		}
+		if got := format(typ.PtrMethodSet); !reflect.DeepEqual(got, test.ptrs) {
+			t.Errorf("method set of *%s = %q, want %q", test.typeName, got, test.ptrs)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// noteMarkers lists the note markers to collect, e.g. TODO or FIXME, in
+// addition to the MARKER(uid) notes found by go/doc.
+type noteMarkers struct {
+	re *regexp.Regexp
+}
+
+// ParseNoteMarkers parses a comma-separated list of markers, normalized to
+// upper case. It returns nil if list is empty.
+func ParseNoteMarkers(list string) []string {
+	var markers []string
+	for _, m := range strings.Split(list, ",") {
+		if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+			markers = append(markers, m)
+		}
+	}
+	return markers
+}
+
+func newNoteMarkers(markers []string) *noteMarkers {
+	quoted := make([]string, len(markers))
+	for i, m := range markers {
+		quoted[i] = regexp.QuoteMeta(m)
+	}
+	// Unlike go/doc, markers match in any case and need no uid, but must be
+	// followed by a colon when they have none.
+	re := regexp.MustCompile(`^[ \t]*(?i:(` + strings.Join(quoted, "|") + `))(?:\(([^)]+)\):?|:)`)
+	return &noteMarkers{re: re}
+}
+
+// docNoteMarker matches the MARKER(uid) notes of go/doc, which end the
+// notes collected before them.
+var docNoteMarker = regexp.MustCompile(`^[ \t]*[A-Z][A-Z]+\([^)]+\)`)
+
+// commentText returns the text of c without its comment markers.
+func commentText(c *ast.Comment) string {
+	text := c.Text
+	if strings.HasPrefix(text, "//") {
+		return text[2:]
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+}
+
+// collect returns the notes of the markers in the comments of pkg, by
+// upper-case marker. A note extends to the end of its comment group or to
+// the next note.
+func (n *noteMarkers) collect(pkg *ast.Package) map[string][]*Note {
+	notes := map[string][]*Note{}
+	var names []string
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, cg := range pkg.Files[name].Comments {
+			var marker string
+			var note *Note
+			var body []string
+			flush := func() {
+				if note != nil {
+					note.Body = strings.TrimSpace(strings.Join(body, "\n")) + "\n"
+					notes[marker] = append(notes[marker], note)
+				}
+			}
+			for _, c := range cg.List {
+				text := commentText(c)
+				if m := n.re.FindStringSubmatchIndex(text); m != nil {
+					flush()
+					marker = strings.ToUpper(text[m[2]:m[3]])
+					note = &Note{Pos: c.Pos(), End: c.End()}
+					if m[4] >= 0 {
+						note.UID = text[m[4]:m[5]]
+					}
+					body = []string{strings.TrimSpace(text[m[1]:])}
+					continue
+				}
+				if docNoteMarker.MatchString(text) {
+					flush()
+					note = nil
+					continue
+				}
+				if note != nil {
+					note.End = c.End()
+					body = append(body, strings.TrimSpace(text))
+				}
+			}
+			flush()
+		}
+	}
+	return notes
+}
+
+// filterNotes replaces the notes of the markers in notes, as found by
+// go/doc, with the collected notes, and drops the notes of other markers if
+// dropUnknown is set. Marker names are normalized to upper case.
+func filterNotes(notes, collected map[string][]*Note, markers []string, dropUnknown bool) map[string][]*Note {
+	known := map[string]bool{}
+	for _, m := range markers {
+		known[m] = true
+	}
+	filtered := map[string][]*Note{}
+	for marker, list := range collected {
+		filtered[marker] = list
+	}
+	for marker, list := range notes {
+		marker = strings.ToUpper(marker)
+		if known[marker] || dropUnknown {
+			continue
+		}
+		filtered[marker] = append(filtered[marker], list...)
+	}
+	return filtered
+}
+
+// setNotePositions sets the file and line of the notes of pkg, whose Pos
+// only locates them in fileSet.
+func setNotePositions(pkg *Package, fileSet *token.FileSet) {
+	for _, notes := range pkg.Notes {
+		for _, note := range notes {
+			position := fileSet.Position(note.Pos)
+			note.Filename, note.Line = position.Filename, position.Line
+		}
+	}
+}
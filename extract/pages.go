@@ -0,0 +1,24 @@
+package extract
+
+import (
+	"go/ast"
+	"strings"
+)
+
+// pageDirective is the comment directive assigning a declaration to a named
+// output page, e.g. "//godocjson:page storage".
+const pageDirective = "//godocjson:page "
+
+// pageOf returns the page assigned by a godocjson:page directive in the doc
+// comment cg, or "" if there is none.
+func pageOf(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	for _, c := range cg.List {
+		if strings.HasPrefix(c.Text, pageDirective) {
+			return strings.TrimSpace(strings.TrimPrefix(c.Text, pageDirective))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,171 @@
+package extract
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ParamDocHeuristics selects the conventions by which the doc comment of a
+// function describes its parameters.
+type ParamDocHeuristics uint
+
+const (
+	// ParamDocSentences recognizes the sentences starting with the name of
+	// a parameter and a verb, e.g. "n is the number of bytes to read.", or
+	// with "The n parameter".
+	ParamDocSentences ParamDocHeuristics = 1 << iota
+	// ParamDocLists recognizes definition lists, with one parameter per
+	// line followed by a colon or a dash, e.g. "  - n: number of bytes".
+	// Following lines indented deeper continue the description.
+	ParamDocLists
+)
+
+// ParseParamDocHeuristics parses a comma-separated list of heuristics:
+// "sentences", "lists" or "all".
+func ParseParamDocHeuristics(list string) (ParamDocHeuristics, error) {
+	var h ParamDocHeuristics
+	for _, s := range strings.Split(list, ",") {
+		switch strings.TrimSpace(s) {
+		case "sentences":
+			h |= ParamDocSentences
+		case "lists":
+			h |= ParamDocLists
+		case "all":
+			h |= ParamDocSentences | ParamDocLists
+		default:
+			return 0, fmt.Errorf("unknown parameter documentation heuristic %q, expected sentences, lists or all", s)
+		}
+	}
+	return h, nil
+}
+
+// paramVerbs are the words following the name of a parameter at the start
+// of a sentence describing it.
+var paramVerbs = map[string]bool{
+	"is": true, "are": true, "must": true, "should": true, "may": true,
+	"can": true, "specifies": true, "contains": true, "holds": true,
+	"controls": true, "determines": true, "defaults": true, "receives": true,
+	"limits": true, "selects": true,
+}
+
+// paramListItem matches a line of a definition list: an optional bullet,
+// the name, optionally quoted, and a colon or a dash before the
+// description.
+var paramListItem = regexp.MustCompile("^([ \t]*)(?:[-*•][ \t]+)?`?([\\pL_][\\pL\\pN_]*)`?(?:[ \t]*:|[ \t]+[-–—])[ \t]+(\\S.*)$")
+
+// setParamDocs sets the Doc of the parameters and named results of the
+// functions of pkg described by their doc comment, by the conventions of h.
+func setParamDocs(pkg *Package, h ParamDocHeuristics) {
+	WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+		fn, ok := symbol.(*Func)
+		if !ok || fn.Doc == "" {
+			return
+		}
+		var lists, sentences map[string]string
+		if h&ParamDocLists != 0 {
+			lists = paramListDocs(fn.Doc)
+		}
+		if h&ParamDocSentences != 0 {
+			sentences = paramSentenceDocs(fn.Doc)
+		}
+		for _, params := range [][]FuncParam{fn.Params, fn.Results} {
+			for i := range params {
+				if params[i].Name == "" || params[i].Name == "_" {
+					continue
+				}
+				if doc := lists[params[i].Name]; doc != "" {
+					params[i].Doc = doc
+				} else {
+					params[i].Doc = sentences[params[i].Name]
+				}
+			}
+		}
+	})
+}
+
+// paramListDocs returns the descriptions of the definition lists of doc,
+// by name.
+func paramListDocs(doc string) map[string]string {
+	docs := map[string]string{}
+	var name, indent string
+	var desc []string
+	flush := func() {
+		if name != "" && docs[name] == "" {
+			docs[name] = strings.Join(desc, " ")
+		}
+		name, desc = "", nil
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		if m := paramListItem.FindStringSubmatch(line); m != nil {
+			flush()
+			indent, name, desc = m[1], m[2], []string{strings.TrimSpace(m[3])}
+			continue
+		}
+		trimmed := strings.TrimLeft(line, " \t")
+		if name != "" && trimmed != "" && len(line)-len(trimmed) > len(indent) {
+			desc = append(desc, strings.TrimSpace(trimmed))
+			continue
+		}
+		flush()
+	}
+	flush()
+	return docs
+}
+
+// paramSentenceDocs returns the sentences of doc starting with a name and
+// one of paramVerbs, or with "The name parameter" or "The name argument",
+// joined by name.
+func paramSentenceDocs(doc string) map[string]string {
+	docs := map[string]string{}
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(paragraph, " ") || strings.HasPrefix(paragraph, "\t") {
+			// Code blocks and lists.
+			continue
+		}
+		for _, sentence := range splitSentences(strings.Join(strings.Fields(paragraph), " ")) {
+			words := strings.SplitN(sentence, " ", 4)
+			var name string
+			switch {
+			case len(words) >= 3 && words[0] == "The" && (words[2] == "parameter" || words[2] == "argument"):
+				name = words[1]
+			case len(words) >= 2 && paramVerbs[words[1]]:
+				name = words[0]
+			default:
+				continue
+			}
+			name = strings.Trim(name, "`")
+			if docs[name] != "" {
+				docs[name] += " "
+			}
+			docs[name] += sentence
+		}
+	}
+	return docs
+}
+
+// abbreviations end with a period that does not end a sentence.
+var abbreviations = []string{"e.g.", "i.e.", "etc.", "cf.", "vs."}
+
+// splitSentences splits text, on a single line, after the periods, question
+// and exclamation marks followed by a space, other than those of
+// abbreviations. Parameters are usually lower-case, so sentences need not
+// start with an upper-case letter.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i := 0; i+1 < len(text); i++ {
+		if strings.IndexByte(".?!", text[i]) < 0 || text[i+1] != ' ' {
+			continue
+		}
+		abbreviated := false
+		for _, a := range abbreviations {
+			abbreviated = abbreviated || strings.HasSuffix(text[start:i+1], " "+a) || text[start:i+1] == a
+		}
+		if !abbreviated {
+			sentences = append(sentences, text[start:i+1])
+			start = i + 2
+		}
+	}
+	return append(sentences, text[start:])
+}
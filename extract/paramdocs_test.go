@@ -0,0 +1,99 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseParamDocHeuristics(t *testing.T) {
+	tests := []struct {
+		list string
+		want ParamDocHeuristics
+		err  bool
+	}{
+		{"sentences", ParamDocSentences, false},
+		{"lists", ParamDocLists, false},
+		{"lists, sentences", ParamDocSentences | ParamDocLists, false},
+		{"all", ParamDocSentences | ParamDocLists, false},
+		{"words", 0, true},
+		{"", 0, true},
+	}
+	for _, test := range tests {
+		got, err := ParseParamDocHeuristics(test.list)
+		if got != test.want || (err != nil) != test.err {
+			t.Errorf("ParseParamDocHeuristics(%q) = %v, %v, want %v and error %v", test.list, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{""}},
+		{"One. Two? Three!", []string{"One.", "Two?", "Three!"}},
+		{"Use a reader, e.g. a file. Done.", []string{"Use a reader, e.g. a file.", "Done."}},
+		{"e.g. this. That.", []string{"e.g. this.", "That."}},
+		{"Version 1.2 is out.", []string{"Version 1.2 is out."}},
+	}
+	for _, test := range tests {
+		if got := splitSentences(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("splitSentences(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestParamListDocs(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want map[string]string
+	}{
+		{"Read reads.\n", map[string]string{}},
+		{"Parameters:\n  - n: number of bytes\n  - buf - the buffer\n", map[string]string{"n": "number of bytes", "buf": "the buffer"}},
+		{"  `ctx`: the context,\n    canceled on exit\n  n: first\n  n: second\n", map[string]string{"ctx": "the context, canceled on exit", "n": "first"}},
+		{"  * size — the size\nNot continued.\n", map[string]string{"size": "the size"}},
+	}
+	for _, test := range tests {
+		if got := paramListDocs(test.doc); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("paramListDocs(%q) = %q, want %q", test.doc, got, test.want)
+		}
+	}
+}
+
+func TestParamSentenceDocs(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want map[string]string
+	}{
+		{"Read reads.\n", map[string]string{}},
+		{"Read reads into buf. n is the number of bytes.\nbuf must not be nil.\n", map[string]string{"n": "n is the number of bytes.", "buf": "buf must not be nil."}},
+		{"The `n` parameter limits the size. n defaults to 1.\n", map[string]string{"n": "The `n` parameter limits the size. n defaults to 1."}},
+		{"Example:\n\n\tn is ignored here.\n", map[string]string{}},
+	}
+	for _, test := range tests {
+		if got := paramSentenceDocs(test.doc); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("paramSentenceDocs(%q) = %q, want %q", test.doc, got, test.want)
+		}
+	}
+}
+
+func TestSetParamDocs(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+// Copy copies n bytes. n must be positive.
+//
+//   - dst: the destination
+func Copy(dst []byte, n int, _ bool) (written int, err error) { return }
+`)
+	setParamDocs(pkg, ParamDocSentences|ParamDocLists)
+	f := pkg.Funcs[0]
+	var got []string
+	for _, p := range append(f.Params, f.Results...) {
+		got = append(got, p.Name+"="+p.Doc)
+	}
+	want := []string{"dst=the destination", "n=n must be positive.", "_=", "written=", "err="}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got docs %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+package extract
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Platform is a target operating system and architecture.
+type Platform struct {
+	GOOS, GOARCH string
+}
+
+func (p Platform) String() string {
+	return p.GOOS + "/" + p.GOARCH
+}
+
+// defaultPlatforms are the platforms pkg.go.dev documents packages for.
+var defaultPlatforms = []Platform{
+	{"linux", "amd64"},
+	{"windows", "amd64"},
+	{"darwin", "amd64"},
+	{"js", "wasm"},
+}
+
+// ParsePlatforms parses a comma-separated list of GOOS/GOARCH pairs, e.g.
+// "linux/amd64,windows/arm64". "default" stands for the platforms of
+// pkg.go.dev.
+func ParsePlatforms(list string) ([]Platform, error) {
+	var platforms []Platform
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "default" {
+			platforms = append(platforms, defaultPlatforms...)
+			continue
+		}
+		goos, goarch, ok := strings.Cut(s, "/")
+		if !ok || !knownOS[goos] || !knownArch[goarch] {
+			return nil, fmt.Errorf("invalid platform %q, expected GOOS/GOARCH, e.g. linux/amd64", s)
+		}
+		platforms = append(platforms, Platform{goos, goarch})
+	}
+	return platforms, nil
+}
+
+// parsePlatformPackages documents the packages in directory once for each
+// of opts.Platforms and merges the results. The symbols missing on some
+// platforms list those declaring them in their Platforms; the
+// documentation of a symbol is that of the first platform declaring it.
+func parsePlatformPackages(directory string, opts Options) ([]*Package, error) {
+	platforms := opts.Platforms
+	opts.Platforms = nil
+	var merged []*Package
+	for _, p := range platforms {
+		opts.GOOS, opts.GOARCH = p.GOOS, p.GOARCH
+		pkgs, err := ParseDirectoryPackages(directory, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", p, err)
+		}
+		for _, pkg := range pkgs {
+			WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+				setPlatforms(symbol, []string{p.String()})
+			})
+			if base := packageNamed(merged, pkg.Name); base != nil {
+				mergePackage(base, pkg, p.String())
+			} else {
+				merged = append(merged, pkg)
+			}
+		}
+	}
+	// The external test package foo_test sorts after foo.
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+
+	names := make([]string, len(platforms))
+	for i, p := range platforms {
+		names[i] = p.String()
+	}
+	for _, pkg := range merged {
+		WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+			if len(platformsOf(symbol)) == len(platforms) {
+				setPlatforms(symbol, nil)
+			}
+		})
+		if pkg.Metadata != nil {
+			pkg.Metadata.Build = nil
+			pkg.Metadata.Platforms = names
+		}
+	}
+	return merged, nil
+}
+
+func packageNamed(pkgs []*Package, name string) *Package {
+	for _, pkg := range pkgs {
+		if pkg.Name == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+func platformsOf(symbol interface{}) []string {
+	switch s := symbol.(type) {
+	case *Type:
+		return s.Platforms
+	case *Func:
+		return s.Platforms
+	case *Value:
+		return s.Platforms
+	}
+	return nil
+}
+
+func setPlatforms(symbol interface{}, platforms []string) {
+	switch s := symbol.(type) {
+	case *Type:
+		s.Platforms = platforms
+	case *Func:
+		s.Platforms = platforms
+	case *Value:
+		s.Platforms = platforms
+	}
+}
+
+// mergePackage adds the symbols of pkg, documented for platform, to base.
+func mergePackage(base, pkg *Package, platform string) {
+	base.Filenames = unionStrings(base.Filenames, pkg.Filenames)
+	base.Imports = unionStrings(base.Imports, pkg.Imports)
+	base.Consts = mergeValues(base.Consts, pkg.Consts, platform)
+	base.Vars = mergeValues(base.Vars, pkg.Vars, platform)
+	base.Funcs = mergeFuncs(base.Funcs, pkg.Funcs, platform)
+	for _, t := range pkg.Types {
+		var match *Type
+		for _, bt := range base.Types {
+			if bt.Name == t.Name {
+				match = bt
+				break
+			}
+		}
+		if match == nil {
+			base.Types = append(base.Types, t)
+			continue
+		}
+		match.Platforms = append(match.Platforms, platform)
+		match.Consts = mergeValues(match.Consts, t.Consts, platform)
+		match.Vars = mergeValues(match.Vars, t.Vars, platform)
+		match.Funcs = mergeFuncs(match.Funcs, t.Funcs, platform)
+		match.Methods = mergeFuncs(match.Methods, t.Methods, platform)
+	}
+	sort.SliceStable(base.Types, func(i, j int) bool { return base.Types[i].Name < base.Types[j].Name })
+}
+
+func mergeValues(base, values []*Value, platform string) []*Value {
+	index := map[string]*Value{}
+	for _, v := range base {
+		index[strings.Join(v.Names, ",")] = v
+	}
+	for _, v := range values {
+		if match := index[strings.Join(v.Names, ",")]; match != nil {
+			match.Platforms = append(match.Platforms, platform)
+		} else {
+			base = append(base, v)
+		}
+	}
+	return base
+}
+
+func mergeFuncs(base, funcs []*Func, platform string) []*Func {
+	index := map[string]*Func{}
+	for _, f := range base {
+		index[f.Name] = f
+	}
+	added := false
+	for _, f := range funcs {
+		if match := index[f.Name]; match != nil {
+			match.Platforms = append(match.Platforms, platform)
+		} else {
+			base = append(base, f)
+			added = true
+		}
+	}
+	if added {
+		sort.SliceStable(base, func(i, j int) bool { return base[i].Name < base[j].Name })
+	}
+	return base
+}
+
+// unionStrings returns the sorted union of a and b.
+func unionStrings(a, b []string) []string {
+	seen := map[string]bool{}
+	union := []string{}
+	for _, list := range [][]string{a, b} {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				union = append(union, s)
+			}
+		}
+	}
+	sort.Strings(union)
+	return union
+}
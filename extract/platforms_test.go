@@ -0,0 +1,86 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParsePlatforms(t *testing.T) {
+	tests := []struct {
+		list string
+		want []Platform
+		err  bool
+	}{
+		{"linux/amd64", []Platform{{"linux", "amd64"}}, false},
+		{"linux/amd64, windows/arm64", []Platform{{"linux", "amd64"}, {"windows", "arm64"}}, false},
+		{"default", defaultPlatforms, false},
+		{"linux", nil, true},
+		{"linux/x86", nil, true},
+		{"beos/amd64", nil, true},
+		{"", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParsePlatforms(test.list)
+		if !reflect.DeepEqual(got, test.want) || (err != nil) != test.err {
+			t.Errorf("ParsePlatforms(%q) = %v, %v, want %v and error %v", test.list, got, err, test.want, test.err)
+		}
+	}
+}
+
+func TestUnionStrings(t *testing.T) {
+	tests := []struct {
+		a, b, want []string
+	}{
+		{nil, nil, []string{}},
+		{[]string{"b", "a"}, nil, []string{"a", "b"}},
+		{[]string{"a", "c"}, []string{"c", "b"}, []string{"a", "b", "c"}},
+	}
+	for _, test := range tests {
+		if got := unionStrings(test.a, test.b); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unionStrings(%q, %q) = %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestParsePlatformPackages(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"p.go":         "package p\n\n// T is a type.\ntype T int\n\n// F is portable.\nfunc F() {}\n",
+		"p_linux.go":   "package p\n\n// Fd is a method.\nfunc (T) Fd() int { return 0 }\n\n// Linux is a constant.\nconst Linux = 1\n",
+		"p_windows.go": "package p\n\n// Handle is a type.\ntype Handle uintptr\n\n// Fd is a method.\nfunc (T) Fd() int { return 0 }\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	platforms := []Platform{{"linux", "amd64"}, {"windows", "amd64"}, {"darwin", "arm64"}}
+	pkgs, err := ParseDirectoryPackages(dir, Options{Platforms: platforms})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 1 {
+		t.Fatalf("got %d packages", len(pkgs))
+	}
+	pkg := pkgs[0]
+	got := map[string][]string{}
+	WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+		if entry.Kind != "package" {
+			got[entry.Name] = platformsOf(symbol)
+		}
+	})
+	want := map[string][]string{
+		"F":      nil,
+		"T":      nil,
+		"T.Fd":   {"linux/amd64", "windows/amd64"},
+		"Linux":  {"linux/amd64"},
+		"Handle": {"windows/amd64"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got platforms %q, want %q", got, want)
+	}
+	if want := []string{"linux/amd64", "windows/amd64", "darwin/arm64"}; !reflect.DeepEqual(pkg.Metadata.Platforms, want) || pkg.Metadata.Build != nil {
+		t.Errorf("got metadata platforms %q and build %v", pkg.Metadata.Platforms, pkg.Metadata.Build)
+	}
+}
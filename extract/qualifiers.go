@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// qualifierImporter imports every package as an empty one named after its
+// import path, see assumedPackageName, so that type-checking resolves the
+// package names qualifying identifiers without loading the packages.
+type qualifierImporter struct{}
+
+func (qualifierImporter) Import(importPath string) (*types.Package, error) {
+	pkg := types.NewPackage(importPath, assumedPackageName(importPath))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// assumedPackageName returns the name a package is assumed to have from its
+// import path, like goimports does: the last element of the path, without
+// a major version suffix such as "v2" or ".v3", a "go-" prefix, or the
+// characters that cannot start or follow an identifier.
+func assumedPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			if dir := path.Dir(importPath); dir != "." {
+				base = path.Base(dir)
+			}
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexFunc(base, func(r rune) bool {
+		return !(r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+	}); i >= 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+// resolveImportPaths type-checks the declarations of astPkg and returns the
+// import path, as given by types.Package.Path, of the package named by
+// every identifier qualifying another one, e.g. the list of list.List.
+// Imported packages are not loaded: their members are unknown, and the
+// type errors this causes are ignored. It must be called before go/doc
+// filters the AST.
+func resolveImportPaths(astPkg *ast.Package, fileSet *token.FileSet) map[*ast.Ident]string {
+	// In file name order, so that duplicate declarations resolve alike
+	// from run to run.
+	filenames := make([]string, 0, len(astPkg.Files))
+	for filename := range astPkg.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	files := make([]*ast.File, len(filenames))
+	for i, filename := range filenames {
+		files[i] = astPkg.Files[filename]
+	}
+	info := &types.Info{Uses: map[*ast.Ident]types.Object{}}
+	conf := types.Config{
+		Importer:         qualifierImporter{},
+		IgnoreFuncBodies: true,
+		FakeImportC:      true,
+		Error:            func(err error) {},
+	}
+	conf.Check(astPkg.Name, fileSet, files, info)
+	importPaths := map[*ast.Ident]string{}
+	for ident, obj := range info.Uses {
+		if pkgName, ok := obj.(*types.PkgName); ok {
+			importPaths[ident] = pkgName.Imported().Path()
+		}
+	}
+	return importPaths
+}
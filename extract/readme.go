@@ -0,0 +1,30 @@
+package extract
+
+import (
+	"go/doc"
+	"os"
+	"path/filepath"
+)
+
+// Synopsis returns the first sentence of a doc comment.
+func Synopsis(text string) string {
+	var p doc.Package
+	return p.Synopsis(text)
+}
+
+// readmeFiles lists the names of README files, in order of preference.
+var readmeFiles = []string{"README.md", "README.markdown", "README", "README.txt"}
+
+// readReadme returns the contents of the README file of directory, or an
+// empty string if it has none.
+func (s sourceFS) readReadme(directory string) (string, error) {
+	for _, name := range readmeFiles {
+		data, err := s.readFile(filepath.Join(directory, name))
+		if err == nil {
+			return string(data), nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return "", nil
+}
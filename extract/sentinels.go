@@ -0,0 +1,77 @@
+package extract
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// SentinelError is a package-level error variable created with errors.New,
+// e.g. io.EOF.
+type SentinelError struct {
+	Name     string `json:"name"`
+	Message  string `json:"message"` // argument of errors.New
+	Doc      string `json:"doc"`     // doc comment of the variable, or of its declaration
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+}
+
+// detectSentinelErrors returns the exported variables of pkg initialized
+// with errors.New and a string literal, sorted like the variables of pkg.
+func detectSentinelErrors(pkg *doc.Package, fileSet *token.FileSet) []*SentinelError {
+	importsErrors := false
+	for _, imp := range pkg.Imports {
+		importsErrors = importsErrors || imp == "errors"
+	}
+	if !importsErrors {
+		return nil
+	}
+
+	var sentinels []*SentinelError
+	for _, v := range pkg.Vars {
+		for _, spec := range v.Decl.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if len(vs.Values) != len(vs.Names) {
+				continue
+			}
+			for i, name := range vs.Names {
+				message, ok := errorsNewMessage(vs.Values[i])
+				if !ok || !name.IsExported() {
+					continue
+				}
+				s := &SentinelError{Name: name.Name, Message: message, Doc: strings.TrimSpace(vs.Doc.Text())}
+				if s.Doc == "" {
+					s.Doc = strings.TrimSpace(vs.Comment.Text())
+				}
+				if s.Doc == "" && len(v.Decl.Specs) == 1 {
+					s.Doc = strings.TrimSpace(v.Doc)
+				}
+				position := fileSet.Position(name.Pos())
+				s.Filename, s.Line = position.Filename, position.Line
+				sentinels = append(sentinels, s)
+			}
+		}
+	}
+	return sentinels
+}
+
+// errorsNewMessage returns the message of x if it is a call of errors.New
+// with a string literal.
+func errorsNewMessage(x ast.Expr) (string, bool) {
+	call, ok := x.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return "", false
+	}
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !isIdent(fun.X, "errors") || fun.Sel.Name != "New" {
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	message, err := strconv.Unquote(lit.Value)
+	return message, err == nil
+}
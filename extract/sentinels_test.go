@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"go/parser"
+	"strings"
+	"testing"
+)
+
+func TestErrorsNewMessage(t *testing.T) {
+	tests := []struct {
+		expr    string
+		message string
+		ok      bool
+	}{
+		{`errors.New("closed")`, "closed", true},
+		{"errors.New(`raw \\n`)", `raw \n`, true},
+		{`errors.New("a" + "b")`, "", false},
+		{`errors.New(msg)`, "", false},
+		{`fmt.Errorf("closed")`, "", false},
+		{`New("closed")`, "", false},
+		{`errors.New()`, "", false},
+	}
+	for _, test := range tests {
+		x, err := parser.ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if message, ok := errorsNewMessage(x); message != test.message || ok != test.ok {
+			t.Errorf("errorsNewMessage(%s) = %q, %v, want %q, %v", test.expr, message, ok, test.message, test.ok)
+		}
+	}
+}
+
+func TestDetectSentinelErrors(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+import "errors"
+
+// ErrA is documented.
+var ErrA = errors.New("a")
+
+var (
+	// ErrB is documented.
+	ErrB = errors.New("b")
+	ErrC = errors.New("c") // ErrC has a line comment.
+	errD = errors.New("d")
+	ErrE, ErrF = errors.New("e"), errors.New("f")
+	Count = 1
+)
+`)
+	var got []string
+	for _, s := range pkg.Errors {
+		got = append(got, s.Name+" "+s.Message+" "+s.Doc)
+	}
+	// go/doc lists grouped declarations first.
+	want := []string{"ErrB b ErrB is documented.", "ErrC c ErrC has a line comment.", "ErrE e ", "ErrF f ", "ErrA a ErrA is documented."}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors %q, want %q", got, want)
+	}
+
+	if pkg := extractSource(t, "package p\n\nvar ErrA = errors.New(\"a\")\n"); pkg.Errors != nil {
+		t.Errorf("got errors %v in a package not importing errors", pkg.Errors)
+	}
+}
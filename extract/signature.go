@@ -0,0 +1,55 @@
+package extract
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// funcSignature renders the declaration of d without its body. Signatures
+// longer than width are written with one parameter per line, like gofmt
+// formats them; a width of 0 never wraps.
+func funcSignature(d *ast.FuncDecl, width int) string {
+	head := "func "
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		head += "(" + strings.Join(fieldStrings(d.Recv), ", ") + ") "
+	}
+	head += d.Name.Name
+	if d.Type.TypeParams != nil {
+		head += "[" + strings.Join(fieldStrings(d.Type.TypeParams), ", ") + "]"
+	}
+	params := fieldStrings(d.Type.Params)
+	results := ""
+	if d.Type.Results != nil {
+		if r := d.Type.Results.List; len(r) == 1 && len(r[0].Names) == 0 {
+			results = " " + types.ExprString(r[0].Type)
+		} else {
+			results = " (" + strings.Join(fieldStrings(d.Type.Results), ", ") + ")"
+		}
+	}
+
+	sig := head + "(" + strings.Join(params, ", ") + ")" + results
+	if width <= 0 || len(sig) <= width || len(params) == 0 {
+		return sig
+	}
+	return head + "(\n\t" + strings.Join(params, ",\n\t") + ",\n)" + results
+}
+
+// fieldStrings renders every field of fields as written, e.g. "a, b int".
+func fieldStrings(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	strs := make([]string, len(fields.List))
+	for i, f := range fields.List {
+		names := make([]string, len(f.Names))
+		for j, name := range f.Names {
+			names[j] = name.Name
+		}
+		strs[i] = types.ExprString(f.Type)
+		if len(names) > 0 {
+			strs[i] = strings.Join(names, ", ") + " " + strs[i]
+		}
+	}
+	return strs
+}
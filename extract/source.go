@@ -0,0 +1,40 @@
+package extract
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+)
+
+// declSource pretty-prints decl as gofmt would, without its doc comment
+// and, for functions, without their body. The comments of the remaining
+// fields and specs are kept, and go/printer notes the fields removed by
+// go/doc.
+func (c *Copier) declSource(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		copied := *d
+		copied.Doc, copied.Body = nil, nil
+		decl = &copied
+	case *ast.GenDecl:
+		copied := *d
+		copied.Doc = nil
+		decl = &copied
+	}
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, c.FileSet, decl); err != nil {
+		c.Options.warnf("cannot print declaration: %s", err)
+		return ""
+	}
+	return buf.String()
+}
+
+// typeSource returns the source of the type declared by spec, alone in its
+// declaration.
+func (c *Copier) typeSource(spec *ast.TypeSpec) string {
+	copied := *spec
+	copied.Doc = nil
+	return c.declSource(&ast.GenDecl{TokPos: spec.Pos(), Tok: token.TYPE, Specs: []ast.Spec{&copied}})
+}
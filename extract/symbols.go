@@ -0,0 +1,95 @@
+package extract
+
+import "regexp"
+
+// symbolFilter selects the symbols of a package by name. Methods are named
+// "Type.Method".
+type symbolFilter struct {
+	include, exclude *regexp.Regexp
+}
+
+// keep reports whether the symbol name passes the filter. Members of a
+// kept type are only matched against the exclude expression, so that
+// including a type includes its methods.
+func (f symbolFilter) keep(name string, member bool) bool {
+	if f.exclude != nil && f.exclude.MatchString(name) {
+		return false
+	}
+	return member || f.include == nil || f.include.MatchString(name)
+}
+
+func (f symbolFilter) funcs(funcs []*Func, prefix string, member bool) []*Func {
+	kept := []*Func{}
+	for _, fn := range funcs {
+		if f.keep(prefix+fn.Name, member) {
+			kept = append(kept, fn)
+		}
+	}
+	return kept
+}
+
+// values drops the names that do not pass the filter, and the values left
+// without names.
+func (f symbolFilter) values(values []*Value, member bool) []*Value {
+	kept := []*Value{}
+	for _, v := range values {
+		var names []string
+		for _, name := range v.Names {
+			if f.keep(name, member) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		}
+		v.Names = names
+		kept = append(kept, v)
+	}
+	return kept
+}
+
+// CompileSymbolFilter compiles the regular expression of a symbol filter
+// flag, returning nil if expr is empty.
+func CompileSymbolFilter(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+// filterSymbols removes the symbols of pkg whose names do not match
+// include, if set, or match exclude, if set. Removing a type removes its
+// methods and associated declarations.
+func filterSymbols(pkg *Package, include, exclude *regexp.Regexp) {
+	if include == nil && exclude == nil {
+		return
+	}
+	f := symbolFilter{include, exclude}
+	pkg.Consts = f.values(pkg.Consts, false)
+	pkg.Vars = f.values(pkg.Vars, false)
+	pkg.Funcs = f.funcs(pkg.Funcs, "", false)
+	types := []*Type{}
+	for _, t := range pkg.Types {
+		if !f.keep(t.Name, false) {
+			continue
+		}
+		t.Consts = f.values(t.Consts, true)
+		t.Vars = f.values(t.Vars, true)
+		t.Funcs = f.funcs(t.Funcs, "", true)
+		t.Methods = f.funcs(t.Methods, t.Name+".", true)
+		types = append(types, t)
+	}
+	pkg.Types = types
+
+	kept := map[string]bool{}
+	WalkSymbols(pkg, func(entry *IndexEntry, symbol interface{}) {
+		kept[entry.Name] = true
+	})
+	examples := []*Example{}
+	for _, e := range pkg.Examples {
+		if e.Symbol == "" || kept[e.Symbol] {
+			examples = append(examples, e)
+		}
+	}
+	pkg.Examples = examples
+}
@@ -0,0 +1,59 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Toolchain describes the Go installation used to locate standard library
+// packages and the language version assumed when type-checking.
+type Toolchain struct {
+	GOROOT    string // root of the Go installation
+	GoVersion string // toolchain version, e.g. "go1.22.1"
+}
+
+// DetectToolchain returns the toolchain found in the GOROOT directory goroot,
+// or the one reported by the go command goCmd (such as "go1.22.1" or a
+// path to a go binary). Exactly one of goroot and goCmd should be set.
+func DetectToolchain(goroot, goCmd string) (*Toolchain, error) {
+	if goroot != "" {
+		tc := &Toolchain{GOROOT: goroot}
+		if version, err := os.ReadFile(filepath.Join(goroot, "VERSION")); err == nil {
+			tc.GoVersion = strings.TrimSpace(strings.SplitN(string(version), "\n", 2)[0])
+			return tc, nil
+		}
+		goCmd = filepath.Join(goroot, "bin", "go")
+	}
+	cmd := exec.Command(goCmd, "env", "GOROOT", "GOVERSION")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s env: %s %s", goCmd, err, strings.TrimSpace(stderr.String()))
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("%s env: unexpected output %q", goCmd, out)
+	}
+	tc := &Toolchain{GOROOT: lines[0], GoVersion: lines[1]}
+	if goroot != "" {
+		tc.GOROOT = goroot
+	}
+	return tc, nil
+}
+
+// LanguageVersion returns the Go language version of the toolchain, such as
+// "go1.22", in the form expected by go/types.
+func (tc *Toolchain) LanguageVersion() string {
+	parts := strings.SplitN(tc.GoVersion, ".", 3)
+	if len(parts) < 2 {
+		return tc.GoVersion
+	}
+	return parts[0] + "." + strings.TrimRightFunc(parts[1], func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+}
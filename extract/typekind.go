@@ -0,0 +1,70 @@
+package extract
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// typeKind returns the kind of the type declared by ts: "struct",
+// "interface", "map", "slice", "array", "chan", "func", "pointer", "basic"
+// for predeclared types, or "alias" for alias declarations. Types defined
+// from another type of the package have the kind of that type, found with
+// lookup; those defined from types of other packages are "named", since
+// their kind is only known after type-checking.
+func typeKind(ts *ast.TypeSpec, lookup func(name string) *ast.TypeSpec) string {
+	if ts.Assign.IsValid() {
+		return "alias"
+	}
+	x := ts.Type
+	seen := map[*ast.TypeSpec]bool{ts: true}
+	for {
+		switch t := x.(type) {
+		case *ast.ParenExpr:
+			x = t.X
+			continue
+		case *ast.StructType:
+			return "struct"
+		case *ast.InterfaceType:
+			return "interface"
+		case *ast.MapType:
+			return "map"
+		case *ast.ArrayType:
+			if t.Len == nil {
+				return "slice"
+			}
+			return "array"
+		case *ast.ChanType:
+			return "chan"
+		case *ast.FuncType:
+			return "func"
+		case *ast.StarExpr:
+			return "pointer"
+		case *ast.Ident:
+			if obj, ok := types.Universe.Lookup(t.Name).(*types.TypeName); ok {
+				if types.IsInterface(obj.Type()) {
+					return "interface" // error, any and comparable
+				}
+				return "basic"
+			}
+			if next := lookup(t.Name); next != nil && !seen[next] && !next.Assign.IsValid() {
+				seen[next] = true
+				x = next.Type
+				continue
+			}
+		}
+		return "named"
+	}
+}
+
+// typeSpecLookup returns a function finding the type specs of the package
+// by name among decls.
+func typeSpecLookup(decls []*ast.GenDecl) func(name string) *ast.TypeSpec {
+	return func(name string) *ast.TypeSpec {
+		for _, decl := range decls {
+			if ts := typeSpec(decl, name); ts != nil {
+				return ts
+			}
+		}
+		return nil
+	}
+}
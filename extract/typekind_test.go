@@ -0,0 +1,80 @@
+package extract
+
+import "testing"
+
+func TestTypeKind(t *testing.T) {
+	pkg := extractSource(t, `package p
+
+import "io"
+
+type Struct struct{ N int }
+type Iface interface{ M() }
+type Map map[string]int
+type Slice []byte
+type Array [4]byte
+type Chan chan int
+type Func func() error
+type Pointer *Struct
+type Basic int
+type Err error
+type Alias = Struct
+type Defined Map
+type Paren (Slice)
+type Cycle1 Cycle2
+type Cycle2 Cycle1
+type ToAlias Alias
+type Other io.Reader
+`)
+	tests := []struct {
+		name, kind, underlying string
+	}{
+		{"Struct", "struct", "struct{N int}"},
+		{"Iface", "interface", "interface{M()}"},
+		{"Map", "map", "map[string]int"},
+		{"Slice", "slice", "[]byte"},
+		{"Array", "array", "[4]byte"},
+		{"Chan", "chan", "chan int"},
+		{"Func", "func", "func() error"},
+		{"Pointer", "pointer", "*Struct"},
+		{"Basic", "basic", "int"},
+		{"Err", "interface", "error"},
+		{"Alias", "alias", "Struct"},
+		{"Defined", "map", "Map"},
+		{"Paren", "slice", "(Slice)"},
+		{"Cycle1", "named", "Cycle2"},
+		{"ToAlias", "named", "Alias"},
+		{"Other", "named", "io.Reader"},
+	}
+	byName := map[string]*Type{}
+	for _, typ := range pkg.Types {
+		byName[typ.Name] = typ
+	}
+	for _, test := range tests {
+		typ := byName[test.name]
+		if typ == nil {
+			t.Errorf("missing type %s", test.name)
+			continue
+		}
+		if typ.Kind != test.kind || typ.Underlying != test.underlying {
+			t.Errorf("%s has kind %q and underlying %q, want %q and %q", test.name, typ.Kind, typ.Underlying, test.kind, test.underlying)
+		}
+	}
+}
+
+func TestTypeAliases(t *testing.T) {
+	tests := []struct {
+		src     string
+		aliasOf string
+	}{
+		{"type A = int", "int"},
+		{"type A = map[string]int", "map[string]int"},
+		{"type A = List[int]", "List[int]"},
+		{"type A int", ""},
+	}
+	for _, test := range tests {
+		typ := extractSource(t, "package p\n\n"+test.src+"\n").Types[0]
+		if typ.IsAlias != (test.aliasOf != "") || typ.AliasOf != test.aliasOf || typ.IsAlias != (typ.Kind == "alias") {
+			t.Errorf("%s: got isAlias %v, aliasOf %q and kind %q", test.src, typ.IsAlias, typ.AliasOf, typ.Kind)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package extract
+
+import "sort"
+
+// SchemaVersionV2 identifies the layout of the documents of the v2 schema,
+// versioned like SchemaVersion.
+//
+// The v2 schema, see ConvertV2, holds the same information as Package with
+// consistent names: every declaration has a "kind", positions are
+// PositionV2 objects, parameters are "params", and the package name and
+// import path are only written once, on the package. Deprecated and
+// redundant fields, such as bugs or the import statement of every symbol,
+// are dropped.
+const SchemaVersionV2 = "2.1"
+
+// PositionV2 locates a declaration or comment in the v2 schema.
+type PositionV2 struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Offset    int    `json:"offset,omitempty"`    // byte offset of the start of the declaration in File
+	EndOffset int    `json:"endOffset,omitempty"` // byte offset following the declaration
+}
+
+// PackageV2 is a package in the v2 schema.
+type PackageV2 struct {
+	SchemaVersion string                 `json:"schemaVersion"`
+	Kind          string                 `json:"kind"` // always "package"
+	Name          string                 `json:"name"`
+	ImportPath    string                 `json:"importPath"`
+	Doc           string                 `json:"doc"`
+	Synopsis      string                 `json:"synopsis,omitempty"`
+	Title         string                 `json:"title,omitempty"`
+	FrontMatter   map[string]interface{} `json:"frontMatter,omitempty"`
+	Readme        string                 `json:"readme,omitempty"`
+	Imports       []string               `json:"imports"`
+	Files         []string               `json:"files"`
+	Import        *Import                `json:"import,omitempty"`
+	Module        *Module                `json:"module,omitempty"`
+	License       *License               `json:"license,omitempty"`
+	Notes         []*NoteV2              `json:"notes"` // by marker, then in source order
+
+	Consts []*ValueV2 `json:"consts"`
+	Vars   []*ValueV2 `json:"vars"`
+	Funcs  []*FuncV2  `json:"funcs"`
+	Types  []*TypeV2  `json:"types"`
+
+	Examples   []*ExampleV2       `json:"examples,omitempty"`
+	Services   []*Service         `json:"services,omitempty"`
+	Errors     []*SentinelErrorV2 `json:"errors,omitempty"`
+	Embeds     []*EmbedV2         `json:"embeds,omitempty"`
+	Cgo        bool               `json:"cgo,omitempty"`
+	CgoExports []*CgoExportV2     `json:"cgoExports,omitempty"`
+	Flags      []*CommandFlag     `json:"flags,omitempty"`
+
+	Metadata    *Metadata      `json:"metadata"`
+	Trimmed     []string       `json:"trimmed,omitempty"`
+	Diagnostics []*SourceError `json:"diagnostics,omitempty"`
+}
+
+// NoteV2 is a note comment in the v2 schema.
+type NoteV2 struct {
+	Marker   string      `json:"marker"` // e.g. "TODO" or "BUG"
+	UID      string      `json:"uid,omitempty"`
+	Body     string      `json:"body"`
+	Position *PositionV2 `json:"position"`
+}
+
+// ValueV2 is a constant or variable declaration in the v2 schema.
+type ValueV2 struct {
+	Kind             string           `json:"kind"`  // "const" or "var"
+	Names            []string         `json:"names"` // in declaration order
+	Doc              string           `json:"doc"`
+	Position         *PositionV2      `json:"position"`
+	Page             string           `json:"page,omitempty"`
+	BuildConstraints string           `json:"buildConstraints,omitempty"`
+	Platforms        []string         `json:"platforms,omitempty"`
+	Directives       []string         `json:"directives,omitempty"`
+	Source           string           `json:"source,omitempty"`
+	Instantiations   []*Instantiation `json:"instantiations,omitempty"`
+}
+
+// FuncV2 is a function or method declaration in the v2 schema.
+type FuncV2 struct {
+	Kind             string      `json:"kind"` // "func" or "method"
+	Name             string      `json:"name"`
+	Doc              string      `json:"doc"`
+	Signature        string      `json:"signature"`
+	Receiver         *ReceiverV2 `json:"receiver,omitempty"` // methods only
+	Params           []FuncParam `json:"params"`
+	Results          []FuncParam `json:"results"`
+	Position         *PositionV2 `json:"position"` // Offset and EndOffset include the body
+	Page             string      `json:"page,omitempty"`
+	BuildConstraints string      `json:"buildConstraints,omitempty"`
+	Platforms        []string    `json:"platforms,omitempty"`
+	Directives       []string    `json:"directives,omitempty"`
+	Source           string      `json:"source,omitempty"`
+	Body             *FuncBodyV2 `json:"body,omitempty"`
+	Examples         []string    `json:"examples,omitempty"`
+}
+
+// ReceiverV2 is the receiver of a method in the v2 schema.
+type ReceiverV2 struct {
+	Type           string `json:"type"`                     // actual receiver, "T" or "*T"
+	Original       string `json:"original"`                 // original receiver of embedded methods, "T" or "*T"
+	EmbeddingLevel int    `json:"embeddingLevel,omitempty"` // 0 means not embedded
+}
+
+// FuncBodyV2 is the body of a function in the v2 schema.
+type FuncBodyV2 struct {
+	Text  string     `json:"text"` // as written, braces included
+	Lines *LineRange `json:"lines,omitempty"`
+}
+
+// TypeV2 is a type declaration in the v2 schema.
+type TypeV2 struct {
+	Kind             string           `json:"kind"` // always "type"
+	Name             string           `json:"name"`
+	Doc              string           `json:"doc"`
+	TypeKind         string           `json:"typeKind"` // e.g. "struct", "interface" or "alias", see Type.Kind
+	Underlying       string           `json:"underlying"`
+	AliasOf          string           `json:"aliasOf,omitempty"`
+	TypeParams       []*TypeParam     `json:"typeParams,omitempty"`
+	Enum             *Enum            `json:"enum,omitempty"`
+	Position         *PositionV2      `json:"position"`
+	Page             string           `json:"page,omitempty"`
+	BuildConstraints string           `json:"buildConstraints,omitempty"`
+	Platforms        []string         `json:"platforms,omitempty"`
+	Directives       []string         `json:"directives,omitempty"`
+	Source           string           `json:"source,omitempty"`
+	Examples         []string         `json:"examples,omitempty"`
+	Fields           []*FieldV2       `json:"fields,omitempty"`
+	Instantiations   []*Instantiation `json:"instantiations,omitempty"`
+
+	Consts  []*ValueV2 `json:"consts"`
+	Vars    []*ValueV2 `json:"vars"`
+	Funcs   []*FuncV2  `json:"funcs"`
+	Methods []*FuncV2  `json:"methods"`
+
+	PromotedMethods []*PromotedMethod `json:"promotedMethods,omitempty"`
+	Implements      []string          `json:"implements,omitempty"`
+	ImplementedBy   []string          `json:"implementedBy,omitempty"`
+	MethodSet       []*MethodSetEntry `json:"methodSet,omitempty"`
+	PtrMethodSet    []*MethodSetEntry `json:"ptrMethodSet,omitempty"`
+}
+
+// FieldV2 is a field of a struct type in the v2 schema.
+type FieldV2 struct {
+	Name           string            `json:"name"`
+	Embedded       bool              `json:"embedded"`
+	Type           string            `json:"type"`
+	Doc            string            `json:"doc"`
+	Comment        string            `json:"comment,omitempty"`
+	Tag            string            `json:"tag,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Position       *PositionV2       `json:"position"`
+	Instantiations []*Instantiation  `json:"instantiations,omitempty"`
+	Channels       []*Channel        `json:"channels,omitempty"`
+}
+
+// ExampleV2 is an example function in the v2 schema.
+type ExampleV2 struct {
+	Name        string      `json:"name"`
+	Symbol      string      `json:"symbol,omitempty"`
+	Suffix      string      `json:"suffix,omitempty"`
+	Doc         string      `json:"doc"`
+	Code        string      `json:"code"`
+	Output      string      `json:"output"`
+	Unordered   bool        `json:"unordered"`
+	EmptyOutput bool        `json:"emptyOutput"`
+	Position    *PositionV2 `json:"position"`
+}
+
+// SentinelErrorV2 is an error variable in the v2 schema.
+type SentinelErrorV2 struct {
+	Name     string      `json:"name"`
+	Message  string      `json:"message"`
+	Doc      string      `json:"doc"`
+	Position *PositionV2 `json:"position"`
+}
+
+// EmbedV2 is a variable initialized by //go:embed directives in the v2
+// schema.
+type EmbedV2 struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Patterns []string    `json:"patterns"`
+	Position *PositionV2 `json:"position"`
+}
+
+// CgoExportV2 is a function exported to C in the v2 schema.
+type CgoExportV2 struct {
+	Name      string      `json:"name"`
+	Signature string      `json:"signature"`
+	Doc       string      `json:"doc"`
+	Position  *PositionV2 `json:"position"`
+}
+
+// ConvertV2 returns the document of pkg in the v2 schema.
+func ConvertV2(pkg *Package) *PackageV2 {
+	v2 := &PackageV2{
+		SchemaVersion: SchemaVersionV2,
+		Kind:          "package",
+		Name:          pkg.Name,
+		ImportPath:    pkg.ImportPath,
+		Doc:           pkg.Doc,
+		Synopsis:      pkg.Synopsis,
+		Title:         pkg.Title,
+		FrontMatter:   pkg.FrontMatter,
+		Readme:        pkg.Readme,
+		Imports:       pkg.Imports,
+		Files:         pkg.Filenames,
+		Import:        pkg.Import,
+		Module:        pkg.Module,
+		License:       pkg.License,
+		Notes:         []*NoteV2{},
+		Consts:        convertValuesV2(pkg.Consts),
+		Vars:          convertValuesV2(pkg.Vars),
+		Funcs:         convertFuncsV2(pkg.Funcs),
+		Types:         []*TypeV2{},
+		Services:      pkg.Services,
+		Cgo:           pkg.Cgo,
+		Flags:         pkg.Flags,
+		Metadata:      pkg.Metadata,
+		Trimmed:       pkg.Trimmed,
+		Diagnostics:   pkg.Diagnostics,
+	}
+
+	var markers []string
+	for marker := range pkg.Notes {
+		markers = append(markers, marker)
+	}
+	sort.Strings(markers)
+	for _, marker := range markers {
+		for _, note := range pkg.Notes[marker] {
+			v2.Notes = append(v2.Notes, &NoteV2{
+				Marker:   marker,
+				UID:      note.UID,
+				Body:     note.Body,
+				Position: &PositionV2{File: note.Filename, Line: note.Line},
+			})
+		}
+	}
+
+	for _, t := range pkg.Types {
+		tv2 := &TypeV2{
+			Kind:             "type",
+			Name:             t.Name,
+			Doc:              t.Doc,
+			TypeKind:         t.Kind,
+			Underlying:       t.Underlying,
+			AliasOf:          t.AliasOf,
+			TypeParams:       t.TypeParams,
+			Instantiations:   t.Instantiations,
+			Enum:             t.Enum,
+			Position:         &PositionV2{File: t.Filename, Line: t.Line, Offset: t.Offset, EndOffset: t.EndOffset},
+			Page:             t.Page,
+			BuildConstraints: t.BuildConstraints,
+			Platforms:        t.Platforms,
+			Directives:       t.Directives,
+			Source:           t.Source,
+			Examples:         t.Examples,
+			Consts:           convertValuesV2(t.Consts),
+			Vars:             convertValuesV2(t.Vars),
+			Funcs:            convertFuncsV2(t.Funcs),
+			Methods:          convertFuncsV2(t.Methods),
+			PromotedMethods:  t.PromotedMethods,
+			Implements:       t.Implements,
+			ImplementedBy:    t.ImplementedBy,
+			MethodSet:        t.MethodSet,
+			PtrMethodSet:     t.PtrMethodSet,
+		}
+		for _, f := range t.Fields {
+			tv2.Fields = append(tv2.Fields, &FieldV2{
+				Name:           f.Name,
+				Embedded:       f.Embedded,
+				Type:           f.Type,
+				Doc:            f.Doc,
+				Comment:        f.Comment,
+				Tag:            f.Tag,
+				Tags:           f.Tags,
+				Position:       &PositionV2{File: f.Filename, Line: f.Line},
+				Instantiations: f.Instantiations,
+				Channels:       f.Channels,
+			})
+		}
+		v2.Types = append(v2.Types, tv2)
+	}
+
+	for _, e := range pkg.Examples {
+		v2.Examples = append(v2.Examples, &ExampleV2{
+			Name:        e.Name,
+			Symbol:      e.Symbol,
+			Suffix:      e.Suffix,
+			Doc:         e.Doc,
+			Code:        e.Code,
+			Output:      e.Output,
+			Unordered:   e.Unordered,
+			EmptyOutput: e.EmptyOutput,
+			Position:    &PositionV2{File: e.Filename, Line: e.Line},
+		})
+	}
+	for _, e := range pkg.Errors {
+		v2.Errors = append(v2.Errors, &SentinelErrorV2{
+			Name:     e.Name,
+			Message:  e.Message,
+			Doc:      e.Doc,
+			Position: &PositionV2{File: e.Filename, Line: e.Line},
+		})
+	}
+	for _, e := range pkg.Embeds {
+		v2.Embeds = append(v2.Embeds, &EmbedV2{
+			Name:     e.Name,
+			Type:     e.Type,
+			Patterns: e.Patterns,
+			Position: &PositionV2{File: e.Filename, Line: e.Line},
+		})
+	}
+	for _, e := range pkg.CgoExports {
+		v2.CgoExports = append(v2.CgoExports, &CgoExportV2{
+			Name:      e.Name,
+			Signature: e.Signature,
+			Doc:       e.Doc,
+			Position:  &PositionV2{File: e.Filename, Line: e.Line},
+		})
+	}
+	return v2
+}
+
+func convertValuesV2(values []*Value) []*ValueV2 {
+	v2 := make([]*ValueV2, len(values))
+	for i, v := range values {
+		v2[i] = &ValueV2{
+			Kind:             v.Type,
+			Names:            v.Names,
+			Doc:              v.Doc,
+			Position:         &PositionV2{File: v.Filename, Line: v.Line, Offset: v.Offset, EndOffset: v.EndOffset},
+			Page:             v.Page,
+			BuildConstraints: v.BuildConstraints,
+			Platforms:        v.Platforms,
+			Directives:       v.Directives,
+			Source:           v.Source,
+			Instantiations:   v.Instantiations,
+		}
+	}
+	return v2
+}
+
+func convertFuncsV2(funcs []*Func) []*FuncV2 {
+	v2 := make([]*FuncV2, len(funcs))
+	for i, f := range funcs {
+		v2[i] = &FuncV2{
+			Kind:             "func",
+			Name:             f.Name,
+			Doc:              f.Doc,
+			Signature:        f.Signature,
+			Params:           f.Params,
+			Results:          f.Results,
+			Position:         &PositionV2{File: f.Filename, Line: f.Line, Offset: f.Offset, EndOffset: f.EndOffset},
+			Page:             f.Page,
+			BuildConstraints: f.BuildConstraints,
+			Platforms:        f.Platforms,
+			Directives:       f.Directives,
+			Source:           f.Source,
+			Examples:         f.Examples,
+		}
+		if f.Recv != "" {
+			v2[i].Kind = "method"
+			v2[i].Receiver = &ReceiverV2{Type: f.Recv, Original: f.Orig, EmbeddingLevel: f.Level}
+		}
+		if f.Body != "" {
+			v2[i].Body = &FuncBodyV2{Text: f.Body, Lines: f.BodyLines}
+		}
+	}
+	return v2
+}
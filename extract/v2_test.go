@@ -0,0 +1,65 @@
+package extract
+
+import "testing"
+
+func TestConvertV2(t *testing.T) {
+	pkg := extractSource(t, `// Package p is documented.
+package p
+
+// Max is the largest value.
+const Max = 10
+
+// T is a type.
+type T struct {
+	N int // N counts.
+}
+
+// NewT returns a T.
+func NewT() T { return T{} }
+
+// Get returns N.
+func (t T) Get() int { return t.N }
+
+// F does nothing.
+func F(a, b int) {}
+
+// TODO(bob): do something.
+`)
+	v2 := ConvertV2(pkg)
+	if v2.SchemaVersion != SchemaVersionV2 || v2.Kind != "package" || v2.Name != "p" {
+		t.Errorf("got package %s %s %s", v2.SchemaVersion, v2.Kind, v2.Name)
+	}
+	if len(v2.Consts) != 1 || v2.Consts[0].Kind != "const" || v2.Consts[0].Position.Line != 5 {
+		t.Errorf("got consts %+v", v2.Consts)
+	}
+	if len(v2.Funcs) != 1 || v2.Funcs[0].Kind != "func" || len(v2.Funcs[0].Params) != 2 || v2.Funcs[0].Receiver != nil {
+		t.Errorf("got funcs %+v", v2.Funcs)
+	}
+	if len(v2.Types) != 1 {
+		t.Fatalf("got %d types, want 1", len(v2.Types))
+	}
+	typ := v2.Types[0]
+	for _, test := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"kind", typ.Kind, "type"},
+		{"typeKind", typ.TypeKind, "struct"},
+		{"position line", typ.Position.Line, 8},
+		{"funcs", len(typ.Funcs), 1},
+		{"func kind", typ.Funcs[0].Kind, "func"},
+		{"methods", len(typ.Methods), 1},
+		{"method kind", typ.Methods[0].Kind, "method"},
+		{"receiver", typ.Methods[0].Receiver.Type, "T"},
+		{"fields", len(typ.Fields), 1},
+		{"field comment", typ.Fields[0].Comment, "N counts.\n"},
+		{"field line", typ.Fields[0].Position.Line, 9},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if len(v2.Notes) != 1 || v2.Notes[0].Marker != "TODO" || v2.Notes[0].UID != "bob" {
+		t.Errorf("got notes %+v", v2.Notes)
+	}
+}
@@ -0,0 +1,54 @@
+package extract
+
+import (
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// VCS identifies the revision of the repository the documentation was
+// extracted from.
+type VCS struct {
+	Type   string `json:"type"` // "git"
+	Commit string `json:"commit"`
+	Dirty  bool   `json:"dirty"`            // uncommitted changes in the working tree
+	Tag    string `json:"tag,omitempty"`    // tag pointing at the commit
+	Remote string `json:"remote,omitempty"` // URL of the origin remote, without credentials
+}
+
+// git runs a git command in directory and returns its trimmed output.
+func git(directory string, args ...string) (string, error) {
+	out, err := exec.Command("git", append([]string{"-C", directory}, args...)...).Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// readVCS returns the revision of the git repository containing directory,
+// or nil if it is not part of one.
+func readVCS(directory string, opts Options) *VCS {
+	commit, err := git(directory, "rev-parse", "HEAD")
+	if err != nil {
+		return nil
+	}
+	vcs := &VCS{Type: "git", Commit: commit}
+	if status, err := git(directory, "status", "--porcelain", "--untracked-files=no"); err == nil {
+		vcs.Dirty = status != ""
+	} else {
+		opts.warnf("cannot read git status of %s: %s", directory, err)
+	}
+	// Both fail when there is no such tag or remote.
+	vcs.Tag, _ = git(directory, "describe", "--tags", "--exact-match", "HEAD")
+	remote, _ := git(directory, "remote", "get-url", "origin")
+	vcs.Remote = stripCredentials(remote)
+	return vcs
+}
+
+// stripCredentials removes the user information of a remote URL, which may
+// hold access tokens. scp-like remotes such as git@host:path are kept.
+func stripCredentials(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		return remote
+	}
+	u.User = nil
+	return u.String()
+}
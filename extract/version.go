@@ -0,0 +1,65 @@
+package extract
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// BuildInfo identifies the build of godocjson that produced a document.
+type BuildInfo struct {
+	Version   string `json:"version"`            // module version, "(devel)" for development builds
+	Commit    string `json:"commit,omitempty"`   // VCS revision the binary was built from
+	Modified  bool   `json:"modified,omitempty"` // uncommitted changes at build time
+	GoVersion string `json:"goVersion"`          // Go version the binary was built with
+}
+
+// ReadBuildInfo returns the build information recorded by the go command
+// in the binary.
+func ReadBuildInfo() *BuildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return &BuildInfo{Version: "(unknown)"}
+	}
+	info := &BuildInfo{Version: bi.Main.Version, GoVersion: bi.GoVersion}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// String formats info as printed by -version, e.g.
+// "godocjson v1.2.0 (commit 1a2b3c4) built with go1.22.1".
+func (info *BuildInfo) String() string {
+	s := "godocjson " + info.Version
+	if info.Commit != "" {
+		s += " (commit " + info.Commit
+		if info.Modified {
+			s += ", modified"
+		}
+		s += ")"
+	}
+	if info.GoVersion != "" {
+		s += fmt.Sprintf(" built with %s", info.GoVersion)
+	}
+	return s
+}
+
+// ToolVersion returns the version of godocjson, as recorded by the go
+// command in the binary: the module version, or the VCS revision for
+// development builds.
+func ToolVersion() string {
+	info := ReadBuildInfo()
+	version := info.Version
+	if info.Commit != "" {
+		version += " " + info.Commit
+	}
+	if info.Modified {
+		version += " (modified)"
+	}
+	return version
+}
@@ -0,0 +1,18 @@
+package extract
+
+import (
+	"fmt"
+	"log"
+)
+
+// warnf reports a problem that makes the documentation incomplete without
+// preventing it from being produced, to opts.Warn if set and otherwise on
+// the standard logger.
+func (opts Options) warnf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if opts.Warn != nil {
+		opts.Warn(message)
+		return
+	}
+	log.Printf("Warning: %s", message)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/mod/module"
+)
+
+// maxModuleZipSize is the size limit of module zip files, as enforced by the
+// go command.
+const maxModuleZipSize = 500 << 20
+
+// proxy is an entry of GOPROXY.
+type proxy struct {
+	URL string // base URL of a module proxy, "direct" or "off"
+	// FallBack passes the requests failing with any error to the next
+	// entry, as when the entry is followed by "|". Otherwise, as when it is
+	// followed by ",", only 404 and 410 responses do.
+	FallBack bool
+}
+
+// goproxies returns the entries of GOPROXY, in order.
+func goproxies() ([]proxy, error) {
+	value := os.Getenv("GOPROXY")
+	if value == "" {
+		value = "https://proxy.golang.org,direct"
+	}
+	var proxies []proxy
+	for value != "" {
+		entry, rest := value, ""
+		fallBack := false
+		if i := strings.IndexAny(value, ",|"); i >= 0 {
+			entry, rest, fallBack = value[:i], value[i+1:], value[i] == '|'
+		}
+		value = rest
+		if entry = strings.TrimSpace(entry); entry != "" {
+			proxies = append(proxies, proxy{URL: strings.TrimSuffix(entry, "/"), FallBack: fallBack})
+		}
+	}
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("GOPROXY lists no module proxy")
+	}
+	return proxies, nil
+}
+
+// matchesPrivate reports whether modPath matches the patterns of the
+// environment variable name, GONOPROXY or GONOSUMDB, which defaults to
+// GOPRIVATE as in the go command.
+func matchesPrivate(name, modPath string) bool {
+	patterns := os.Getenv(name)
+	if patterns == "" {
+		patterns = os.Getenv("GOPRIVATE")
+	}
+	return module.MatchPrefixPatterns(patterns, modPath)
+}
+
+// escapeModulePath escapes the upper-case letters of a module path or
+// version for module proxies and the module cache, e.g.
+// "github.com/!azure/sdk" for "github.com/Azure/sdk".
+func escapeModulePath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// proxyGet returns the body of the file name of module modPath from the
+// first entry of proxies serving it. Requests pass to the next entry as
+// described by proxy.FallBack, like the go command does. "direct" entries
+// are skipped, since downloading from version control systems is not
+// supported, and "off" entries end the lookup.
+func proxyGet(proxies []proxy, modPath, name string, limit int64) ([]byte, error) {
+	var lastErr error
+	for _, p := range proxies {
+		switch p.URL {
+		case "off":
+			if lastErr == nil {
+				lastErr = fmt.Errorf("module downloads are disabled by GOPROXY=off")
+			}
+			return nil, lastErr
+		case "direct":
+			if lastErr == nil {
+				lastErr = fmt.Errorf("%s: downloads from version control systems (GOPROXY=direct) are not supported", modPath)
+			}
+			continue
+		}
+		body, notFound, err := proxyGetURL(p.URL+"/"+escapeModulePath(modPath)+"/"+name, limit)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if !notFound && !p.FallBack {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// proxyGetURL returns the body of url, up to limit bytes, and whether it
+// failed with a 404 or 410 response.
+func proxyGetURL(url string, limit int64) ([]byte, bool, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, false, err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	resp.Body.Close()
+	switch {
+	case err != nil:
+		return nil, false, fmt.Errorf("%s: %s", url, err)
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return nil, true, fmt.Errorf("%s: %s", url, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("%s: %s", url, resp.Status)
+	case int64(len(body)) > limit:
+		return nil, false, fmt.Errorf("%s: larger than %d bytes", url, limit)
+	}
+	return body, false, nil
+}
+
+// FetchModule downloads the module named by spec, of the form
+// "path@version", from the module proxies of GOPROXY and returns the
+// directory it is extracted to. The version may be "latest", or omitted
+// for the latest version. The module zip is verified with verifyModule.
+// Modules are extracted once, below the user cache directory, and reused
+// by later calls. Modules matching GONOPROXY or GOPRIVATE, which the go
+// command downloads from version control systems, are not supported.
+func FetchModule(spec string) (string, error) {
+	modPath, version, _ := strings.Cut(spec, "@")
+	if modPath == "" {
+		return "", fmt.Errorf("invalid module %q, expected path@version", spec)
+	}
+	if matchesPrivate("GONOPROXY", modPath) {
+		return "", fmt.Errorf("%s matches GONOPROXY or GOPRIVATE: downloads from version control systems are not supported", modPath)
+	}
+	proxies, err := goproxies()
+	if err != nil {
+		return "", err
+	}
+	if version == "" || version == "latest" {
+		body, err := proxyGet(proxies, modPath, "@latest", 1<<20)
+		if err != nil {
+			return "", err
+		}
+		var info struct{ Version string }
+		if err := json.Unmarshal(body, &info); err != nil || info.Version == "" {
+			return "", fmt.Errorf("%s: invalid latest version information", modPath)
+		}
+		version = info.Version
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cacheDir, "godocjson", "mod", filepath.FromSlash(escapeModulePath(modPath))+"@"+escapeModulePath(version))
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	zipData, err := proxyGet(proxies, modPath, "@v/"+escapeModulePath(version)+".zip", maxModuleZipSize)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyModule(modPath, version, zipData); err != nil {
+		return "", err
+	}
+	err = extractOnce(dir, func(tmp string) error {
+		if err := extractZip(zipData, modPath+"@"+version+"/", tmp); err != nil {
+			return fmt.Errorf("%s: %s", spec, err)
+		}
+		if _, err := os.Stat(filepath.Join(tmp, "go.mod")); err == nil {
+			return nil
+		}
+		// Modules predating go.mod files have one synthesized by the go
+		// command.
+		return os.WriteFile(filepath.Join(tmp, "go.mod"), []byte("module "+modPath+"\n"), 0644)
+	})
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// extractOnce calls extract to fill a temporary directory, renamed to dir
+// once complete so that interrupted extractions are not reused. It does
+// nothing if dir exists.
+func extractOnce(dir string, extract func(tmp string) error) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extract(tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, statErr := os.Stat(dir); statErr == nil {
+			// Extracted concurrently by another process.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// extractZip extracts the files of a zip archive to dir, rejecting those
+// outside of it. If prefix is not empty, all files must be below it, e.g.
+// "path@version/" in module zips, and it is removed from their names.
+func extractZip(data []byte, prefix string, dir string) error {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		name, ok := strings.CutPrefix(f.Name, prefix)
+		if !ok {
+			return fmt.Errorf("file %s of archive is not below %s", f.Name, prefix)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = writeExtractedFile(dir, name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExtractedFile writes the content of the archive file name, a
+// slash-separated path, below dir.
+func writeExtractedFile(dir, name string, r io.Reader) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) || path.Clean(name) != name {
+		return fmt.Errorf("invalid file name %s in archive", name)
+	}
+	target := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// fetchModules downloads the modules named by specs with FetchModule, and
+// returns the patterns naming their packages.
+func fetchModules(specs []string) ([]string, error) {
+	var patterns []string
+	for _, spec := range specs {
+		dir, err := FetchModule(spec)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, filepath.Join(dir, "..."))
+	}
+	return patterns, nil
+}
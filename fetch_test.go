@@ -0,0 +1,160 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb"
+	"golang.org/x/mod/sumdb/note"
+)
+
+// zipFiles returns a zip archive of files, keyed by name.
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, src := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, src)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// newProxy returns a module proxy serving zipData as example.com/m
+// v1.0.0.
+func newProxy(t *testing.T, zipData []byte) *httptest.Server {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/m/@v/v1.0.0.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(zipData)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// newSumDB returns the GOSUMDB value of a checksum database listing hash
+// for the zip of example.com/m v1.0.0.
+func newSumDB(t *testing.T, hash string) string {
+	skey, vkey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := sumdb.NewTestServer(skey, func(path, vers string) ([]byte, error) {
+		return []byte(path + " " + vers + " " + hash + "\n" + path + " " + vers + "/go.mod h1:x\n"), nil
+	})
+	s := httptest.NewServer(sumdb.NewServer(db))
+	t.Cleanup(s.Close)
+	return vkey + " " + s.URL
+}
+
+// setupFetch isolates FetchModule from the environment: modules are
+// extracted to a temporary cache directory, and the working directory is
+// outside of any module.
+func setupFetch(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range []string{"GOPRIVATE", "GONOPROXY", "GONOSUMDB"} {
+		t.Setenv(name, "")
+	}
+	t.Chdir(t.TempDir())
+}
+
+func TestFetchModuleVerification(t *testing.T) {
+	zipData := zipFiles(t, moduleFiles)
+	hash, err := hashZip(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name    string
+		sumHash string // listed by the checksum database
+		goSum   string // listed by the go.sum of the working directory, if any
+		env     map[string]string
+		err     string // expected error, if any
+	}{
+		{name: "sumdb", sumHash: hash},
+		{name: "sumdb mismatch", sumHash: "h1:AAAA", err: "checksum mismatch"},
+		{name: "go.sum", sumHash: "h1:AAAA", goSum: hash},
+		{name: "go.sum mismatch", sumHash: hash, goSum: "h1:AAAA", err: "checksum mismatch"},
+		{name: "GOSUMDB=off", sumHash: "h1:AAAA", env: map[string]string{"GOSUMDB": "off"}},
+		{name: "GONOSUMDB", sumHash: "h1:AAAA", env: map[string]string{"GONOSUMDB": "example.com"}},
+		{name: "GOPRIVATE", sumHash: hash, env: map[string]string{"GOPRIVATE": "example.com"}, err: "matches GONOPROXY or GOPRIVATE"},
+		{name: "GOPRIVATE with GONOPROXY", sumHash: "h1:AAAA", env: map[string]string{"GOPRIVATE": "example.com", "GONOPROXY": "none"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			setupFetch(t)
+			t.Setenv("GOPROXY", newProxy(t, zipData).URL)
+			t.Setenv("GOSUMDB", newSumDB(t, test.sumHash))
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			if test.goSum != "" {
+				os.WriteFile("go.mod", []byte("module example.com/main\n"), 0644)
+				os.WriteFile("go.sum", []byte("example.com/m v1.0.0 "+test.goSum+"\n"), 0644)
+			}
+			dir, err := FetchModule("example.com/m@v1.0.0")
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "p", "p.go")); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGOPROXYFallBack(t *testing.T) {
+	zipData := zipFiles(t, moduleFiles)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	good := newProxy(t, zipData).URL
+
+	for _, test := range []struct {
+		goproxy string
+		ok      bool
+	}{
+		{failing.URL + "|" + good, true},
+		{failing.URL + "," + good, false},
+		{missing.URL + "," + good, true},
+		{"direct," + good, true},
+		{"off," + good, false},
+	} {
+		t.Run(test.goproxy, func(t *testing.T) {
+			setupFetch(t)
+			t.Setenv("GOSUMDB", "off")
+			t.Setenv("GOPROXY", test.goproxy)
+			_, err := FetchModule("example.com/m@v1.0.0")
+			if test.ok && err != nil {
+				t.Errorf("got error %v", err)
+			} else if !test.ok && err == nil {
+				t.Errorf("got no error")
+			}
+		})
+	}
+}
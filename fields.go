@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// fieldsFormats are the formats whose symbols -fields applies to, and whose
+// empty values -omitempty drops.
+var fieldsFormats = map[string]bool{
+	"json":           true,
+	"ndjson":         true,
+	"ndjson-symbols": true,
+}
+
+// nestedDeclFields are the fields of types listing declarations of their
+// own, whose symbols are trimmed in turn rather than dropped.
+var nestedDeclFields = []string{"consts", "vars", "funcs", "methods"}
+
+// symbolFieldNames returns the JSON names of the fields of symbols, those
+// of extract.Func, extract.Type and extract.Value, or of their v2 schema
+// counterparts.
+func symbolFieldNames(v2 bool) map[string]bool {
+	symbols := []interface{}{extract.Func{}, extract.Type{}, extract.Value{}}
+	if v2 {
+		symbols = []interface{}{extract.FuncV2{}, extract.TypeV2{}, extract.ValueV2{}}
+	}
+	names := map[string]bool{}
+	for _, symbol := range symbols {
+		t := reflect.TypeOf(symbol)
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}
+
+// parseFields parses the comma-separated list of symbol fields of -fields,
+// e.g. "doc,name,signature,line", in the v1 or v2 schema. Selecting "name"
+// also selects the "names" of values.
+func parseFields(list string, v2 bool) (map[string]bool, error) {
+	known := symbolFieldNames(v2)
+	fields := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("-fields: unknown symbol field %q", name)
+		}
+		fields[name] = true
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("-fields: no symbol field selected")
+	}
+	if fields["name"] {
+		fields["names"] = true
+	}
+	return fields, nil
+}
+
+// jsonValue returns v as decoded from its JSON encoding, made of maps,
+// slices and basic values.
+func jsonValue(v interface{}) (interface{}, error) {
+	vJSON, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %s", err)
+	}
+	var value interface{}
+	err = json.Unmarshal(vJSON, &value)
+	return value, err
+}
+
+// jsonObject is a JSON object decoded by orderedJSONValue, whose members
+// are encoded in their original order.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		keyJSON, _ := json.Marshal(key)
+		valueJSON, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(keyJSON)
+		b.WriteByte(':')
+		b.Write(valueJSON)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// deleteIf removes the members of o for which remove returns true.
+func (o *jsonObject) deleteIf(remove func(key string, value interface{}) bool) {
+	keys := o.keys[:0]
+	for _, key := range o.keys {
+		if remove(key, o.values[key]) {
+			delete(o.values, key)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	o.keys = keys
+}
+
+// orderedJSONValue returns v as decoded from its JSON encoding, like
+// jsonValue, but with objects decoded as *jsonObject, so that the fields of
+// structs keep their order, and numbers as json.Number.
+func orderedJSONValue(v interface{}) (interface{}, error) {
+	vJSON, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %s", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(vJSON))
+	dec.UseNumber()
+	return decodeOrdered(dec)
+}
+
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		o := &jsonObject{values: map[string]interface{}{}}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			o.keys = append(o.keys, key.(string))
+			o.values[key.(string)] = value
+		}
+		_, err := dec.Token()
+		return o, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return tok, nil
+}
+
+// trimmed returns the options of opts dropping members of documents, as
+// listed by their trimmed field.
+func (opts *outputOptions) trimmed() []string {
+	var trimmed []string
+	if opts.Fields != nil {
+		trimmed = append(trimmed, "fields")
+	}
+	if opts.OmitEmpty {
+		trimmed = append(trimmed, "omitempty")
+	}
+	return trimmed
+}
+
+// document returns the value written as the JSON document of pkg: pkg
+// itself, or its v2 schema document with opts.SchemaV2, or their JSON value
+// whose symbols only hold the fields selected by opts.Fields and without the
+// empty values dropped by opts.OmitEmpty. Such documents list these options
+// as trimmed, so that validate does not require the members dropped.
+func (opts *outputOptions) document(pkg *extract.Package) (interface{}, error) {
+	trimmed := opts.trimmed()
+	if trimmed != nil {
+		p := *pkg
+		p.Trimmed = trimmed
+		pkg = &p
+	}
+	var v interface{} = pkg
+	if opts.SchemaV2 {
+		v = extract.ConvertV2(pkg)
+	}
+	if trimmed == nil {
+		return v, nil
+	}
+	doc, err := orderedJSONValue(v)
+	if err != nil {
+		return nil, err
+	}
+	o := doc.(*jsonObject)
+	if opts.Fields != nil {
+		for _, key := range []string{"consts", "vars", "funcs", "types"} {
+			for _, symbol := range asList(o.values[key]) {
+				selectFields(symbol, opts.Fields)
+			}
+		}
+	}
+	if opts.OmitEmpty {
+		omitEmpty(o)
+	}
+	return o, nil
+}
+
+// symbol returns the value written for a *extract.Package, *extract.Type,
+// *extract.Func or *extract.Value like document does: the symbol itself,
+// or its JSON value only holding the fields selected by opts.Fields,
+// unless a package, and without the empty values dropped by opts.OmitEmpty.
+func (opts *outputOptions) symbol(symbol interface{}) (interface{}, error) {
+	if opts.Fields == nil && !opts.OmitEmpty {
+		return symbol, nil
+	}
+	value, err := orderedJSONValue(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := symbol.(*extract.Package); !ok && opts.Fields != nil {
+		selectFields(value, opts.Fields)
+	}
+	if opts.OmitEmpty {
+		omitEmpty(value)
+	}
+	return value, nil
+}
+
+// selectFields removes the fields of the JSON value of a symbol that are
+// not in fields. The declarations listed by types are kept and trimmed in
+// turn, unless empty.
+func selectFields(symbol interface{}, fields map[string]bool) {
+	o, ok := symbol.(*jsonObject)
+	if !ok {
+		return
+	}
+	nested := map[string]bool{}
+	for _, key := range nestedDeclFields {
+		if list := asList(o.values[key]); len(list) > 0 {
+			for _, symbol := range list {
+				selectFields(symbol, fields)
+			}
+			nested[key] = true
+		}
+	}
+	o.deleteIf(func(key string, _ interface{}) bool {
+		return !fields[key] && !nested[key]
+	})
+}
+
+// omitEmpty removes the members of the objects of the JSON value v, at any
+// depth, whose value is empty: null, false, 0, "", or an empty array or
+// object, possibly after removing the empty members of the object. The
+// elements of arrays are kept.
+func omitEmpty(v interface{}) {
+	switch v := v.(type) {
+	case *jsonObject:
+		v.deleteIf(func(_ string, member interface{}) bool {
+			omitEmpty(member)
+			return isEmptyJSON(member)
+		})
+	case []interface{}:
+		for _, elem := range v {
+			omitEmpty(elem)
+		}
+	}
+}
+
+// isEmptyJSON reports whether the JSON value v is null, false, 0, "", or an
+// empty array or object.
+func isEmptyJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case *jsonObject:
+		return len(v.keys) == 0
+	}
+	return false
+}
+
+// asList returns the elements of a JSON array value, or nil.
+func asList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
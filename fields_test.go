@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	for _, test := range []struct {
+		list string
+		v2   bool
+		want map[string]bool
+		err  bool
+	}{
+		{"doc", false, map[string]bool{"doc": true}, false},
+		{"doc, line", false, map[string]bool{"doc": true, "line": true}, false},
+		{"name,doc", false, map[string]bool{"name": true, "names": true, "doc": true}, false},
+		{"signature,,", false, map[string]bool{"signature": true}, false},
+		{"doc,nope", false, nil, true},
+		{"", false, nil, true},
+		{" , ", false, nil, true},
+		{"recv", false, map[string]bool{"recv": true}, false},
+		{"recv", true, nil, true},
+		{"kind,position", true, map[string]bool{"kind": true, "position": true}, false},
+		{"position", false, nil, true},
+	} {
+		got, err := parseFields(test.list, test.v2)
+		if (err != nil) != test.err {
+			t.Errorf("parseFields(%q, %v): got error %v", test.list, test.v2, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseFields(%q, %v) = %v, want %v", test.list, test.v2, got, test.want)
+		}
+	}
+}
+
+func TestFieldsFormatter(t *testing.T) {
+	pkg := extractSource(t, schemaSource)
+	var b bytes.Buffer
+	opts := &outputOptions{Fields: map[string]bool{"name": true, "names": true, "doc": true}}
+	if err := jsonFormatter(opts)(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Name   string
+		Consts []map[string]interface{}
+		Types  []map[string]interface{}
+	}
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Name != "p" {
+		t.Errorf("got package name %q, want p", doc.Name)
+	}
+	wantConst := map[string]interface{}{"names": []interface{}{"Max"}, "doc": "Max is the largest value.\n"}
+	if len(doc.Consts) != 1 || !reflect.DeepEqual(doc.Consts[0], wantConst) {
+		t.Errorf("got consts %v, want [%v]", doc.Consts, wantConst)
+	}
+	if len(doc.Types) != 1 {
+		t.Fatalf("got %d types, want 1", len(doc.Types))
+	}
+	// F returns a T, it is listed and trimmed under the type.
+	wantType := map[string]interface{}{
+		"name": "T",
+		"doc":  "T is a type.\n",
+		"funcs": []interface{}{
+			map[string]interface{}{"name": "F", "doc": "F returns t.\n"},
+		},
+	}
+	if !reflect.DeepEqual(doc.Types[0], wantType) {
+		t.Errorf("got type %v, want %v", doc.Types[0], wantType)
+	}
+}
+
+func TestTrimmedDocumentsValidate(t *testing.T) {
+	pkg := extractSource(t, "// Package p is documented.\npackage p\n\n// F does nothing.\nfunc F() {}\n\n// T is a type.\ntype T struct{ X int }\n")
+	for name, opts := range map[string]*outputOptions{
+		"omitempty":    {OmitEmpty: true},
+		"fields":       {Fields: map[string]bool{"name": true, "names": true, "line": true}},
+		"omitempty v2": {OmitEmpty: true, SchemaV2: true},
+		"fields v2":    {Fields: map[string]bool{"name": true}, SchemaV2: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := jsonFormatter(opts)(&b, pkg); err != nil {
+				t.Fatal(err)
+			}
+			var report bytes.Buffer
+			if invalid, err := validateDocuments(&report, name, &b); err != nil || invalid > 0 {
+				t.Errorf("invalid document: %v\n%s", err, report.String())
+			}
+		})
+	}
+}
+
+func TestTrimmedDocumentsKeepFieldOrder(t *testing.T) {
+	pkg := extractSource(t, "// Package p is documented.\npackage p\n\n// F does nothing.\nfunc F() {}\n")
+	var b bytes.Buffer
+	if err := jsonFormatter(&outputOptions{OmitEmpty: true})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	doc := b.String()
+	// The order of the fields of extract.Package.
+	last := -1
+	for _, key := range []string{`"schemaVersion"`, `"type"`, `"doc"`, `"name"`, `"importPath"`, `"funcs"`, `"trimmed"`} {
+		i := strings.Index(doc, key)
+		if i < last {
+			t.Errorf("%s out of order in %s", key, doc)
+		}
+		last = i
+	}
+	if strings.Contains(doc, `"recv"`) || strings.Contains(doc, `"notes"`) {
+		t.Errorf("empty fields written: %s", doc)
+	}
+}
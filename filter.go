@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+	"github.com/rtfd/godocjson/extract"
+)
+
+// filterFormats are the formats whose output -filter applies to, and the
+// options of the JSON documents written for the results of the filter.
+var filterFormats = map[string]func(opts *outputOptions) *outputOptions{
+	"json":   func(opts *outputOptions) *outputOptions { return opts },
+	"ndjson": (*outputOptions).line,
+}
+
+// filterFormatter applies the jq expression to the JSON document of every
+// package, and writes each of its results as a JSON document in the given
+// format, one of filterFormats.
+func filterFormatter(expr, format string, opts *outputOptions) (formatter, error) {
+	resultOpts, ok := filterFormats[format]
+	if !ok {
+		return nil, fmt.Errorf("-filter cannot be used with format %q, only with json or ndjson", format)
+	}
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("-filter: %s", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("-filter: %s", err)
+	}
+	opts = resultOpts(opts)
+	return func(w io.Writer, pkg *extract.Package) error {
+		doc, err := opts.document(pkg)
+		if err != nil {
+			return err
+		}
+		// gojq only operates on the values decoded from JSON.
+		v, err := jsonValue(doc)
+		if err != nil {
+			return err
+		}
+		iter := code.Run(v)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				return nil
+			}
+			if err, ok := result.(error); ok {
+				var halt *gojq.HaltError
+				if errors.As(err, &halt) && halt.Value() == nil {
+					return nil
+				}
+				return fmt.Errorf("-filter: %s: %s", pkg.ImportPath, err)
+			}
+			if err := writeJSON(w, result, opts); err != nil {
+				return err
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilterFormatter(t *testing.T) {
+	tests := []struct {
+		expr, format string
+		want         string
+		err          bool
+	}{
+		{".name", "json", "\"p\"\n", false},
+		{"[.funcs[]?.name]", "json", "[]\n", false},
+		{".consts[].names[]", "ndjson", "\"Max\"\n", false},
+		{"{name, n: (.types | length)}", "ndjson", "{\"n\":1,\"name\":\"p\"}\n", false},
+		{"empty", "json", "", false},
+		{"halt", "json", "", false},
+		{".name", "rst", "", true},
+		{".[", "json", "", true},
+		{"$undefined", "json", "", true},
+	}
+	pkg := extractSource(t, schemaSource)
+	for _, test := range tests {
+		write, err := filterFormatter(test.expr, test.format, &outputOptions{})
+		if err != nil {
+			if !test.err {
+				t.Errorf("%s: %s", test.expr, err)
+			}
+			continue
+		}
+		if test.err {
+			t.Errorf("%s: got no error with format %s", test.expr, test.format)
+			continue
+		}
+		var b strings.Builder
+		if err := write(&b, pkg); err != nil {
+			t.Errorf("%s: %s", test.expr, err)
+			continue
+		}
+		if b.String() != test.want {
+			t.Errorf("%s: got %q, want %q", test.expr, b.String(), test.want)
+		}
+	}
+
+	write, err := filterFormatter("error(\"bad\")", "json", &outputOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := write(&strings.Builder{}, pkg); err == nil || !strings.Contains(err.Error(), "bad") {
+		t.Errorf("got error %v, want the error raised by the filter", err)
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/rtfd/godocjson
+
+go 1.24
+
+require (
+	github.com/fsnotify/fsnotify v1.8.0
+	github.com/itchyny/gojq v0.12.17
+	golang.org/x/mod v0.22.0
+	golang.org/x/tools v0.29.0
+)
+
+require (
+	github.com/itchyny/timefmt-go v0.1.6 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+)
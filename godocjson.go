@@ -1,330 +1,498 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
-	"go/ast"
 	"go/doc"
-	"go/parser"
-	"go/token"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
-)
-
-// Func represents a function declaration.
-type Func struct {
-	Doc               string      `json:"doc"`
-	Name              string      `json:"name"`
-	PackageName       string      `json:"packageName"`
-	PackageImportPath string      `json:"packageImportPath"`
-	Type              string      `json:"type"`
-	Filename          string      `json:"filename"`
-	Line              int         `json:"line"`
-	Params            []FuncParam `json:"parameters"`
-	Results           []FuncParam `json:"results"`
-
-	// methods
-	// (for functions, these fields have the respective zero value)
-	Recv string `json:"recv"` // actual   receiver "T" or "*T"
-	Orig string `json:"orig"` // original receiver "T" or "*T"
-	// Level int    // embedding level; 0 means not embedded
-}
-
-// Package represents a package declaration.
-type Package struct {
-	Type       string             `json:"type"`
-	Doc        string             `json:"doc"`
-	Name       string             `json:"name"`
-	ImportPath string             `json:"importPath"`
-	Imports    []string           `json:"imports"`
-	Filenames  []string           `json:"filenames"`
-	Notes      map[string][]*Note `json:"notes"`
-	// DEPRECATED. For backward compatibility Bugs is still populated,
-	// but all new code should use Notes instead.
-	Bugs []string `json:"bugs"`
-
-	// declarations
-	Consts []*Value `json:"consts"`
-	Types  []*Type  `json:"types"`
-	Vars   []*Value `json:"vars"`
-	Funcs  []*Func  `json:"funcs"`
-}
-
-// Note represents a note comment.
-type Note struct {
-	Pos  token.Pos `json:"pos"`
-	End  token.Pos `json:"end"`  // position range of the comment containing the marker
-	UID  string    `json:"uid"`  // uid found with the marker
-	Body string    `json:"body"` // note body text
-}
-
-// Type represents a type declaration.
-type Type struct {
-	PackageName       string `json:"packageName"`
-	PackageImportPath string `json:"packageImportPath"`
-	Doc               string `json:"doc"`
-	Name              string `json:"name"`
-	Type              string `json:"type"`
-	Filename          string `json:"filename"`
-	Line              int    `json:"line"`
-	// Decl              *ast.GenDecl
+	"text/template"
 
-	// associated declarations
-	Consts  []*Value `json:"consts"`  // sorted list of constants of (mostly) this type
-	Vars    []*Value `json:"vars"`    // sorted list of variables of (mostly) this type
-	Funcs   []*Func  `json:"funcs"`   // sorted list of functions returning this type
-	Methods []*Func  `json:"methods"` // sorted list of methods (including embedded ones) of this type
-}
+	"github.com/rtfd/godocjson/extract"
+)
 
-// Value represents a value declaration.
-type Value struct {
-	PackageName       string   `json:"packageName"`
-	PackageImportPath string   `json:"packageImportPath"`
-	Doc               string   `json:"doc"`
-	Names             []string `json:"names"` // var or const names in declaration order
-	Type              string   `json:"type"`
-	Filename          string   `json:"filename"`
-	Line              int      `json:"line"`
-	// Decl              *ast.GenDecl
-}
+// excludePatterns is the value of -e flags, which may be repeated and hold
+// comma-separated patterns.
+type excludePatterns []string
 
-// FuncParam represents a parameter to a function.
-type FuncParam struct {
-	Type string `json:"type"`
-	Name string `json:"name"`
+func (p *excludePatterns) String() string {
+	return strings.Join(*p, ",")
 }
 
-func typeOf(x interface{}) string {
-	switch x := x.(type) {
-	case *ast.Ident:
-		return x.String()
-	case *ast.ArrayType:
-		return "[]" + typeOf(x.Elt)
-	case *ast.Field:
-		return x.Names[0].Name + " " + typeOf(x.Type)
-	case *ast.StructType:
-		fields := make([]string, x.Fields.NumFields())
-		for i, f := range x.Fields.List {
-			fields[i] = typeOf(f.Type)
-		}
-		return fmt.Sprintf("struct{%s}", strings.Join(fields, ","))
-	case *ast.InterfaceType:
-		methods := make([]string, x.Methods.NumFields())
-		for i, m := range x.Methods.List {
-			methods[i] = typeOf(m.Type)
-		}
-		return fmt.Sprintf("interface{%s}", strings.Join(methods, ","))
-	case *ast.SelectorExpr:
-		return typeOf(x.X) + "." + x.Sel.Name
-	case *ast.Ellipsis:
-		return "..." + typeOf(x.Elt)
-	case *ast.StarExpr:
-		return "*" + typeOf(x.X)
-	case *ast.FuncType:
-		params := make([]string, x.Params.NumFields())
-		for i, p := range x.Params.List {
-			params[i] = typeOf(p.Type)
-		}
-		var results []string
-		if x.Results != nil {
-			results = make([]string, x.Results.NumFields())
-			for i, r := range x.Results.List {
-				results[i] = typeOf(r.Type)
+// Set adds the comma-separated patterns of value. Commas within brackets
+// or braces, as in "[,;]" or "x{1,3}", and escaped commas do not separate
+// patterns.
+func (p *excludePatterns) Set(value string) error {
+	depth, start := 0, 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case '[', '{':
+			depth++
+		case ']', '}':
+			if depth > 0 {
+				depth--
 			}
-		}
-		return fmt.Sprintf("func(%s)%s", strings.Join(params, ","), strings.Join(results, ","))
-	case *ast.MapType:
-		return fmt.Sprintf("map [%s]%s", typeOf(x.Key), typeOf(x.Value))
-	case *ast.ChanType:
-		if x.Dir == ast.SEND {
-			return fmt.Sprintf("chan<- %s", typeOf(x.Value))
-		} else if x.Dir == ast.RECV {
-			return fmt.Sprintf("<-chan %s", typeOf(x.Value))
-		} else {
-			return fmt.Sprintf("chan %s", typeOf(x.Value))
-		}
-	default:
-		panic(fmt.Sprintf("Unknown type %+v", x))
-	}
-}
-
-func processFuncDecl(d *ast.FuncDecl, fun *Func) {
-	fun.Params = make([]FuncParam, 0)
-	for _, f := range d.Type.Params.List {
-		t := typeOf(f.Type)
-		for _, name := range f.Names {
-			fun.Params = append(fun.Params, FuncParam{
-				Type: t,
-				Name: name.String(),
-			})
-		}
-	}
-	fun.Results = make([]FuncParam, 0)
-	if d.Type.Results != nil {
-		for _, f := range d.Type.Results.List {
-			t := typeOf(f.Type)
-			if len(f.Names) == 0 {
-				// For case func foo() Type
-				fun.Results = append(fun.Results, FuncParam{
-					Type: t,
-				})
-			} else {
-				// For case func foo() (name, name Type)
-				for _, name := range f.Names {
-					fun.Results = append(fun.Results, FuncParam{
-						Type: t,
-						Name: name.String(),
-					})
-				}
+		case ',':
+			if depth == 0 {
+				*p = append(*p, value[start:i])
+				start = i + 1
 			}
 		}
 	}
+	*p = append(*p, value[start:])
+	return nil
 }
 
-// CopyFuncs produces a json-annotated array of Func objects from an array of GoDoc Func objects.
-func CopyFuncs(f []*doc.Func, packageName string, packageImportPath string, fileSet *token.FileSet) []*Func {
-	newFuncs := make([]*Func, len(f))
-	for i, n := range f {
-		position := fileSet.Position(n.Decl.Pos())
-		newFuncs[i] = &Func{
-			Doc:               n.Doc,
-			Name:              n.Name,
-			PackageName:       packageName,
-			PackageImportPath: packageImportPath,
-			Type:              "func",
-			Orig:              n.Orig,
-			Recv:              n.Recv,
-			Filename:          position.Filename,
-			Line:              position.Line,
-		}
-		processFuncDecl(n.Decl, newFuncs[i])
+// documentDirectories documents the package in every directory and writes
+// it to out, recording its identifiers in collisions if not nil. Up to jobs
+// directories are parsed concurrently; packages are written in the order of
+// directories. The directories of archives are read from memory. Errors
+// documenting the optional directories are reported as warnings.
+func documentDirectories(directories []string, archives map[string]*archiveDir, opts extract.Options, out *outputTarget, jobs int, collisions *collisionIndex, optional map[string]bool) error {
+	type result struct {
+		pkgs []*extract.Package
+		err  error
 	}
-	return newFuncs
-}
-
-// CopyValues produces a json-annotated array of Value objects from an array of GoDoc Value objects.
-func CopyValues(c []*doc.Value, packageName string, packageImportPath string, fileSet *token.FileSet) []*Value {
-	newConsts := make([]*Value, len(c))
-	for i, c := range c {
-		position := fileSet.Position(c.Decl.TokPos)
-		newConsts[i] = &Value{
-			Doc:               c.Doc,
-			Names:             c.Names,
-			PackageName:       packageName,
-			PackageImportPath: packageImportPath,
-			Type:              c.Decl.Tok.String(),
-			Filename:          position.Filename,
-			Line:              position.Line,
-		}
+	results := make([]chan result, len(directories))
+	for i := range results {
+		results[i] = make(chan result, 1)
 	}
-	return newConsts
-}
-
-// CopyPackage produces a json-annotated Package object from a GoDoc Package object.
-func CopyPackage(pkg *doc.Package, fileSet *token.FileSet) Package {
-	newPkg := Package{
-		Type:       "package",
-		Doc:        pkg.Doc,
-		Name:       pkg.Name,
-		ImportPath: pkg.ImportPath,
-		Imports:    pkg.Imports,
-		Filenames:  pkg.Filenames,
-		Bugs:       pkg.Bugs,
+	if jobs < 1 {
+		jobs = 1
 	}
+	sem := make(chan struct{}, jobs)
+	go func() {
+		for i, directory := range directories {
+			sem <- struct{}{}
+			go func(i int, directory string) {
+				defer func() { <-sem }()
+				var r result
+				if archive := archives[directory]; archive != nil {
+					r.pkgs, r.err = extract.ParseFS(archive.FS, archive.Dir, opts)
+				} else {
+					r.pkgs, r.err = extract.ParseDirectoryPackages(directory, opts)
+				}
+				results[i] <- r
+			}(i, directory)
+		}
+	}()
 
-	newPkg.Notes = map[string][]*Note{}
-	for key, value := range pkg.Notes {
-		notes := make([]*Note, len(value))
-		for i, note := range value {
-			notes[i] = &Note{
-				Pos:  note.Pos,
-				End:  note.End,
-				UID:  note.UID,
-				Body: note.Body,
+	for i, directory := range directories {
+		r := <-results[i]
+		if r.err != nil && optional[directory] {
+			skipf(1, "%s, skipped", r.err)
+			continue
+		} else if r.err != nil {
+			return r.err
+		}
+		if len(r.pkgs) == 0 {
+			if marker, _ := extract.ReadMarker(directory); marker == nil || !marker.Exclude {
+				skipf(1, "no Go files in %s, skipped", directory)
 			}
+			continue
 		}
-		newPkg.Notes[key] = notes
-	}
-
-	newPkg.Consts = CopyValues(pkg.Consts, pkg.Name, pkg.ImportPath, fileSet)
-	newPkg.Funcs = CopyFuncs(pkg.Funcs, pkg.Name, pkg.ImportPath, fileSet)
-
-	newPkg.Types = make([]*Type, len(pkg.Types))
-	for i, t := range pkg.Types {
-		newPkg.Types[i] = &Type{
-			Name:              t.Name,
-			PackageName:       pkg.Name,
-			PackageImportPath: pkg.ImportPath,
-			Type:              "type",
-			Consts:            CopyValues(t.Consts, pkg.Name, pkg.ImportPath, fileSet),
-			Doc:               t.Doc,
-			Funcs:             CopyFuncs(t.Funcs, pkg.Name, pkg.ImportPath, fileSet),
-			Methods:           CopyFuncs(t.Methods, pkg.Name, pkg.ImportPath, fileSet),
-			Vars:              CopyValues(t.Vars, pkg.Name, pkg.ImportPath, fileSet),
+		if out.Path != "" && !out.Dir && len(r.pkgs) > 1 {
+			// Each package would replace the previous one.
+			return &usageError{fmt.Sprintf("%s holds %d packages, which cannot all be written to the file %s: end -o with %c to write a directory, or use -tests=false", directory, len(r.pkgs), out.Path, filepath.Separator)}
 		}
-	}
-
-	newPkg.Vars = CopyValues(pkg.Vars, pkg.Name, pkg.ImportPath, fileSet)
-	return newPkg
-}
-
-// Building filter function that can be used with parser.ParseDir
-func GetExcludeFilter(re string) func(os.FileInfo) bool {
-	if re != "" {
-		return func(info os.FileInfo) bool {
-			matched, err := regexp.MatchString(re, info.Name())
-			if err != nil {
-				panic(err)
-			} else {
-				return !matched
+		// The files with syntax errors skipped with -keep-going were
+		// reported by the extraction, and are listed in the diagnostics.
+		diagnosed := map[string]bool{}
+		for _, pkg := range r.pkgs {
+			for _, diagnostic := range pkg.Diagnostics {
+				diagnosed[diagnostic.File] = true
+			}
+		}
+		skipped.Add(int64(len(diagnosed)))
+		for _, pkg := range r.pkgs {
+			if err := out.WritePackage(pkg); err != nil {
+				return err
+			}
+			if collisions != nil {
+				collisions.add(directory, pkg)
 			}
 		}
 	}
-
-	// Returning nil by default results no filtering
 	return nil
 }
 
-func GetUsageText()  {
+func GetUsageText() {
 	log.Println("Usage of godocjson:")
-	log.Println("godocjson [-e] target_directory")
+	log.Println("godocjson [-e] [-format name | -template file] [-fields list] [-filter expr] [-o path] [-o-template template] target_directory|archive...")
+	log.Println("godocjson -stdlib [-format name] [-o path] import_path...")
+	log.Println("godocjson fetch [-format name] [-o path] module@version...")
+	log.Println("godocjson serve [-root dir] [-addr host:port]")
+	log.Println("godocjson grpc-server [-root dir] [-addr host:port] [-proto]")
+	log.Println("godocjson diff [-json] [-semver] old.json new.json")
+	log.Println("godocjson verify [-against pkgsite] [-version v] <directory>")
+	log.Println("godocjson html [-o dir] [-title title] target_directory...")
+	log.Println("godocjson hugo [-o site] [-format json|toml] target_directory...")
+	log.Println("godocjson docusaurus [-o dir] [-id-prefix prefix] [-sidebar name] target_directory...")
+	log.Println("godocjson graph [-internal] [-format json|dot] target_directory...")
+	log.Println("godocjson imports [-config file] [-format json|markdown] target_directory...")
+	log.Println("godocjson coverage [-format text|json] [-min percent] [-v] target_directory...")
+	log.Println("godocjson lint [-format text|json|sarif] target_directory...")
+	log.Println("godocjson tags [-o file] [-format ctags|etags] target_directory...")
+	log.Println("godocjson readme [-o API.md] target_directory")
+	log.Println("godocjson schema")
+	log.Println("godocjson validate file.json...")
 	flag.PrintDefaults()
 }
 
+// subcommands maps subcommand names to their implementation. Each receives
+// the remaining command line arguments and returns the exit status.
+var subcommands = map[string]func(args []string) int{
+	"coverage":    runCoverage,
+	"diff":        runDiff,
+	"docusaurus":  runDocusaurus,
+	"graph":       runGraph,
+	"grpc-server": runGRPCServer,
+	"html":        runHTML,
+	"hugo":        runHugo,
+	"imports":     runImports,
+	"lint":        runLint,
+	"readme":      runReadme,
+	"schema":      runSchema,
+	"serve":       runServe,
+	"tags":        runTags,
+	"validate":    runValidate,
+	"verify":      runVerify,
+}
+
 func main() {
-	var filter_regexp string
+	var filter excludePatterns
+	var format string
+	var output, outputTemplate, outputIndex string
+	var compact bool
+	var goroot, goCmd string
+	var strict bool
+	var watch bool
+	var jobs int
+	var useCache bool
+	var cacheDir string
+	var includeSymbols, excludeSymbols string
+	var excludeDirs string
+	var tests bool
+	var notes string
+	var collisionsFile string
+	var allDecls, allMethods, preserveAST bool
+	var opts extract.Options
+	var walkRules WalkRules
+	var deps int
+	var stdlib bool
+	var version bool
+	var implements bool
+	var buildTags, platforms string
+	var paramDocs string
+	var overlay string
+	var templateFile string
+	var filterExpr string
+	var fields string
+	var schema string
+	var err error
+	outputOpts := &outputOptions{}
 	// Disable timestamps inside the log file as we will just use it as wrapper
 	// around stderr for now.
 	log.SetFlags(0)
 
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+	// fetch takes the flags of the main command, documenting modules
+	// downloaded from GOPROXY in place of target directories.
+	fetch := len(os.Args) > 1 && os.Args[1] == "fetch"
+	if fetch {
+		os.Args = append(os.Args[:1:1], os.Args[2:]...)
+	}
+
 	flag.Usage = GetUsageText
-	flag.StringVar(&filter_regexp,"e", "", "Regex filter for excluding source files")
+	flag.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flag.StringVar(&format, "format", "json", "Output format: json, index, ndjson, ndjson-symbols, msgpack, cbor, sphinx-inv, rst, docfx, doxygen-xml, man, text, lunr, es-bulk, or exec:command to pipe JSON to an external renderer")
+	flag.StringVar(&output, "o", "", "Write output to this file, or to one file per package and page in this directory (several target directories, or an existing directory or path ending with /)")
+	flag.StringVar(&templateFile, "template", "", "Render each package through this text/template file in place of -format")
+	flag.StringVar(&filterExpr, "filter", "", "jq expression applied to the JSON document of each package, whose results are written in its place; requires -format json or ndjson")
+	flag.StringVar(&fields, "fields", "", "Comma-separated fields written for every symbol, e.g. doc,name,signature,line; requires -format json, ndjson or ndjson-symbols")
+	flag.BoolVar(&outputOpts.OmitEmpty, "omitempty", false, "Drop empty arrays, strings and objects, and zero values such as false or 0, from JSON output; requires -format json, ndjson or ndjson-symbols")
+	flag.StringVar(&schema, "schema", "v1", "Schema of the JSON documents: v1, or v2 with consistent names; v2 requires -format json or ndjson")
+	flag.StringVar(&outputTemplate, "o-template", "", "Template of the path of each package file in the -o directory, e.g. \"{{.ImportPath}}.json\"; implies writing to a directory")
+	flag.StringVar(&outputIndex, "o-index", "index.json", "Name of the file listing the files written to the -o directory; empty for none")
+	flag.StringVar(&outputOpts.Indent, "indent", "  ", "Indentation used for JSON output")
+	flag.BoolVar(&compact, "compact", false, "Write JSON output without any whitespace, overriding -indent")
+	flag.BoolVar(&outputOpts.Canonical, "canonical", false, "Write JSON output in the canonical form of RFC 8785, byte-identical for identical inputs; overrides -indent")
+	flag.StringVar(&goroot, "goroot", "", "GOROOT of the Go installation used to resolve and type-check packages")
+	flag.StringVar(&goCmd, "toolchain", "", "Go command (e.g. go1.22.1 or a path) whose GOROOT and version are used to resolve and type-check packages")
+	flag.BoolVar(&opts.ResolveEmbedded, "resolve-embedded", false, "List methods promoted from embedded types of other packages")
+	flag.StringVar(&paramDocs, "param-docs", "", "Describe parameters from the doc comment of functions with these comma-separated heuristics: sentences, lists or all")
+	flag.IntVar(&opts.SigWidth, "sig-width", 0, "Write function signatures longer than this with one parameter per line; 0 never wraps")
+	flag.BoolVar(&strict, "strict", false, "Exit with status 6 if any warning was reported")
+	flag.IntVar(&jobs, "j", runtime.GOMAXPROCS(0), "Number of packages parsed concurrently")
+	flag.BoolVar(&useCache, "cache", false, "Reuse the documentation of unchanged packages from the cache directory")
+	flag.StringVar(&cacheDir, "cache-dir", "", "Cache directory, implies -cache (default: godocjson in the user cache directory)")
+	flag.BoolVar(&allDecls, "all-decls", false, "Document all declarations, not just exported ones (go/doc AllDecls mode)")
+	flag.BoolVar(&allMethods, "all-methods", false, "Show all embedded methods, not just those of unexported embedded types (go/doc AllMethods mode)")
+	flag.BoolVar(&preserveAST, "preserve-ast", false, "Do not let go/doc modify the AST while documenting (go/doc PreserveAST mode)")
+	flag.BoolVar(&opts.Readme, "readme", false, "Include the README file of each package directory")
+	flag.BoolVar(&opts.VCS, "vcs", false, "Record the commit, dirty flag, tag and remote URL of the enclosing git repository in the metadata")
+	flag.BoolVar(&opts.Source, "source", false, "Include the declaration of every symbol as printed by gofmt")
+	flag.BoolVar(&opts.Bodies, "with-bodies", false, "Include the body of every function as written, with its line range; implies -preserve-ast")
+	flag.BoolVar(&opts.SkipGenerated, "skip-generated", false, "Skip files marked with a \"// Code generated ... DO NOT EDIT.\" comment")
+	flag.StringVar(&notes, "notes", "", "Comma-separated note markers to collect in any case, e.g. TODO,FIXME,SECURITY")
+	flag.BoolVar(&opts.DropUnknownNotes, "drop-unknown-notes", false, "Drop the notes of markers not listed by -notes")
+	flag.BoolVar(&tests, "tests", true, "Document _test.go files, and the external test package of each directory")
+	flag.StringVar(&includeSymbols, "include-symbols", "", "Regex selecting the symbols to document by name (Type.Method for methods)")
+	flag.StringVar(&excludeSymbols, "exclude-symbols", "", "Regex filter for excluding symbols by name (Type.Method for methods)")
+	flag.StringVar(&collisionsFile, "collisions", "", "Write the exported identifiers declared by several packages of a module to this file as JSON")
+	flag.BoolVar(&walkRules.Vendor, "include-vendor", false, "Include vendor directories below dir/... patterns")
+	flag.BoolVar(&walkRules.Testdata, "include-testdata", false, "Include testdata directories below dir/... patterns")
+	flag.BoolVar(&walkRules.Hidden, "include-hidden", false, "Include directories starting with \".\" or \"_\" below dir/... patterns")
+	flag.StringVar(&excludeDirs, "exclude-dirs", "", "Regex filter for excluding directories, and those below them, by their path relative to the root of dir/... patterns")
+	flag.BoolVar(&opts.KeepGoing, "keep-going", false, "Skip the files with syntax errors, listed in the diagnostics of the package, instead of failing")
+	flag.StringVar(&overlay, "overlay", "", "JSON file replacing the contents of source files, such as unsaved editor buffers, in the format of the -overlay flag of the go command")
+	flag.BoolVar(&stdlib, "stdlib", false, "Document the standard library packages of GOROOT/src named by import path instead of target directories, e.g. net/http, net/... or std")
+	flag.BoolVar(&opts.MethodSets, "method-sets", false, "List the method sets of T and *T, promoted methods included, for every type T")
+	flag.BoolVar(&implements, "implements", false, "List the interfaces of the documented packages implemented by each type, and the types implementing each interface")
+	flag.IntVar(&deps, "deps", 0, "Also document the packages imported by the target packages, up to this many levels of imports; -1 for all")
+	flag.BoolVar(&watch, "watch", false, "Keep running and write the output again whenever a .go file changes")
+	flag.StringVar(&opts.GOOS, "goos", "", "Document the files of this target operating system, e.g. windows, instead of all files")
+	flag.StringVar(&opts.GOARCH, "goarch", "", "Document the files of this target architecture, e.g. arm64, instead of all files")
+	flag.StringVar(&buildTags, "tags", "", "Comma-separated build tags satisfied by the documented files, e.g. integration,purego; selects files like -goos")
+	flag.StringVar(&platforms, "platforms", "", "Document each package for these comma-separated GOOS/GOARCH platforms, or \"default\" for those of pkg.go.dev, and merge the results")
+	flag.BoolVar(&version, "version", false, "Print the version, commit and Go version of godocjson and exit")
 	flag.Parse()
 
-	directory := flag.Arg(0)
-	if directory == "" {
-		flag.Usage()
-		log.Fatal("Fatal: Please specify a target_directory.", )
+	if version {
+		fmt.Println(extract.ReadBuildInfo())
+		return
 	}
 
-	fileSet := token.NewFileSet()
-	pkgs, firstError := parser.ParseDir(fileSet, directory, GetExcludeFilter(filter_regexp), parser.ParseComments|parser.AllErrors)
-	if firstError != nil {
-		panic(firstError)
-	}
-	if len(pkgs) > 1 {
-		panic("Multiple packages found in directory!\n")
-	}
-	for _, pkg := range pkgs {
-		docPkg := doc.New(pkg, directory, 0)
-		cleanedPkg := CopyPackage(docPkg, fileSet)
-		pkgJSON, err := json.MarshalIndent(cleanedPkg, "", "  ")
+	if goroot != "" || goCmd != "" {
+		tc, err := extract.DetectToolchain(goroot, goCmd)
 		if err != nil {
-			log.Fatal("Failed to encode JSON: %s", err)
+			log.Fatalf("Fatal: %s", err)
+		}
+		opts.Toolchain = tc
+	}
+	if platforms != "" {
+		if opts.GOOS != "" || opts.GOARCH != "" {
+			fatalf(exitUsage, "-platforms cannot be combined with -goos or -goarch")
+		}
+		if opts.Platforms, err = extract.ParsePlatforms(platforms); err != nil {
+			fatalf(exitUsage, "%s", err)
+		}
+	}
+	if paramDocs != "" {
+		if opts.ParamDocs, err = extract.ParseParamDocHeuristics(paramDocs); err != nil {
+			fatalf(exitUsage, "%s", err)
+		}
+	}
+	if overlay != "" {
+		if opts.Overlay, err = extract.ReadOverlay(overlay); err != nil {
+			fatalf(exitUsage, "%s", err)
+		}
+	}
+	opts.BuildTags = strings.FieldsFunc(buildTags, func(r rune) bool { return r == ',' || r == ' ' })
+	opts.Warn = func(message string) { warnf("%s", message) }
+	if compact {
+		outputOpts.Indent = ""
+	}
+	if opts.Filter, err = extract.GetExcludeFilter(filter...); err != nil {
+		fatalf(exitUsage, "%s", err)
+	}
+	if excludeDirs != "" {
+		if walkRules.ExcludeDirs, err = regexp.Compile(excludeDirs); err != nil {
+			fatalf(exitUsage, "invalid -exclude-dirs pattern: %s", err)
+		}
+	}
+	opts.ExcludeTests = !tests
+	if allDecls {
+		opts.Mode |= doc.AllDecls
+	}
+	if allMethods {
+		opts.Mode |= doc.AllMethods
+	}
+	if preserveAST {
+		opts.Mode |= doc.PreserveAST
+	}
+	if opts.ResolveEmbedded || opts.MethodSets {
+		opts.Imports = extract.NewImportCache()
+	}
+	opts.Notes = extract.ParseNoteMarkers(notes)
+	if opts.IncludeSymbols, err = extract.CompileSymbolFilter(includeSymbols); err != nil {
+		fatalf(exitUsage, "%s", err)
+	}
+	if opts.ExcludeSymbols, err = extract.CompileSymbolFilter(excludeSymbols); err != nil {
+		fatalf(exitUsage, "%s", err)
+	}
+	if useCache || cacheDir != "" {
+		if cacheDir == "" {
+			if cacheDir, err = extract.DefaultCacheDir(); err != nil {
+				log.Fatalf("Fatal: %s", err)
+			}
+		}
+		opts.Cache = &extract.Cache{Dir: cacheDir}
+	}
+	if templateFile != "" {
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "format" {
+				fatalf(exitUsage, "-template cannot be combined with -format")
+			}
+		})
+		format = "template:" + templateFile
+	}
+	switch schema {
+	case "v1":
+	case "v2":
+		if format != "json" && format != "ndjson" {
+			fatalf(exitUsage, "-schema v2 cannot be used with format %q, only with json or ndjson", format)
 		}
-		fmt.Printf("%s\n", pkgJSON)
+		outputOpts.SchemaV2 = true
+	default:
+		fatalf(exitUsage, "unknown schema %q, expected v1 or v2", schema)
+	}
+	if fields != "" {
+		if !fieldsFormats[format] {
+			fatalf(exitUsage, "-fields cannot be used with format %q, only with json, ndjson or ndjson-symbols", format)
+		}
+		if outputOpts.Fields, err = parseFields(fields, outputOpts.SchemaV2); err != nil {
+			fatalf(exitUsage, "%s", err)
+		}
+	}
+	if outputOpts.OmitEmpty && !fieldsFormats[format] {
+		fatalf(exitUsage, "-omitempty cannot be used with format %q, only with json, ndjson or ndjson-symbols", format)
+	}
+	if format == "text" {
+		// Declarations are printed in full, like go doc does.
+		opts.Source = true
+	}
+	writePackage, err := getFormatter(format, outputOpts)
+	if err == nil && filterExpr != "" {
+		writePackage, err = filterFormatter(filterExpr, format, outputOpts)
+	}
+	if err != nil {
+		flag.Usage()
+		fatalf(exitUsage, "%s", err)
+	}
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		fatalf(exitUsage, "Please specify a target_directory.")
+	}
+	if fetch && stdlib {
+		fatalf(exitUsage, "-stdlib cannot be used with fetch")
+	}
+	args := flag.Args()
+	var archives map[string]*archiveDir
+	if fetch {
+		args, err = fetchModules(args)
+	} else if !stdlib {
+		args, archives, err = expandArchives(args, walkRules)
+	}
+	if err != nil {
+		log.Fatalf("Fatal: %s", err)
+	}
+	if watch && len(archives) > 0 {
+		fatalf(exitUsage, "-watch cannot be used with archives")
+	}
+	var directories []string
+	if stdlib {
+		directories, err = StdlibDirectories(args, opts.ImportContext().GOROOT, walkRules)
+	} else {
+		directories, err = ExpandDirectories(args, walkRules)
+	}
+	if err != nil {
+		log.Fatalf("Fatal: %s", err)
+	}
+	if len(directories) == 0 {
+		fatalf(exitEmpty, "no Go files in %s", strings.Join(args, " "))
+	}
+	var dependencies map[string]bool
+	if deps != 0 {
+		dependencies = map[string]bool{}
+		for _, directory := range AddDependencies(directories, deps, opts) {
+			directories = append(directories, directory)
+			dependencies[directory] = true
+		}
+	}
+	out := &outputTarget{
+		Path:   output,
+		Dir:    output != "" && (len(directories) > 1 || isOutputDir(output) || outputTemplate != ""),
+		Format: format,
+		Write:  writePackage,
+	}
+	if out.Dir && !stdlib {
+		if out.Workspace, err = FindWorkspace(args); err != nil {
+			log.Fatalf("Fatal: %s", err)
+		}
+	}
+	if outputTemplate != "" {
+		if output == "" {
+			fatalf(exitUsage, "-o-template requires an -o directory")
+		}
+		if out.Template, err = template.New("o-template").Option("missingkey=error").Parse(outputTemplate); err != nil {
+			fatalf(exitUsage, "%s", err)
+		}
+	}
+	if out.Dir {
+		if err := os.MkdirAll(output, 0755); err != nil {
+			log.Fatalf("Fatal: %s", err)
+		}
+	}
+
+	document := func() error {
+		var collisions *collisionIndex
+		if collisionsFile != "" {
+			collisions = newCollisionIndex()
+		}
+		out.reset()
+		if implements {
+			opts.Implementations = extract.BuildImplementations(directories, opts)
+		}
+		if err := documentDirectories(directories, archives, opts, out, jobs, collisions, dependencies); err != nil {
+			return err
+		}
+		if out.Dir && outputIndex != "" {
+			if err := out.writeIndex(outputIndex, outputOpts); err != nil {
+				return err
+			}
+		}
+		if collisions == nil {
+			return nil
+		}
+		return extract.WriteFileAtomic(collisionsFile, func(w io.Writer) error {
+			return writeCollisions(w, collisions)
+		})
+	}
+	if watch {
+		redocument := func() {
+			if err := document(); err != nil {
+				log.Printf("Error: %s", err)
+			}
+		}
+		redocument()
+		if err := watchDirectories(directories, redocument); err != nil {
+			log.Fatalf("Fatal: %s", err)
+		}
+		return
+	}
+	if err := document(); err != nil {
+		exitWithError(err)
+	}
+	if out.Packages() == 0 {
+		fatalf(exitEmpty, "no package documented in %s", strings.Join(args, " "))
+	}
+	if n := warnings.Load(); strict && n > 0 {
+		fatalf(exitStrict, "%d warning(s) reported in strict mode", n)
+	}
+	if n := skipped.Load(); n > 0 {
+		exitPartially(n)
 	}
 }
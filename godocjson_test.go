@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// extractSource documents the package of src, written to p.go in a new
+// directory.
+func extractSource(t *testing.T, src string) *extract.Package {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := extract.Extract(dir, extract.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
+
+// TestMain runs the godocjson command with the command line arguments
+// instead of the tests when GODOCJSON_RUN_MAIN is set, see runMain.
+func TestMain(m *testing.M) {
+	if os.Getenv("GODOCJSON_RUN_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the godocjson command with args in a new process, and
+// returns its exit status and what it wrote to stderr.
+func runMain(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "GODOCJSON_RUN_MAIN=1")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return cmd.ProcessState.ExitCode(), stderr.String()
+}
+
+// testModule holds the files of the module example.com/m: p, imported by
+// q, whose tests import r, which imports q, and the command cmd/tool.
+var testModule = map[string]string{
+	"go.mod": "module example.com/m\n",
+	"p/p.go": `// Package p adds numbers.
+package p
+
+// Max is the largest operand.
+const Max = 10
+
+// Add returns the sum of a and b.
+func Add(a, b int) int { return a + b }
+
+// T holds a number.
+type T struct{ N int }
+
+// Double returns twice N.
+func (t T) Double() int { return 2 * t.N }
+`,
+	"q/q.go":      "// Package q uses {p}.\npackage q\n\nimport \"example.com/m/p\"\n\n// Sum returns p.Add(a, b).\nfunc Sum(a, b int) int { return p.Add(a, b) }\n",
+	"q/q_test.go": "package q\n\nimport _ \"example.com/m/r\"\n",
+	"r/r.go":      "// Package r wraps q.\npackage r\n\nimport _ \"example.com/m/q\"\n",
+	"cmd/tool/main.go": `// Tool prints numbers.
+package main
+
+import "flag"
+
+var (
+	verbose = flag.Bool("v", false, "Print more details")
+	count   = flag.Int("n", 3, "Number of lines")
+)
+
+func main() { flag.Parse() }
+`,
+}
+
+// writeModule writes testModule to a new directory, returned.
+func writeModule(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range testModule {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// silenceStderr discards what is written to os.Stderr until the end of the
+// test, such as the usage of subcommands.
+func silenceStderr(t *testing.T) {
+	f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = f
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		f.Close()
+	})
+}
+
+// captureStdout returns what run writes to os.Stdout, and its result.
+func captureStdout(t *testing.T, run func() int) (string, int) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- b
+	}()
+	status := run()
+	os.Stdout = stdout
+	w.Close()
+	b := <-out
+	r.Close()
+	return string(b), status
+}
+
+func TestExcludePatternsSet(t *testing.T) {
+	tests := []struct {
+		values []string // values of repeated -e flags
+		want   []string
+	}{
+		{[]string{"_test.go$"}, []string{"_test.go$"}},
+		{[]string{"a,b", "c"}, []string{"a", "b", "c"}},
+		{[]string{"[,;]x,y"}, []string{"[,;]x", "y"}},
+		{[]string{"x{1,3},y{2,}"}, []string{"x{1,3}", "y{2,}"}},
+		{[]string{`a\,b,c`}, []string{`a\,b`, "c"}},
+		{[]string{`[\],]z,w`}, []string{`[\],]z`, "w"}},
+		{[]string{"a,,b"}, []string{"a", "", "b"}},
+		{[]string{"a]b,c"}, []string{"a]b", "c"}},
+	}
+	for _, test := range tests {
+		var p excludePatterns
+		for _, value := range test.values {
+			if err := p.Set(value); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !reflect.DeepEqual([]string(p), test.want) {
+			t.Errorf("Set(%q) gives %q, want %q", test.values, p, test.want)
+		}
+	}
+}
+
+func TestUsageListsSubcommands(t *testing.T) {
+	var b bytes.Buffer
+	defer func(w io.Writer, flags int) {
+		log.SetOutput(w)
+		log.SetFlags(flags)
+	}(log.Writer(), log.Flags())
+	log.SetOutput(&b)
+	log.SetFlags(0)
+	flag.CommandLine.SetOutput(io.Discard)
+	defer flag.CommandLine.SetOutput(nil)
+	GetUsageText()
+	listed := map[string]bool{}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if fields := strings.Fields(line); len(fields) > 1 && fields[0] == "godocjson" {
+			listed[fields[1]] = true
+		}
+	}
+	for name := range subcommands {
+		if !listed[name] {
+			t.Errorf("usage does not list the %s subcommand", name)
+		}
+	}
+}
+
+// TestDocumentDirectoriesCountsSkipped checks that the files skipped with
+// -keep-going and the directories without Go files are counted, for the
+// exit status exitPartial.
+func TestDocumentDirectoriesCountsSkipped(t *testing.T) {
+	defer func(w io.Writer) { log.SetOutput(w) }(log.Writer())
+	log.SetOutput(io.Discard)
+	defer skipped.Store(skipped.Load())
+	skipped.Store(0)
+
+	dir := writeTestPackage(t)
+	if err := os.WriteFile(filepath.Join(dir, "bad.go"), []byte("package p\n\nfunc {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := t.TempDir()
+	out := &outputTarget{Path: t.TempDir(), Dir: true, Format: "json", Write: jsonFormatter(&outputOptions{})}
+	if err := documentDirectories([]string{dir, empty}, nil, extract.Options{KeepGoing: true}, out, 1, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := skipped.Load(); n != 2 {
+		t.Errorf("got %d skipped, want 2", n)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	dir := writeTestPackage(t)
+	bad := writeTestPackage(t)
+	if err := os.WriteFile(filepath.Join(bad, "bad.go"), []byte("package p\n\nfunc {\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := t.TempDir()
+	for _, test := range []struct {
+		name   string
+		args   []string
+		status int
+		stderr string // expected in stderr
+	}{
+		{"success", []string{"-o", filepath.Join(output, "p.json"), "-tests=false", dir}, 0, ""},
+		{"failure", []string{"-o", filepath.Join(output, "missing", "p.json"), "-tests=false", dir}, exitFailure, "Fatal: "},
+		{"usage", []string{"-platforms", "linux/amd64", "-goos", "linux", dir}, exitUsage, "Fatal: -platforms cannot be combined"},
+		{"parse error", []string{"-o", filepath.Join(output, "bad.json"), bad}, exitParseError, `"message"`},
+		{"empty", []string{t.TempDir()}, exitEmpty, "Fatal: no package documented"},
+		{"partial", []string{"-o", output, "-keep-going", bad}, exitPartial, "Warning: 1 file(s) or package(s) skipped; the output is written"},
+		{"strict", []string{"-o", output, "-keep-going", "-strict", bad}, exitStrict, "Fatal: 1 warning(s) reported in strict mode"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			status, stderr := runMain(t, test.args...)
+			if status != test.status || !strings.Contains(stderr, test.stderr) {
+				t.Errorf("got exit status %d, want %d, with stderr:\n%s", status, test.status, stderr)
+			}
+			if status == exitPartial && strings.Contains(stderr, "Fatal:") {
+				t.Errorf("partial success reported as fatal:\n%s", stderr)
+			}
+		})
+	}
+}
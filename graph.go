@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// ImportGraph holds the imports of a set of documented packages.
+type ImportGraph struct {
+	Packages []string      `json:"packages"` // import paths of the documented packages
+	Edges    []*ImportEdge `json:"edges"`
+}
+
+// ImportEdge is an import of package To by package From.
+type ImportEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Test is set if only the _test.go files of the package import To.
+	// Imports of external test packages (package x_test) are not edges
+	// of the graph, since they cannot create cycles.
+	Test bool `json:"test,omitempty"`
+}
+
+// BuildImportGraph returns the import graph of the packages in directories,
+// parsing only the import declarations of the files selected by filter.
+func BuildImportGraph(directories []string, filter func(os.FileInfo) bool) (*ImportGraph, error) {
+	graph := &ImportGraph{Packages: []string{}, Edges: []*ImportEdge{}}
+	for _, directory := range directories {
+		fileSet := token.NewFileSet()
+		pkgs, err := parser.ParseDir(fileSet, directory, filter, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+		if len(pkgs) == 0 {
+			continue
+		}
+		from := extract.ImportPathOf(directory)
+		graph.Packages = append(graph.Packages, from)
+
+		test := map[string]bool{} // import path -> imported by test files only
+		for name, pkg := range pkgs {
+			if strings.HasSuffix(name, "_test") && len(pkgs) > 1 {
+				continue
+			}
+			for filename, file := range pkg.Files {
+				isTest := strings.HasSuffix(filename, "_test.go")
+				for _, spec := range file.Imports {
+					to, err := strconv.Unquote(spec.Path.Value)
+					if err != nil {
+						continue
+					}
+					if onlyTest, ok := test[to]; !ok || onlyTest {
+						test[to] = isTest
+					}
+				}
+			}
+		}
+		for to, isTest := range test {
+			graph.Edges = append(graph.Edges, &ImportEdge{From: from, To: to, Test: isTest})
+		}
+	}
+	sort.Strings(graph.Packages)
+	sort.Slice(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		return a.From < b.From || (a.From == b.From && a.To < b.To)
+	})
+	return graph, nil
+}
+
+// ImportCycle is a set of packages importing each other.
+type ImportCycle struct {
+	Packages []string `json:"packages"`
+	// Test is set if the cycle only exists through imports of _test.go
+	// files, which the go tool rejects when testing.
+	Test bool `json:"test"`
+}
+
+// Cycles returns the import cycles between the documented packages, as the
+// strongly connected components of the graph with more than one package.
+func (g *ImportGraph) Cycles() []*ImportCycle {
+	edges := map[string][]*ImportEdge{}
+	for _, e := range g.Edges {
+		edges[e.From] = append(edges[e.From], e)
+	}
+
+	// Tarjan's strongly connected components algorithm.
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var cycles []*ImportCycle
+	var visit func(p string)
+	visit = func(p string) {
+		index[p] = len(index)
+		lowlink[p] = index[p]
+		stack = append(stack, p)
+		onStack[p] = true
+		for _, e := range edges[p] {
+			if _, ok := index[e.To]; !ok {
+				visit(e.To)
+				if lowlink[e.To] < lowlink[p] {
+					lowlink[p] = lowlink[e.To]
+				}
+			} else if onStack[e.To] && index[e.To] < lowlink[p] {
+				lowlink[p] = index[e.To]
+			}
+		}
+		if lowlink[p] != index[p] {
+			return
+		}
+		var component []string
+		for {
+			q := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[q] = false
+			component = append(component, q)
+			if q == p {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, &ImportCycle{Packages: component, Test: g.cycleNeedsTests(component)})
+		}
+	}
+	for _, p := range g.Packages {
+		if _, ok := index[p]; !ok {
+			visit(p)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Packages[0] < cycles[j].Packages[0] })
+	return cycles
+}
+
+// cycleNeedsTests reports whether the packages of component stop forming a
+// cycle when test imports are ignored.
+func (g *ImportGraph) cycleNeedsTests(component []string) bool {
+	nonTest := &ImportGraph{Packages: component}
+	in := map[string]bool{}
+	for _, p := range component {
+		in[p] = true
+	}
+	for _, e := range g.Edges {
+		if !e.Test && in[e.From] && in[e.To] {
+			nonTest.Edges = append(nonTest.Edges, e)
+		}
+	}
+	return len(nonTest.Cycles()) == 0
+}
+
+// Internal returns the graph restricted to the imports between its
+// packages.
+func (g *ImportGraph) Internal() *ImportGraph {
+	in := map[string]bool{}
+	for _, p := range g.Packages {
+		in[p] = true
+	}
+	internal := &ImportGraph{Packages: g.Packages, Edges: []*ImportEdge{}}
+	for _, e := range g.Edges {
+		if in[e.To] {
+			internal.Edges = append(internal.Edges, e)
+		}
+	}
+	return internal
+}
+
+// writeDot writes the graph in the DOT language of Graphviz. Test imports
+// are drawn as dashed edges.
+func (g *ImportGraph) writeDot(w io.Writer) {
+	fmt.Fprintf(w, "digraph imports {\n")
+	for _, p := range g.Packages {
+		fmt.Fprintf(w, "\t%q;\n", p)
+	}
+	for _, e := range g.Edges {
+		style := ""
+		if e.Test {
+			style = " [style=dashed]"
+		}
+		fmt.Fprintf(w, "\t%q -> %q%s;\n", e.From, e.To, style)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// runGraph implements the graph subcommand.
+func runGraph(args []string) int {
+	flags := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := flags.String("format", "json", "Graph format: json or dot")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	internal := flags.Bool("internal", false, "Only list the imports between the given packages")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	graph, err := BuildImportGraph(directories, fileFilter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *internal {
+		graph = graph.Internal()
+	}
+
+	switch *format {
+	case "json":
+		graphJSON, _ := json.MarshalIndent(graph, "", "  ")
+		fmt.Printf("%s\n", graphJSON)
+	case "dot":
+		graph.writeDot(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown graph format %q\n", *format)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// moduleGraph returns the import graph of the packages of writeModule.
+func moduleGraph(t *testing.T) *ImportGraph {
+	t.Helper()
+	dir := writeModule(t)
+	var dirs []string
+	for _, name := range []string{"p", "q", "r"} {
+		dirs = append(dirs, filepath.Join(dir, name))
+	}
+	graph, err := BuildImportGraph(dirs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graph
+}
+
+func TestBuildImportGraph(t *testing.T) {
+	graph := moduleGraph(t)
+	if got, want := strings.Join(graph.Packages, " "), "example.com/m/p example.com/m/q example.com/m/r"; got != want {
+		t.Errorf("got packages %q, want %q", got, want)
+	}
+	var edges []string
+	for _, e := range graph.Edges {
+		edges = append(edges, fmt.Sprintf("%s -> %s %t", e.From, e.To, e.Test))
+	}
+	want := []string{
+		"example.com/m/q -> example.com/m/p false",
+		"example.com/m/q -> example.com/m/r true",
+		"example.com/m/r -> example.com/m/q false",
+	}
+	if strings.Join(edges, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got edges\n%s\nwant\n%s", strings.Join(edges, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestImportGraphCycles(t *testing.T) {
+	graph := moduleGraph(t)
+	cycles := graph.Cycles()
+	if len(cycles) != 1 {
+		t.Fatalf("got %d cycles, want 1", len(cycles))
+	}
+	if got := strings.Join(cycles[0].Packages, " "); got != "example.com/m/q example.com/m/r" || !cycles[0].Test {
+		t.Errorf("got cycle %q (test %t), want q and r through test files", got, cycles[0].Test)
+	}
+}
+
+func TestImportGraphWriteDot(t *testing.T) {
+	var b strings.Builder
+	moduleGraph(t).writeDot(&b)
+	want := `digraph imports {
+	"example.com/m/p";
+	"example.com/m/q";
+	"example.com/m/r";
+	"example.com/m/q" -> "example.com/m/p";
+	"example.com/m/q" -> "example.com/m/r" [style=dashed];
+	"example.com/m/r" -> "example.com/m/q";
+}
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestImportGraphInternal(t *testing.T) {
+	graph := &ImportGraph{
+		Packages: []string{"example.com/m/p", "example.com/m/q"},
+		Edges: []*ImportEdge{
+			{From: "example.com/m/p", To: "fmt"},
+			{From: "example.com/m/q", To: "example.com/m/p"},
+		},
+	}
+	internal := graph.Internal()
+	if len(internal.Edges) != 1 || internal.Edges[0].To != "example.com/m/p" {
+		t.Errorf("got edges %+v, want only q -> p", internal.Edges)
+	}
+}
+
+func TestRunGraph(t *testing.T) {
+	dir := writeModule(t)
+	out, status := captureStdout(t, func() int {
+		return runGraph([]string{"-internal", "-format", "dot", filepath.Join(dir, "q"), filepath.Join(dir, "r")})
+	})
+	if status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	// The import of p by q is left out, p not being given.
+	if strings.Contains(out, `"example.com/m/p"`) || !strings.Contains(out, `"example.com/m/r" -> "example.com/m/q";`) {
+		t.Errorf("got\n%s", out)
+	}
+
+	silenceStderr(t)
+	if _, status := captureStdout(t, func() int { return runGraph([]string{"-format", "svg", dir}) }); status != 2 {
+		t.Errorf("got exit status %d for an unknown format, want 2", status)
+	}
+}
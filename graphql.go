@@ -0,0 +1,884 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// The GraphQL endpoint of the serve subcommand supports queries made of
+// fields, aliases, arguments, variables and fragments, executed against the
+// Package documents. The object types of the schema are derived from the Go
+// types of the documents, with the fields named like their JSON keys; maps
+// are values of the JSON scalar type. Mutations, subscriptions, directives
+// and introspection are not supported: graphQLSchema describes the schema
+// instead.
+
+// gqlToken is a lexical token of a GraphQL document.
+type gqlToken struct {
+	kind byte // 'p' for punctuators, 'n' for names, 's' for strings, 'i' and 'f' for numbers
+	text string
+}
+
+// gqlLex splits src into tokens, skipping whitespace, commas and comments.
+func gqlLex(src string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, gqlToken{'p', "..."})
+			i += 3
+		case strings.IndexByte("!$&()=:@[]{}|", c) >= 0:
+			tokens = append(tokens, gqlToken{'p', string(c)})
+			i++
+		case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || 'a' <= src[j] && src[j] <= 'z' || 'A' <= src[j] && src[j] <= 'Z' || '0' <= src[j] && src[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, gqlToken{'n', src[i:j]})
+			i = j
+		case c == '-' || '0' <= c && c <= '9':
+			j, kind := i+1, byte('i')
+			for j < len(src) && strings.IndexByte("0123456789.eE+-", src[j]) >= 0 {
+				if strings.IndexByte(".eE", src[j]) >= 0 {
+					kind = 'f'
+				}
+				j++
+			}
+			tokens = append(tokens, gqlToken{kind, src[i:j]})
+			i = j
+		case c == '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				end := strings.Index(src[i+3:], `"""`)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated block string")
+				}
+				tokens = append(tokens, gqlToken{'s', src[i+3 : i+3+end]})
+				i += end + 6
+				continue
+			}
+			j := i + 1
+			for j < len(src) && src[j] != '"' && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) || src[j] != '"' {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			// GraphQL string escapes are those of JSON.
+			var s string
+			if err := json.Unmarshal([]byte(src[i:j+1]), &s); err != nil {
+				return nil, fmt.Errorf("invalid string %s", src[i:j+1])
+			}
+			tokens = append(tokens, gqlToken{'s', s})
+			i = j + 1
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, fmt.Errorf("unexpected character %q", r)
+		}
+	}
+	return tokens, nil
+}
+
+// gqlSelection is a field, or an inline fragment if Name is empty.
+type gqlSelection struct {
+	Alias, Name string
+	Args        map[string]interface{} // literal values, with gqlVariable references
+	On          string                 // type condition of inline fragments
+	Selections  []*gqlSelection
+}
+
+// responseName returns the key of the field in the response.
+func (sel *gqlSelection) responseName() string {
+	if sel.Alias != "" {
+		return sel.Alias
+	}
+	return sel.Name
+}
+
+// gqlVariable is a reference to a variable in an argument value.
+type gqlVariable string
+
+// gqlOperation is a query of a GraphQL document.
+type gqlOperation struct {
+	Name       string
+	Variables  []*gqlVariableDef
+	Selections []*gqlSelection
+}
+
+type gqlVariableDef struct {
+	Name     string
+	NonNull  bool
+	Default  interface{}
+	Defaults bool // whether Default is set
+}
+
+// gqlMaxDepth is the maximum nesting depth of the selection sets of a
+// query, fragments included, and of list and object values. Deeper
+// documents are rejected rather than parsed and executed recursively.
+const gqlMaxDepth = 32
+
+// gqlParser parses a GraphQL document.
+type gqlParser struct {
+	tokens    []gqlToken
+	pos       int
+	depth     int                      // nesting depth of the selection set or value being parsed
+	fragments map[string]*gqlSelection // named fragments, as inline fragments
+	spreads   map[*gqlSelection]string // fragment spreads, resolved once all fragments are parsed
+}
+
+// parseGraphQL parses the executable definitions of a document.
+func parseGraphQL(src string) ([]*gqlOperation, error) {
+	tokens, err := gqlLex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{tokens: tokens, fragments: map[string]*gqlSelection{}, spreads: map[*gqlSelection]string{}}
+	var ops []*gqlOperation
+	for p.pos < len(p.tokens) {
+		switch t := p.peek(); {
+		case t.kind == 'p' && t.text == "{":
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, &gqlOperation{Selections: sels})
+		case t.kind == 'n' && t.text == "query":
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, op)
+		case t.kind == 'n' && t.text == "fragment":
+			if err := p.fragment(); err != nil {
+				return nil, err
+			}
+		case t.kind == 'n' && (t.text == "mutation" || t.text == "subscription"):
+			return nil, fmt.Errorf("%s operations are not supported", t.text)
+		default:
+			return nil, fmt.Errorf("unexpected %q", t.text)
+		}
+	}
+	for spread, name := range p.spreads {
+		f, ok := p.fragments[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown fragment %q", name)
+		}
+		spread.On, spread.Selections = f.On, f.Selections
+	}
+	done := map[*gqlSelection]bool{}
+	for name := range p.fragments {
+		if p.cyclic(p.fragments[name], map[*gqlSelection]bool{}, done) {
+			return nil, fmt.Errorf("fragment %q spreads itself", name)
+		}
+	}
+	depths := map[*gqlSelection]int{}
+	for _, op := range ops {
+		if selectionDepth(op.Selections, depths) > gqlMaxDepth {
+			return nil, fmt.Errorf("selections nested deeper than %d levels", gqlMaxDepth)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("no operation in document")
+	}
+	return ops, nil
+}
+
+// cyclic reports whether the selections of sel include a fragment being
+// expanded. Fragments found not to be cyclic are added to done, so that
+// each is only walked once.
+func (p *gqlParser) cyclic(sel *gqlSelection, expanding, done map[*gqlSelection]bool) bool {
+	if expanding[sel] {
+		return true
+	}
+	if done[sel] {
+		return false
+	}
+	expanding[sel] = true
+	defer delete(expanding, sel)
+	for _, s := range sel.Selections {
+		if name, ok := p.spreads[s]; ok {
+			// The selections of s are those of the fragment.
+			if p.cyclic(p.fragments[name], expanding, done) {
+				return true
+			}
+		} else if p.cyclic(s, expanding, done) {
+			return true
+		}
+	}
+	done[sel] = true
+	return false
+}
+
+// selectionDepth returns the nesting depth of sels once fragments are
+// expanded, counting fragments as levels like the parser does. The depths
+// of selection sets, shared by the spreads of a fragment, are memoized by
+// their first selection in depths, so that each is only walked once.
+func selectionDepth(sels []*gqlSelection, depths map[*gqlSelection]int) int {
+	if len(sels) == 0 {
+		return 0
+	}
+	if depth, ok := depths[sels[0]]; ok {
+		return depth
+	}
+	depth := 0
+	for _, sel := range sels {
+		depth = max(depth, selectionDepth(sel.Selections, depths))
+	}
+	depths[sels[0]] = depth + 1
+	return depth + 1
+}
+
+// enter increments the nesting depth, failing past gqlMaxDepth; leave
+// decrements it.
+func (p *gqlParser) enter() error {
+	if p.depth++; p.depth > gqlMaxDepth {
+		return fmt.Errorf("document nested deeper than %d levels", gqlMaxDepth)
+	}
+	return nil
+}
+
+func (p *gqlParser) leave() {
+	p.depth--
+}
+
+func (p *gqlParser) peek() gqlToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return gqlToken{}
+}
+
+func (p *gqlParser) next() gqlToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// accept consumes the punctuator text if it is next.
+func (p *gqlParser) accept(text string) bool {
+	if t := p.peek(); t.kind == 'p' && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *gqlParser) expect(text string) error {
+	if !p.accept(text) {
+		if p.pos >= len(p.tokens) {
+			return fmt.Errorf("expected %q, found end of document", text)
+		}
+		return fmt.Errorf("expected %q, found %q", text, p.peek().text)
+	}
+	return nil
+}
+
+func (p *gqlParser) name() (string, error) {
+	t := p.next()
+	if t.kind != 'n' {
+		return "", fmt.Errorf("expected a name, found %q", t.text)
+	}
+	return t.text, nil
+}
+
+func (p *gqlParser) operation() (*gqlOperation, error) {
+	p.next() // query
+	op := &gqlOperation{}
+	if p.peek().kind == 'n' {
+		op.Name = p.next().text
+	}
+	if p.accept("(") {
+		for !p.accept(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			def := &gqlVariableDef{}
+			var err error
+			if def.Name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if def.NonNull, err = p.typeRef(); err != nil {
+				return nil, err
+			}
+			if p.accept("=") {
+				if def.Default, err = p.value(true); err != nil {
+					return nil, err
+				}
+				def.Defaults = true
+			}
+			op.Variables = append(op.Variables, def)
+		}
+	}
+	if p.peek().text == "@" {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+	var err error
+	op.Selections, err = p.selectionSet()
+	return op, err
+}
+
+// typeRef skips a type reference and reports whether it is non-null.
+func (p *gqlParser) typeRef() (bool, error) {
+	if p.accept("[") {
+		if err := p.enter(); err != nil {
+			return false, err
+		}
+		defer p.leave()
+		if _, err := p.typeRef(); err != nil {
+			return false, err
+		}
+		if err := p.expect("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.name(); err != nil {
+		return false, err
+	}
+	return p.accept("!"), nil
+}
+
+func (p *gqlParser) fragment() error {
+	p.next() // fragment
+	name, err := p.name()
+	if err != nil {
+		return err
+	}
+	if on := p.next(); on.kind != 'n' || on.text != "on" {
+		return fmt.Errorf("expected \"on\" after fragment %s", name)
+	}
+	f := &gqlSelection{}
+	if f.On, err = p.name(); err != nil {
+		return err
+	}
+	if f.Selections, err = p.selectionSet(); err != nil {
+		return err
+	}
+	if _, ok := p.fragments[name]; ok {
+		return fmt.Errorf("fragment %q is defined twice", name)
+	}
+	p.fragments[name] = f
+	return nil
+}
+
+func (p *gqlParser) selectionSet() ([]*gqlSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	var sels []*gqlSelection
+	for !p.accept("}") {
+		if p.pos >= len(p.tokens) {
+			return nil, fmt.Errorf("expected \"}\", found end of document")
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return sels, nil
+}
+
+func (p *gqlParser) selection() (*gqlSelection, error) {
+	sel := &gqlSelection{}
+	var err error
+	if p.accept("...") {
+		if t := p.peek(); t.kind == 'n' && t.text != "on" {
+			p.spreads[sel] = p.next().text
+		} else {
+			if p.peek().text == "on" {
+				p.next()
+				if sel.On, err = p.name(); err != nil {
+					return nil, err
+				}
+			}
+			if sel.Selections, err = p.selectionSet(); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek().text == "@" {
+			return nil, fmt.Errorf("directives are not supported")
+		}
+		return sel, nil
+	}
+	if sel.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if p.accept(":") {
+		sel.Alias = sel.Name
+		if sel.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("(") {
+		sel.Args = map[string]interface{}{}
+		for !p.accept(")") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if sel.Args[name], err = p.value(false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.peek().text == "@" {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+	if p.peek().kind == 'p' && p.peek().text == "{" {
+		if sel.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+// value parses an input value. Variables are not allowed in constant
+// values, such as default values.
+func (p *gqlParser) value(constant bool) (interface{}, error) {
+	t := p.next()
+	if t.kind == 'p' && (t.text == "[" || t.text == "{") {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+	}
+	switch t.kind {
+	case 's':
+		return t.text, nil
+	case 'i':
+		return strconv.ParseInt(t.text, 10, 64)
+	case 'f':
+		return strconv.ParseFloat(t.text, 64)
+	case 'n':
+		switch t.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return t.text, nil // enum value
+	}
+	switch t.text {
+	case "$":
+		if constant {
+			return nil, fmt.Errorf("unexpected variable in constant value")
+		}
+		name, err := p.name()
+		return gqlVariable(name), err
+	case "[":
+		list := []interface{}{}
+		for !p.accept("]") {
+			if p.pos >= len(p.tokens) {
+				return nil, fmt.Errorf("expected \"]\", found end of document")
+			}
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case "{":
+		object := map[string]interface{}{}
+		for !p.accept("}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unexpected %q in value", t.text)
+}
+
+// gqlObject is an object of a GraphQL response, keeping the order of its
+// fields.
+type gqlObject []gqlField
+
+type gqlField struct {
+	Name  string
+	Value interface{}
+}
+
+func (o gqlObject) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(f.Name)
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+func gqlLookupField(t reflect.Type, name string) *jsonStructField {
+	for _, f := range jsonFields(t) {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// gqlCollect merges the fields selected on an object of type typeName by
+// sels, expanding fragments, in the order of their first selection. Fields
+// selected several times under the same response name have their
+// selections merged.
+func gqlCollect(typeName string, sels []*gqlSelection) ([]*gqlSelection, error) {
+	var fields []*gqlSelection
+	byName := map[string]*gqlSelection{}
+	var collect func(sels []*gqlSelection) error
+	collect = func(sels []*gqlSelection) error {
+		for _, sel := range sels {
+			if sel.Name == "" {
+				if sel.On != "" && sel.On != typeName {
+					return fmt.Errorf("fragment on %q cannot be spread on type %q", sel.On, typeName)
+				}
+				if err := collect(sel.Selections); err != nil {
+					return err
+				}
+				continue
+			}
+			key := sel.responseName()
+			if prev, ok := byName[key]; ok {
+				if prev.Name != sel.Name {
+					return fmt.Errorf("fields %q and %q conflict as %q", prev.Name, sel.Name, key)
+				}
+				prev.Selections = append(prev.Selections, sel.Selections...)
+				continue
+			}
+			merged := *sel
+			merged.Selections = append([]*gqlSelection(nil), sel.Selections...)
+			byName[key] = &merged
+			fields = append(fields, &merged)
+		}
+		return nil
+	}
+	err := collect(sels)
+	return fields, err
+}
+
+// gqlResolve returns the response value of v for the selections sels.
+func gqlResolve(v reflect.Value, sels []*gqlSelection) (interface{}, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if len(sels) == 0 {
+			return nil, fmt.Errorf("field of type %q must have a selection of subfields", v.Type().Name())
+		}
+		fields, err := gqlCollect(v.Type().Name(), sels)
+		if err != nil {
+			return nil, err
+		}
+		object := gqlObject{}
+		for _, sel := range fields {
+			if len(sel.Args) > 0 {
+				return nil, fmt.Errorf("field %q takes no arguments", sel.Name)
+			}
+			if sel.Name == "__typename" {
+				object = append(object, gqlField{sel.responseName(), v.Type().Name()})
+				continue
+			}
+			f := gqlLookupField(v.Type(), sel.Name)
+			if f == nil {
+				return nil, fmt.Errorf("cannot query field %q on type %q", sel.Name, v.Type().Name())
+			}
+			value, err := gqlResolve(v.FieldByIndex(f.index), sel.Selections)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, gqlField{sel.responseName(), value})
+		}
+		return object, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			var err error
+			if list[i], err = gqlResolve(v.Index(i), sels); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	if len(sels) > 0 {
+		return nil, fmt.Errorf("field of type %q has no subfields", gqlTypeName(v.Type()))
+	}
+	return v.Interface(), nil
+}
+
+// gqlTypeRef returns the GraphQL type of values of the Go type t, and
+// calls object for the struct types it references.
+func gqlTypeRef(t reflect.Type, object func(reflect.Type)) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return strings.TrimSuffix(gqlTypeRef(t.Elem(), object), "!")
+	case reflect.Slice, reflect.Array:
+		return "[" + gqlTypeRef(t.Elem(), object) + "]"
+	case reflect.Struct:
+		object(t)
+		return t.Name() + "!"
+	case reflect.Map, reflect.Interface:
+		return "JSON"
+	}
+	return gqlTypeName(t) + "!"
+}
+
+// gqlTypeName returns the GraphQL name of the type of the Go type t.
+func gqlTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "String"
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.Struct:
+		return t.Name()
+	}
+	return "JSON"
+}
+
+// graphQLSchema returns the schema of the GraphQL endpoint in the GraphQL
+// schema definition language.
+func graphQLSchema() string {
+	var b strings.Builder
+	b.WriteString(`"""Any JSON value, for the maps of the documents."""` + "\n")
+	b.WriteString("scalar JSON\n\n")
+	b.WriteString("type Query {\n")
+	b.WriteString(`  """The package with the given import path, or null."""` + "\n")
+	b.WriteString("  package(importPath: String!): Package\n")
+	b.WriteString(`  """The packages with the given import paths, null for those not found."""` + "\n")
+	b.WriteString("  packages(importPaths: [String!]!): [Package]\n")
+	b.WriteString("}\n")
+	seen := map[reflect.Type]bool{}
+	queue := []reflect.Type{reflect.TypeOf(extract.Package{})}
+	object := func(t reflect.Type) {
+		if !seen[t] {
+			seen[t] = true
+			queue = append(queue, t)
+		}
+	}
+	seen[queue[0]] = true
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		fmt.Fprintf(&b, "\ntype %s {\n", t.Name())
+		for _, f := range jsonFields(t) {
+			fmt.Fprintf(&b, "  %s: %s\n", f.name, gqlTypeRef(f.typ, object))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// gqlRequest is a GraphQL request, as posted in JSON.
+type gqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// gqlResponse is the result of a GraphQL request.
+type gqlResponse struct {
+	Data   interface{} `json:"data"`
+	Errors []*gqlError `json:"errors,omitempty"`
+}
+
+type gqlError struct {
+	Message string `json:"message"`
+}
+
+// executeGraphQL executes the query of req, resolving the packages of the
+// root fields with lookup, which returns nil for unknown import paths.
+func executeGraphQL(req *gqlRequest, lookup func(importPath string) (*extract.Package, error)) *gqlResponse {
+	data, err := executeGraphQLQuery(req, lookup)
+	if err != nil {
+		return &gqlResponse{Errors: []*gqlError{{Message: err.Error()}}}
+	}
+	return &gqlResponse{Data: data}
+}
+
+func executeGraphQLQuery(req *gqlRequest, lookup func(importPath string) (*extract.Package, error)) (interface{}, error) {
+	ops, err := parseGraphQL(req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("syntax error: %s", err)
+	}
+	var op *gqlOperation
+	for _, o := range ops {
+		if req.OperationName == "" && len(ops) == 1 || o.Name == req.OperationName {
+			op = o
+		}
+	}
+	if op == nil {
+		return nil, fmt.Errorf("unknown operation %q", req.OperationName)
+	}
+	vars := map[string]interface{}{}
+	for _, def := range op.Variables {
+		value, ok := req.Variables[def.Name]
+		if !ok && def.Defaults {
+			value, ok = def.Default, true
+		}
+		if (!ok || value == nil) && def.NonNull {
+			return nil, fmt.Errorf("variable $%s is required", def.Name)
+		}
+		vars[def.Name] = value
+	}
+
+	fields, err := gqlCollect("Query", op.Selections)
+	if err != nil {
+		return nil, err
+	}
+	data := gqlObject{}
+	for _, sel := range fields {
+		args, err := gqlArgs(sel.Args, vars)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		switch sel.Name {
+		case "__typename":
+			value = "Query"
+		case "package":
+			importPath, ok := args["importPath"].(string)
+			if !ok || len(args) != 1 {
+				return nil, fmt.Errorf("field \"package\" takes an importPath String argument")
+			}
+			if value, err = gqlPackage(importPath, sel.Selections, lookup); err != nil {
+				return nil, err
+			}
+		case "packages":
+			importPaths, ok := args["importPaths"].([]interface{})
+			if !ok || len(args) != 1 {
+				return nil, fmt.Errorf("field \"packages\" takes an importPaths [String!] argument")
+			}
+			list := make([]interface{}, len(importPaths))
+			for i, p := range importPaths {
+				importPath, ok := p.(string)
+				if !ok {
+					return nil, fmt.Errorf("field \"packages\" takes an importPaths [String!] argument")
+				}
+				if list[i], err = gqlPackage(importPath, sel.Selections, lookup); err != nil {
+					return nil, err
+				}
+			}
+			value = list
+		default:
+			return nil, fmt.Errorf("cannot query field %q on type \"Query\"", sel.Name)
+		}
+		data = append(data, gqlField{sel.responseName(), value})
+	}
+	return data, nil
+}
+
+func gqlPackage(importPath string, sels []*gqlSelection, lookup func(importPath string) (*extract.Package, error)) (interface{}, error) {
+	pkg, err := lookup(importPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", importPath, err)
+	}
+	if pkg == nil {
+		if _, err := gqlCollect("Package", sels); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return gqlResolve(reflect.ValueOf(pkg), sels)
+}
+
+// gqlArgs returns the arguments args with their variables replaced by
+// their value in vars.
+func gqlArgs(args map[string]interface{}, vars map[string]interface{}) (map[string]interface{}, error) {
+	var substitute func(v interface{}) (interface{}, error)
+	substitute = func(v interface{}) (interface{}, error) {
+		switch v := v.(type) {
+		case gqlVariable:
+			value, ok := vars[string(v)]
+			if !ok {
+				return nil, fmt.Errorf("undefined variable $%s", v)
+			}
+			return value, nil
+		case []interface{}:
+			list := make([]interface{}, len(v))
+			for i, e := range v {
+				var err error
+				if list[i], err = substitute(e); err != nil {
+					return nil, err
+				}
+			}
+			return list, nil
+		case map[string]interface{}:
+			object := map[string]interface{}{}
+			for k, e := range v {
+				var err error
+				if object[k], err = substitute(e); err != nil {
+					return nil, err
+				}
+			}
+			return object, nil
+		}
+		return v, nil
+	}
+	resolved := map[string]interface{}{}
+	for name, v := range args {
+		var err error
+		if resolved[name], err = substitute(v); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// newTestServer returns a packageServer of a root directory holding the
+// package "p" with the given Go source.
+func newTestServer(t *testing.T, src string) *packageServer {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "p"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "p", "p.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &packageServer{root: root, opts: extract.Options{ExcludeTests: true}, cache: map[string]*servedPackage{}}
+}
+
+// postGraphQL posts query to the GraphQL endpoint of s.
+func postGraphQL(t *testing.T, s *packageServer, body string) (*httptest.ResponseRecorder, *gqlResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.serveGraphQL(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		return w, nil
+	}
+	var resp gqlResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %s: %s", w.Body, err)
+	}
+	return w, &resp
+}
+
+func gqlBody(query string) string {
+	body, _ := json.Marshal(&gqlRequest{Query: query})
+	return string(body)
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	s := newTestServer(t, "// Package p is documented.\npackage p\n\n// F does nothing.\nfunc F() {}\n")
+	w, resp := postGraphQL(t, s, gqlBody(`{ package(importPath: "p") { name funcs { name doc } } }`))
+	if resp == nil || len(resp.Errors) > 0 {
+		t.Fatalf("query failed: %s", w.Body)
+	}
+	want := `{"data":{"package":{"name":"p","funcs":[{"name":"F","doc":"F does nothing.\n"}]}}}` + "\n"
+	if w.Body.String() != want {
+		t.Errorf("got %s, want %s", w.Body, want)
+	}
+}
+
+func TestGraphQLOversizedRequest(t *testing.T) {
+	s := newTestServer(t, "package p\n")
+	query := "{ package(importPath: \"p\") { name } }" + strings.Repeat(" ", maxGraphQLRequest)
+	w, _ := postGraphQL(t, s, gqlBody(query))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestGraphQLTooDeep(t *testing.T) {
+	s := newTestServer(t, "package p\n")
+	// Fragments F0 to F32, each nesting the next in a selection set.
+	fragments := `{ package(importPath: "p") { types { ...F0 } } }`
+	for i := 0; i < gqlMaxDepth; i++ {
+		fragments += fmt.Sprintf("\nfragment F%d on Type { methods { ...F%d } }", i, i+1)
+	}
+	fragments += fmt.Sprintf("\nfragment F%d on Type { name }", gqlMaxDepth)
+	for name, query := range map[string]string{
+		"selections": strings.Repeat("{ a ", 100000) + strings.Repeat("}", 100000),
+		"values":     `{ package(importPath: ` + strings.Repeat("[", 100000) + strings.Repeat("]", 100000) + `) { name } }`,
+		"fragments":  fragments,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w, resp := postGraphQL(t, s, gqlBody(query))
+			if resp == nil {
+				t.Fatalf("got status %d: %s", w.Code, w.Body)
+			}
+			if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "deeper than") {
+				t.Errorf("got %+v, want a nesting depth error", resp.Errors)
+			}
+		})
+	}
+}
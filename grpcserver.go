@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// gRPC status codes, see
+// https://github.com/grpc/grpc/blob/master/doc/statuscodes.md.
+const (
+	grpcOK            = 0
+	grpcInvalidArg    = 3
+	grpcNotFound      = 5
+	grpcExhausted     = 8
+	grpcUnimplemented = 12
+	grpcInternal      = 13
+)
+
+// grpcExtractPath is the HTTP/2 path of the Extract method.
+const grpcExtractPath = "/godocjson.Extractor/Extract"
+
+// maxGRPCRequest is the size limit of the body of gRPC requests, whose
+// message only holds an import path.
+const maxGRPCRequest = 64 << 10
+
+// grpcStatus sets the status of a gRPC response in its trailers.
+func grpcStatus(w http.ResponseWriter, code int, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", grpcPercentEncode(message))
+	}
+}
+
+// grpcPercentEncode encodes message as required for the grpc-message
+// trailer.
+func grpcPercentEncode(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		if c := message[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// grpcMessage returns the single message of the body of a unary gRPC
+// request.
+func grpcMessage(body []byte) ([]byte, error) {
+	if len(body) < 5 {
+		return nil, fmt.Errorf("missing request message")
+	}
+	if body[0] != 0 {
+		return nil, fmt.Errorf("compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(body[1:5])
+	if uint64(length) != uint64(len(body)-5) {
+		return nil, fmt.Errorf("expected a single request message")
+	}
+	return body[5:], nil
+}
+
+// serveGRPC implements the Extractor service of protoSchema over HTTP/2.
+func (s *packageServer) serveGRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	if r.URL.Path != grpcExtractPath {
+		grpcStatus(w, grpcUnimplemented, "unknown method "+r.URL.Path)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxGRPCRequest))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		grpcStatus(w, grpcExhausted, fmt.Sprintf("request larger than %d bytes", maxGRPCRequest))
+		return
+	} else if err != nil {
+		grpcStatus(w, grpcInternal, err.Error())
+		return
+	}
+	msg, err := grpcMessage(body)
+	if err != nil {
+		grpcStatus(w, grpcInvalidArg, err.Error())
+		return
+	}
+	var importPath string
+	req := &protoReader{data: msg}
+	for {
+		num, wireType, _, data, err := req.next()
+		if err != nil {
+			grpcStatus(w, grpcInvalidArg, err.Error())
+			return
+		}
+		if num == 0 {
+			break
+		}
+		if num == 1 && wireType == protoBytes {
+			importPath = string(data)
+		}
+	}
+
+	pkg, err := s.lookupPackage(importPath)
+	if err != nil {
+		grpcStatus(w, grpcInternal, err.Error())
+		return
+	}
+	if pkg == nil {
+		grpcStatus(w, grpcNotFound, "no package "+importPath)
+		return
+	}
+	resp, err := marshalProto(pkg)
+	if err != nil {
+		grpcStatus(w, grpcInternal, err.Error())
+		return
+	}
+	frame := make([]byte, 5, 5+len(resp))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(resp)))
+	w.Write(append(frame, resp...))
+	grpcStatus(w, grpcOK, "")
+}
+
+// runGRPCServer implements the grpc-server subcommand.
+func runGRPCServer(args []string) int {
+	flags := flag.NewFlagSet("grpc-server", flag.ExitOnError)
+	root := flags.String("root", ".", "Directory containing the packages to serve")
+	addr := flags.String("addr", "localhost:50051", "Address to listen on")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	proto := flags.Bool("proto", false, "Print the .proto definition of the service and exit")
+	var opts extract.Options
+	flags.BoolVar(&opts.ResolveEmbedded, "resolve-embedded", false, "List methods promoted from embedded types of other packages")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	opts.Filter = fileFilter
+
+	if *proto {
+		fmt.Print(protoSchema())
+		return 0
+	}
+	s := &packageServer{
+		root:       *root,
+		modulePath: extract.ModulePath(*root),
+		opts:       opts,
+		cache:      map[string]*servedPackage{},
+	}
+	// gRPC clients speak HTTP/2 with prior knowledge, without TLS.
+	server := &http.Server{Addr: *addr, Handler: http.HandlerFunc(s.serveGRPC)}
+	server.Protocols = new(http.Protocols)
+	server.Protocols.SetUnencryptedHTTP2(true)
+	log.Printf("Serving packages of %s over gRPC on %s", *root, *addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
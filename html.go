@@ -0,0 +1,165 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"go/doc/comment"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+//go:embed templates
+var htmlTemplates embed.FS
+
+// htmlFuncs are the functions available to the HTML templates.
+var htmlFuncs = template.FuncMap{
+	"doc":     docHTML,
+	"oneLine": oneLine,
+	"join":    strings.Join,
+	"anchor": func(typeName string, f *extract.Func) *htmlFunc {
+		if typeName == "" {
+			return &htmlFunc{Anchor: f.Name, Title: f.Name, Func: f}
+		}
+		return &htmlFunc{Anchor: typeName + "." + f.Name, Title: "(" + f.Recv + ") " + f.Name, Func: f}
+	},
+}
+
+// htmlFunc is a function rendered by the "func" template.
+type htmlFunc struct {
+	Anchor string // e.g. "T.Method", like pkg.go.dev
+	Title  string // e.g. "(*T) Method"
+	Func   *extract.Func
+}
+
+// htmlPage is the data of the page of a package.
+type htmlPage struct {
+	Path     string // import path
+	File     string // file name of the page in the site
+	Synopsis string
+	Package  *extract.Package
+}
+
+// docHTML renders a doc comment as HTML.
+func docHTML(text string) template.HTML {
+	var p comment.Parser
+	pr := comment.Printer{HeadingLevel: 3}
+	return template.HTML(pr.HTML(p.Parse(text)))
+}
+
+// htmlFileName returns the name of the page of the package importPath: its
+// path with underscores doubled, so that "a_b" and "a/b" don't share a
+// page, and slashes replaced by underscores.
+func htmlFileName(importPath string) string {
+	name := strings.Trim(filepath.ToSlash(filepath.Clean(importPath)), "./")
+	name = strings.ReplaceAll(name, "_", "__")
+	return strings.ReplaceAll(name, "/", "_") + ".html"
+}
+
+// htmlURI returns the URI of the symbol name of the package importPath in
+// the html site, relative to its root, or of the page of the package if
+// name is empty.
+func htmlURI(importPath, name string) string {
+	if name == "" {
+		return htmlFileName(importPath)
+	}
+	return htmlFileName(importPath) + "#" + name
+}
+
+// writeHTMLSite writes the pages of pkgs, an index page listing them and the
+// style sheet to the directory output.
+func writeHTMLSite(output, title string, pkgs []*extract.Package) error {
+	tmpl, err := template.New("").Funcs(htmlFuncs).ParseFS(htmlTemplates, "templates/*.html")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(output, 0755); err != nil {
+		return err
+	}
+	var pages []*htmlPage
+	paths := map[string]string{"index.html": "the index page"} // import paths by page
+	for _, pkg := range pkgs {
+		path := extract.PackagePath(pkg)
+		page := &htmlPage{Path: path, File: htmlFileName(path), Synopsis: pkg.Synopsis, Package: pkg}
+		if other, ok := paths[page.File]; ok {
+			return fmt.Errorf("%s and %s are both written to %s", other, path, page.File)
+		}
+		paths[page.File] = path
+		if page.Synopsis == "" {
+			page.Synopsis = extract.Synopsis(pkg.Doc)
+		}
+		err := extract.WriteFileAtomic(filepath.Join(output, page.File), func(w io.Writer) error {
+			return tmpl.ExecuteTemplate(w, "package.html", page)
+		})
+		if err != nil {
+			return err
+		}
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Path < pages[j].Path })
+	err = extract.WriteFileAtomic(filepath.Join(output, "index.html"), func(w io.Writer) error {
+		return tmpl.ExecuteTemplate(w, "index.html", struct {
+			Title    string
+			Packages []*htmlPage
+		}{title, pages})
+	})
+	if err != nil {
+		return err
+	}
+	css, err := htmlTemplates.ReadFile("templates/style.css")
+	if err != nil {
+		return err
+	}
+	return extract.WriteFileAtomic(filepath.Join(output, "style.css"), func(w io.Writer) error {
+		_, err := w.Write(css)
+		return err
+	})
+}
+
+// runHTML implements the html subcommand.
+func runHTML(args []string) int {
+	flags := flag.NewFlagSet("html", flag.ExitOnError)
+	output := flags.String("o", "site", "Directory receiving the site")
+	title := flags.String("title", "Packages", "Title of the index page")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson html [-o dir] [-title title] [-e pattern] directory...")
+		return 2
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := extract.Options{Filter: fileFilter, ExcludeTests: true}
+	var pkgs []*extract.Package
+	for _, directory := range directories {
+		pkg, err := extract.Extract(directory, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if err := writeHTMLSite(*output, *title, pkgs); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
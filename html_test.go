@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestHTMLFileName(t *testing.T) {
+	for path, want := range map[string]string{
+		"example.com/m/p": "example.com_m_p.html",
+		"./p/":            "p.html",
+		"a_b":             "a__b.html",
+		"a/b":             "a_b.html",
+	} {
+		if got := htmlFileName(path); got != want {
+			t.Errorf("htmlFileName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRunHTML(t *testing.T) {
+	dir := writeModule(t)
+	output := t.TempDir()
+	if status := runHTML([]string{"-o", output, "-title", "Module m", filepath.Join(dir, "...")}); status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	index, err := os.ReadFile(filepath.Join(output, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>Module m</title>",
+		`<tr><td><a href="example.com_m_p.html">example.com/m/p</a></td><td>Package p adds numbers.</td></tr>`,
+		"<td>Tool prints numbers.</td>",
+	} {
+		if !strings.Contains(string(index), want) {
+			t.Errorf("missing %q in\n%s", want, index)
+		}
+	}
+	page, err := os.ReadFile(filepath.Join(output, "example.com_m_p.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h1>package p</h1>",
+		`<li><a href="#T.Double">func (t T) Double() int</a></li>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("missing %q in\n%s", want, page)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(output, "style.css")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteHTMLSiteCollision(t *testing.T) {
+	pkgs := []*extract.Package{
+		{Name: "b", ImportPath: "a/b"},
+		{Name: "a_b", ImportPath: "a_b"},
+	}
+	output := t.TempDir()
+	if err := writeHTMLSite(output, "Packages", pkgs); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a_b.html", "a__b.html"} {
+		if _, err := os.Stat(filepath.Join(output, name)); err != nil {
+			t.Error(err)
+		}
+	}
+	pkgs = append(pkgs, &extract.Package{Name: "index", ImportPath: "index"})
+	err := writeHTMLSite(t.TempDir(), "Packages", pkgs)
+	if want := "the index page and index are both written to index.html"; err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// packageKeyInvalid matches the characters replaced in the keys of
+// packages, so that they can be used in Hugo templates as
+// .Site.Data.godocjson.packages.<key>, or as Docusaurus doc IDs.
+var packageKeyInvalid = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// HugoPackage is an entry of the packages of the Hugo index.
+type HugoPackage struct {
+	Key        string `json:"key"` // name of the data file of the package, without extension
+	ImportPath string `json:"importPath"`
+	Name       string `json:"name"`
+	Synopsis   string `json:"synopsis"`
+}
+
+// HugoSymbol is an entry of the symbols of the Hugo index.
+type HugoSymbol struct {
+	*extract.IndexEntry
+	Key string `json:"key"` // data key of the package declaring the symbol
+}
+
+// HugoIndex is the data file listing the packages and symbols written to a
+// Hugo data directory.
+type HugoIndex struct {
+	Packages []*HugoPackage `json:"packages"` // by import path
+	Symbols  []*HugoSymbol  `json:"symbols"`  // by package, then in document order
+}
+
+// packageKey returns the key naming the files of the package importPath, e.g.
+// "example_com_mod_pkg" for "example.com/mod/pkg".
+func packageKey(importPath string) string {
+	key := packageKeyInvalid.ReplaceAllString(filepath.ToSlash(filepath.Clean(importPath)), "_")
+	if key = strings.Trim(key, "_"); key == "" {
+		return "root"
+	}
+	return key
+}
+
+// writeHugoData writes the document of every package of pkgs to
+// data/godocjson/packages/<key>.<format> below the site directory, and the
+// HugoIndex to data/godocjson/index.<format>, format being json or toml.
+func writeHugoData(site, format string, pkgs []*extract.Package, opts *outputOptions) error {
+	dir := filepath.Join(site, "data", "godocjson")
+	if err := os.MkdirAll(filepath.Join(dir, "packages"), 0755); err != nil {
+		return err
+	}
+	write := func(name string, v interface{}) error {
+		return extract.WriteFileAtomic(filepath.Join(dir, name+"."+format), func(w io.Writer) error {
+			if format == "json" {
+				return writeJSON(w, v, opts)
+			}
+			value, err := jsonValue(v)
+			if err != nil {
+				return err
+			}
+			return writeTOML(w, value.(map[string]interface{}))
+		})
+	}
+
+	index := &HugoIndex{Packages: []*HugoPackage{}, Symbols: []*HugoSymbol{}}
+	keys := map[string]string{}
+	for _, pkg := range pkgs {
+		path := extract.PackagePath(pkg)
+		key := packageKey(path)
+		if other, ok := keys[key]; ok {
+			return fmt.Errorf("packages %s and %s have the same Hugo data key %s", other, path, key)
+		}
+		keys[key] = path
+		if err := write(filepath.Join("packages", key), pkg); err != nil {
+			return err
+		}
+		synopsis := pkg.Synopsis
+		if synopsis == "" {
+			synopsis = extract.Synopsis(pkg.Doc)
+		}
+		index.Packages = append(index.Packages, &HugoPackage{Key: key, ImportPath: path, Name: pkg.Name, Synopsis: synopsis})
+		for _, entry := range extract.BuildIndex(pkg) {
+			index.Symbols = append(index.Symbols, &HugoSymbol{IndexEntry: entry, Key: key})
+		}
+	}
+	sort.Slice(index.Packages, func(i, j int) bool { return index.Packages[i].ImportPath < index.Packages[j].ImportPath })
+	return write("index", index)
+}
+
+// runHugo implements the hugo subcommand.
+func runHugo(args []string) int {
+	flags := flag.NewFlagSet("hugo", flag.ExitOnError)
+	output := flags.String("o", ".", "Directory of the Hugo site, receiving the data/godocjson directory")
+	format := flags.String("format", "json", "Format of the data files: json or toml")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() == 0 || (*format != "json" && *format != "toml") {
+		fmt.Fprintln(os.Stderr, "usage: godocjson hugo [-o site] [-format json|toml] [-e pattern] directory...")
+		return 2
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := extract.Options{Filter: fileFilter, ExcludeTests: true}
+	var pkgs []*extract.Package
+	for _, directory := range directories {
+		pkg, err := extract.Extract(directory, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if err := writeHugoData(*output, *format, pkgs, &outputOptions{Indent: "  "}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackageKey(t *testing.T) {
+	for path, want := range map[string]string{
+		"example.com/mod/pkg": "example_com_mod_pkg",
+		"./pkg/":              "pkg",
+		".":                   "root",
+		"gopkg.in/yaml.v3":    "gopkg_in_yaml_v3",
+	} {
+		if got := packageKey(path); got != want {
+			t.Errorf("packageKey(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestRunHugo(t *testing.T) {
+	dir := writeModule(t)
+	site := t.TempDir()
+	if status := runHugo([]string{"-o", site, filepath.Join(dir, "p"), filepath.Join(dir, "q")}); status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	data := filepath.Join(site, "data", "godocjson")
+	indexJSON, err := os.ReadFile(filepath.Join(data, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index HugoIndex
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, pkg := range index.Packages {
+		keys = append(keys, pkg.Key+" "+pkg.ImportPath+" "+pkg.Synopsis)
+	}
+	want := []string{"example_com_m_p example.com/m/p Package p adds numbers.", "example_com_m_q example.com/m/q Package q uses {p}."}
+	if strings.Join(keys, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got packages\n%s\nwant\n%s", strings.Join(keys, "\n"), strings.Join(want, "\n"))
+	}
+	symbols := map[string]string{}
+	for _, s := range index.Symbols {
+		symbols[s.Name] = s.Key
+	}
+	if symbols["T.Double"] != "example_com_m_p" || symbols["Sum"] != "example_com_m_q" {
+		t.Errorf("got symbols %v", symbols)
+	}
+	for _, key := range []string{"example_com_m_p", "example_com_m_q"} {
+		if _, err := os.Stat(filepath.Join(data, "packages", key+".json")); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRunHugoTOML(t *testing.T) {
+	dir := writeModule(t)
+	site := t.TempDir()
+	if status := runHugo([]string{"-o", site, "-format", "toml", filepath.Join(dir, "p")}); status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	index, err := os.ReadFile(filepath.Join(site, "data", "godocjson", "index.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), `importPath = "example.com/m/p"`) {
+		t.Errorf("index.toml does not list example.com/m/p:\n%s", index)
+	}
+	if _, err := os.Stat(filepath.Join(site, "data", "godocjson", "packages", "example_com_m_p.toml")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRunHugoUsage(t *testing.T) {
+	silenceStderr(t)
+	for _, args := range [][]string{nil, {"-format", "yaml", "."}} {
+		if status := runHugo(args); status != 2 {
+			t.Errorf("runHugo(%q) = %d, want 2", args, status)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// LayerViolation is an import between layers not allowed by the
+// configuration.
+type LayerViolation struct {
+	From      string `json:"from"`
+	FromLayer string `json:"fromLayer"`
+	To        string `json:"to"`
+	ToLayer   string `json:"toLayer"`
+	Test      bool   `json:"test,omitempty"` // only _test.go files import To
+}
+
+// ImportReport is the result of the imports subcommand.
+type ImportReport struct {
+	Cycles     []*ImportCycle    `json:"cycles"`
+	Violations []*LayerViolation `json:"violations"`
+}
+
+// matchPackage reports whether importPath matches pattern, which may end
+// in "/..." to also match every package below it.
+func matchPackage(pattern, importPath string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+	}
+	return importPath == pattern
+}
+
+// layerOf returns the first layer containing importPath, or nil.
+func layerOf(layers []*Layer, importPath string) *Layer {
+	for _, layer := range layers {
+		for _, pattern := range layer.Packages {
+			if matchPackage(pattern, importPath) {
+				return layer
+			}
+		}
+	}
+	return nil
+}
+
+// LayerViolations returns the edges of g that are not allowed by layers.
+func (g *ImportGraph) LayerViolations(layers []*Layer) []*LayerViolation {
+	violations := []*LayerViolation{}
+	for _, e := range g.Edges {
+		from, to := layerOf(layers, e.From), layerOf(layers, e.To)
+		if from == nil || to == nil || from == to {
+			continue
+		}
+		allowed := false
+		for _, name := range from.MayImport {
+			allowed = allowed || name == to.Name
+		}
+		if !allowed {
+			violations = append(violations, &LayerViolation{
+				From:      e.From,
+				FromLayer: from.Name,
+				To:        e.To,
+				ToLayer:   to.Name,
+				Test:      e.Test,
+			})
+		}
+	}
+	return violations
+}
+
+// writeMarkdown writes the report as a Markdown document.
+func (r *ImportReport) writeMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Import report\n\n## Cycles\n\n")
+	if len(r.Cycles) == 0 {
+		fmt.Fprintf(w, "No import cycles.\n")
+	}
+	for _, c := range r.Cycles {
+		test := ""
+		if c.Test {
+			test = " (through test files)"
+		}
+		fmt.Fprintf(w, "- `%s`%s\n", strings.Join(c.Packages, "` ↔ `"), test)
+	}
+	fmt.Fprintf(w, "\n## Layering violations\n\n")
+	if len(r.Violations) == 0 {
+		fmt.Fprintf(w, "No layering violations.\n")
+		return
+	}
+	fmt.Fprintf(w, "| Package | Layer | Imports | Layer | Test only |\n")
+	fmt.Fprintf(w, "|---|---|---|---|---|\n")
+	for _, v := range r.Violations {
+		fmt.Fprintf(w, "| `%s` | %s | `%s` | %s | %t |\n", v.From, v.FromLayer, v.To, v.ToLayer, v.Test)
+	}
+}
+
+// runImports implements the imports subcommand.
+func runImports(args []string) int {
+	flags := flag.NewFlagSet("imports", flag.ExitOnError)
+	configFile := flags.String("config", "", "Configuration file declaring the layers (default "+defaultConfigFile+" if present)")
+	format := flags.String("format", "json", "Report format: json or markdown")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	config, err := LoadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	graph, err := BuildImportGraph(flags.Args(), fileFilter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	report := &ImportReport{
+		Cycles:     graph.Cycles(),
+		Violations: graph.LayerViolations(config.Layers),
+	}
+	if report.Cycles == nil {
+		report.Cycles = []*ImportCycle{}
+	}
+
+	switch *format {
+	case "json":
+		reportJSON, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Printf("%s\n", reportJSON)
+	case "markdown":
+		report.writeMarkdown(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown report format %q\n", *format)
+		return 2
+	}
+	if len(report.Cycles) > 0 || len(report.Violations) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatchPackage(t *testing.T) {
+	for _, test := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"example.com/m/p", "example.com/m/p", true},
+		{"example.com/m/p", "example.com/m/p/x", false},
+		{"example.com/m/...", "example.com/m", true},
+		{"example.com/m/...", "example.com/m/p/x", true},
+		{"example.com/m/...", "example.com/mx", false},
+	} {
+		if got := matchPackage(test.pattern, test.path); got != test.want {
+			t.Errorf("matchPackage(%q, %q) = %t, want %t", test.pattern, test.path, got, test.want)
+		}
+	}
+}
+
+func TestLayerViolations(t *testing.T) {
+	layers := []*Layer{
+		{Name: "core", Packages: []string{"example.com/m/p"}},
+		{Name: "app", Packages: []string{"example.com/m/q", "example.com/m/r"}, MayImport: []string{"core"}},
+	}
+	graph := moduleGraph(t)
+	if violations := graph.LayerViolations(layers); len(violations) != 0 {
+		t.Errorf("got violations %+v, want none", violations)
+	}
+
+	// The imports within a layer are always allowed.
+	layers[1].MayImport = nil
+	report := &ImportReport{Cycles: graph.Cycles(), Violations: graph.LayerViolations(layers)}
+	if len(report.Violations) != 1 {
+		t.Fatalf("got violations %+v, want q -> p", report.Violations)
+	}
+	var b strings.Builder
+	report.writeMarkdown(&b)
+	for _, want := range []string{
+		"- `example.com/m/q` ↔ `example.com/m/r` (through test files)\n",
+		"| `example.com/m/q` | app | `example.com/m/p` | core | false |\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in\n%s", want, b.String())
+		}
+	}
+}
+
+func TestRunImports(t *testing.T) {
+	dir := writeModule(t)
+	config := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(config, []byte(`{"layers": [{"name": "core", "packages": ["example.com/m/p"]}, {"name": "app", "packages": ["example.com/m/q"]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"-config", config, filepath.Join(dir, "p"), filepath.Join(dir, "q"), filepath.Join(dir, "r")}
+	out, status := captureStdout(t, func() int { return runImports(args) })
+	if status != 1 {
+		t.Errorf("got exit status %d with a cycle and a violation, want 1", status)
+	}
+	var report ImportReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Cycles) != 1 || len(report.Violations) != 1 {
+		t.Errorf("got report %s, want a cycle and a violation", out)
+	}
+
+	_, status = captureStdout(t, func() int { return runImports([]string{"-config", config, filepath.Join(dir, "p")}) })
+	if status != 0 {
+		t.Errorf("got exit status %d for p alone, want 0", status)
+	}
+}
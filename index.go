@@ -0,0 +1,13 @@
+package main
+
+import (
+	"io"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func indexFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		return writeJSON(w, extract.BuildIndex(pkg), opts)
+	}
+}
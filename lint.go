@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/doclint"
+	"github.com/rtfd/godocjson/extract"
+)
+
+// LintFinding is a violation of a documentation rule, located in a source
+// file.
+type LintFinding struct {
+	Rule     string `json:"rule"` // doclint.MissingDoc or doclint.DocPrefix
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Message  string `json:"message"`
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+}
+
+// lintRules describes the rules of doclint, in the order of their index in
+// SARIF logs.
+var lintRules = []struct {
+	id, description string
+}{
+	{doclint.MissingDoc, "Exported declarations and packages should have a doc comment."},
+	{doclint.DocPrefix, "Doc comments should start with the name of the declaration they document."},
+}
+
+// lintPackage is a package checked by doclint.
+type lintPackage struct {
+	ImportPath string // directory of the package, like go/doc
+	Symbols    []*doclint.Symbol
+}
+
+// lintPackages returns the packages in directory, ignoring _test.go files,
+// with their exported symbols as checked by doclint, package first, and
+// the file set positioning the symbols. The files are those documented by
+// godocjson: packages of programs tagged "//go:build ignore" are left out.
+func lintPackages(directory string, filter func(os.FileInfo) bool) (*token.FileSet, []*lintPackage, error) {
+	opts := extract.Options{Filter: filter, ExcludeTests: true}
+	fileSet := token.NewFileSet()
+	astPkgs, err := extract.ParseDir(fileSet, directory, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(astPkgs))
+	for name := range astPkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pkgs []*lintPackage
+	for _, name := range names {
+		var files []*ast.File
+		for _, file := range astPkgs[name].Files {
+			files = append(files, file)
+		}
+		sort.Slice(files, func(i, j int) bool { return files[i].Pos() < files[j].Pos() })
+		pkg := &lintPackage{ImportPath: directory, Symbols: []*doclint.Symbol{doclint.PackageSymbol(files)}}
+		for _, file := range files {
+			pkg.Symbols = append(pkg.Symbols, doclint.Symbols(file)...)
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return fileSet, pkgs, nil
+}
+
+// LintDirectory checks the documentation of the exported declarations of
+// the packages in directory, ignoring _test.go files, with the rules of
+// doclint.
+func LintDirectory(directory string, filter func(os.FileInfo) bool) ([]*LintFinding, error) {
+	fileSet, pkgs, err := lintPackages(directory, filter)
+	if err != nil {
+		return nil, err
+	}
+	var findings []*LintFinding
+	for _, pkg := range pkgs {
+		for _, symbol := range pkg.Symbols {
+			for _, f := range doclint.Check(symbol) {
+				pos := fileSet.Position(symbol.Pos)
+				findings = append(findings, &LintFinding{
+					Rule:     f.Rule,
+					Kind:     symbol.Kind,
+					Name:     symbol.Name,
+					Message:  f.Message,
+					Filename: pos.Filename,
+					Line:     pos.Line,
+					Column:   pos.Column,
+				})
+			}
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Line < b.Line
+	})
+	return findings, nil
+}
+
+// sarifLog is the subset of a SARIF 2.1.0 log written by writeSARIF.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+// sarifURI returns the URI of filename in a SARIF log: relative to the
+// current directory, which code review tools take as the root of the
+// repository, when below it.
+func sarifURI(filename string) sarifArtifactLocation {
+	if abs, err := filepath.Abs(filename); err == nil {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				return sarifArtifactLocation{URI: filepath.ToSlash(rel), URIBaseID: "%SRCROOT%"}
+			}
+		}
+		filename = abs
+	}
+	return sarifArtifactLocation{URI: "file://" + filepath.ToSlash(filename)}
+}
+
+// writeSARIF writes findings as a SARIF 2.1.0 log.
+func writeSARIF(w io.Writer, findings []*LintFinding) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "godocjson",
+			InformationURI: "https://github.com/rtfd/godocjson",
+			Version:        extract.ToolVersion(),
+		}},
+		Results: []sarifResult{},
+	}
+	ruleIndex := map[string]int{}
+	for i, rule := range lintRules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: rule.id, ShortDescription: sarifMessage{rule.description}})
+		ruleIndex[rule.id] = i
+	}
+	for _, f := range findings {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: ruleIndex[f.Rule],
+			Level:     "warning",
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifURI(f.Filename),
+				Region:           sarifRegion{StartLine: f.Line, StartColumn: f.Column},
+			}}},
+		})
+	}
+	sarif := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+	logJSON, err := json.MarshalIndent(sarif, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", logJSON)
+	return err
+}
+
+// runLint implements the lint subcommand.
+func runLint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := flags.String("format", "text", "Report format: text, json or sarif")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson lint [-format text|json|sarif] [-e pattern] directory...")
+		return 2
+	}
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "unknown report format %q\n", *format)
+		return 2
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	findings := []*LintFinding{}
+	for _, directory := range directories {
+		dirFindings, err := LintDirectory(directory, fileFilter)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		findings = append(findings, dirFindings...)
+	}
+
+	switch *format {
+	case "text":
+		for _, f := range findings {
+			fmt.Printf("%s:%d:%d: %s (%s)\n", f.Filename, f.Line, f.Column, f.Message, f.Rule)
+		}
+	case "json":
+		findingsJSON, _ := json.MarshalIndent(findings, "", "  ")
+		fmt.Printf("%s\n", findingsJSON)
+	case "sarif":
+		if err := writeSARIF(os.Stdout, findings); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
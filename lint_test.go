@@ -0,0 +1,26 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLintIgnoredPrograms(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"p.go":   "// Package p is documented.\npackage p\n\n// F is documented.\nfunc F() {}\n",
+		"gen.go": "//go:build ignore\n\npackage main\n\nfunc Generate() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	findings, err := LintDirectory(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findings {
+		t.Errorf("unexpected finding %s:%d: %s", f.Filename, f.Line, f.Message)
+	}
+}
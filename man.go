@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// roffEscaper escapes the characters of text interpreted by roff.
+var roffEscaper = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+
+// roffText returns text escaped as roff input lines: lines starting with a
+// control character are protected with \&.
+func roffText(text string) string {
+	lines := strings.Split(roffEscaper.Replace(text), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffDoc renders a doc comment as the paragraphs of a man page: headings
+// are subsections, code blocks are indented and not filled, and list items
+// are indented paragraphs.
+func roffDoc(w io.Writer, text string) {
+	var p comment.Parser
+	pr := comment.Printer{TextWidth: -1}
+	plain := func(blocks ...comment.Block) string {
+		return strings.TrimSpace(string(pr.Text(&comment.Doc{Content: blocks})))
+	}
+	for _, block := range p.Parse(text).Content {
+		switch b := block.(type) {
+		case *comment.Heading:
+			fmt.Fprintf(w, ".SS %s\n", roffText(plain(&comment.Paragraph{Text: b.Text})))
+		case *comment.Code:
+			fmt.Fprintf(w, ".PP\n.RS 4\n.nf\n%s\n.fi\n.RE\n", roffText(strings.TrimSuffix(b.Text, "\n")))
+		case *comment.List:
+			for i, item := range b.Items {
+				bullet := `\(bu`
+				if item.Number != "" {
+					bullet = fmt.Sprintf("%d.", i+1)
+				}
+				fmt.Fprintf(w, ".IP %s 4\n", bullet)
+				for j, c := range item.Content {
+					if j > 0 {
+						fmt.Fprintf(w, ".IP \"\" 4\n")
+					}
+					fmt.Fprintf(w, "%s\n", roffText(plain(c)))
+				}
+			}
+		default:
+			fmt.Fprintf(w, ".PP\n%s\n", roffText(plain(b)))
+		}
+	}
+}
+
+// commandName returns the name of the command built from the main package
+// pkg: the last element of its import path, or of its directory.
+func commandName(pkg *extract.Package) string {
+	name := path.Base(extract.PackagePath(pkg))
+	if (name == "." || name == "/") && len(pkg.Filenames) > 0 {
+		if dir, err := filepath.Abs(filepath.Dir(pkg.Filenames[0])); err == nil {
+			name = filepath.Base(dir)
+		}
+	}
+	return name
+}
+
+// manFormatter writes a man page, in section 1, of every main package from
+// its package comment and the flags it defines with the flag package.
+// Other packages are skipped.
+func manFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		if pkg.Name != "main" {
+			return nil
+		}
+		name := commandName(pkg)
+		fmt.Fprintf(w, ".TH %s 1\n", roffText(strings.ToUpper(name)))
+		fmt.Fprintf(w, ".SH NAME\n%s", roffText(name))
+		synopsis := pkg.Synopsis
+		if synopsis == "" {
+			synopsis = extract.Synopsis(pkg.Doc)
+		}
+		if synopsis != "" {
+			fmt.Fprintf(w, ` \- %s`, roffText(strings.TrimSuffix(synopsis, ".")))
+		}
+		fmt.Fprintf(w, "\n.SH SYNOPSIS\n.B %s\n", roffText(name))
+		if len(pkg.Flags) > 0 {
+			fmt.Fprintf(w, ".RI [ options ]\n")
+		}
+		if pkg.Doc != "" {
+			fmt.Fprintf(w, ".SH DESCRIPTION\n")
+			roffDoc(w, pkg.Doc)
+		}
+		if len(pkg.Flags) > 0 {
+			fmt.Fprintf(w, ".SH OPTIONS\n")
+		}
+		for _, f := range pkg.Flags {
+			fmt.Fprintf(w, ".TP\n\\fB\\-%s\\fR", roffText(f.Name))
+			if f.Type != "bool" && f.Type != "func" {
+				fmt.Fprintf(w, " \\fI%s\\fR", roffText(f.Type))
+			}
+			fmt.Fprintf(w, "\n%s\n", roffText(f.Usage))
+			switch f.Default {
+			case "", "0", "false":
+			default:
+				fmt.Fprintf(w, "Defaults to %s.\n", roffText(f.Default))
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestRoffText(t *testing.T) {
+	if got, want := roffText(".TH\n'quote\nC:\\dir -v"), "\\&.TH\n\\&'quote\nC:\\edir \\-v"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestManFormatter(t *testing.T) {
+	dir := writeModule(t)
+	pkg, err := extract.Extract(filepath.Join(dir, "cmd", "tool"), extract.Options{ExcludeTests: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := manFormatter(&outputOptions{})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		".TH TOOL 1\n.SH NAME\ntool \\- Tool prints numbers\n",
+		".SH SYNOPSIS\n.B tool\n.RI [ options ]\n",
+		".TP\n\\fB\\-v\\fR\nPrint more details\n",
+		".TP\n\\fB\\-n\\fR \\fIint\\fR\nNumber of lines\nDefaults to 3.\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in\n%s", want, b.String())
+		}
+	}
+
+	// Other packages have no man page, and are reported.
+	pkg, err = extract.Extract(filepath.Join(dir, "p"), extract.Options{ExcludeTests: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "p.1")
+	out := &outputTarget{Path: output, Format: "man", Write: manFormatter(&outputOptions{})}
+	var logged strings.Builder
+	defer func(w io.Writer) { log.SetOutput(w) }(log.Writer())
+	log.SetOutput(&logged)
+	defer warnings.Store(warnings.Load())
+	warnings.Store(0)
+	if err := out.WritePackage(pkg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("got a man page for package p: %v", err)
+	}
+	if want := "Warning: example.com/m/p is not a command, no man page written"; warnings.Load() != 1 || !strings.Contains(logged.String(), want) {
+		t.Errorf("got %d warning(s), want %q in\n%s", warnings.Load(), want, logged.String())
+	}
+}
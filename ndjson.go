@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// SymbolRecord is one line of the ndjson-symbols format: the index entry of
+// a symbol followed by the symbol itself.
+type SymbolRecord struct {
+	*extract.IndexEntry
+	Symbol interface{} `json:"symbol"`
+}
+
+// ndjsonFormatter writes every package document on a single line.
+func ndjsonFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		doc, err := opts.document(pkg)
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, doc, opts.line())
+	}
+}
+
+// ndjsonSymbolsFormatter writes one SymbolRecord per line for every symbol
+// of a package. Declarations nested in packages and types are cleared since
+// they are written on lines of their own, and values declaring several names
+// are written once.
+func ndjsonSymbolsFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		lineOpts := opts.line()
+		var err error
+		var last interface{}
+		extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+			if err != nil || symbol == last {
+				return
+			}
+			last = symbol
+			switch s := symbol.(type) {
+			case *extract.Package:
+				p := *s
+				p.Consts, p.Types, p.Vars, p.Funcs = nil, nil, nil, nil
+				symbol = &p
+			case *extract.Type:
+				t := *s
+				t.Consts, t.Vars, t.Funcs, t.Methods = nil, nil, nil, nil
+				symbol = &t
+			}
+			if symbol, err = opts.symbol(symbol); err != nil {
+				return
+			}
+			err = writeJSON(w, SymbolRecord{IndexEntry: entry, Symbol: symbol}, lineOpts)
+		})
+		return err
+	}
+}
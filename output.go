@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// A formatter writes a documented package to w.
+type formatter func(w io.Writer, pkg *extract.Package) error
+
+// outputOptions controls how formatters encode packages.
+type outputOptions struct {
+	Indent    string // indentation of JSON output; empty for compact output
+	Canonical bool   // write JSON output in canonical form, see marshalCanonical; overrides Indent
+
+	Fields    map[string]bool // with -fields, the fields of the symbols written; nil for all
+	OmitEmpty bool            // drop empty arrays, strings and objects, and zero values, see omitEmpty
+	SchemaV2  bool            // write documents in the v2 schema, see extract.ConvertV2
+}
+
+// formatters maps -format names to a constructor of their implementation.
+var formatters = map[string]func(opts *outputOptions) formatter{
+	"json":           jsonFormatter,
+	"index":          indexFormatter,
+	"ndjson":         ndjsonFormatter,
+	"ndjson-symbols": ndjsonSymbolsFormatter,
+	"msgpack":        binaryFormatter(newMsgpackEncoder),
+	"cbor":           binaryFormatter(newCBOREncoder),
+	"sphinx-inv":     sphinxInventoryFormatter,
+	"rst":            rstFormatter,
+	"docfx":          docfxFormatter,
+	"doxygen-xml":    doxygenFormatter,
+	"man":            manFormatter,
+	"text":           textFormatter,
+	"lunr":           lunrFormatter,
+	"es-bulk":        esBulkFormatter,
+}
+
+// formatExtensions maps -format names to the file extension used for
+// per-package output files.
+var formatExtensions = map[string]string{
+	"json":           ".json",
+	"index":          ".index.json",
+	"ndjson":         ".ndjson",
+	"ndjson-symbols": ".ndjson",
+	"msgpack":        ".msgpack",
+	"cbor":           ".cbor",
+	"sphinx-inv":     ".inv",
+	"rst":            ".rst",
+	"docfx":          ".yml",
+	"doxygen-xml":    ".xml",
+	"man":            ".1",
+	"text":           ".txt",
+	"lunr":           ".lunr.json",
+	"es-bulk":        ".ndjson",
+}
+
+// getFormatter returns the formatter for the given -format value. Values of
+// the form "exec:command args..." select an external renderer, and
+// "template:file", set by -template, a text/template.
+func getFormatter(name string, opts *outputOptions) (formatter, error) {
+	if file, ok := strings.CutPrefix(name, "template:"); ok {
+		return templateFormatter(file)
+	}
+	if strings.HasPrefix(name, "exec:") {
+		args := strings.Fields(strings.TrimPrefix(name, "exec:"))
+		if len(args) == 0 {
+			return nil, fmt.Errorf("missing command in format %q", name)
+		}
+		return execFormatter(args), nil
+	}
+	if newFormatter, ok := formatters[name]; ok {
+		return newFormatter(opts), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", name)
+}
+
+func jsonFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		doc, err := opts.document(pkg)
+		if err != nil {
+			return err
+		}
+		return writeJSON(w, doc, opts)
+	}
+}
+
+// line returns the options of JSON documents written on a single line.
+func (opts *outputOptions) line() *outputOptions {
+	return &outputOptions{Canonical: opts.Canonical, Fields: opts.Fields, OmitEmpty: opts.OmitEmpty, SchemaV2: opts.SchemaV2}
+}
+
+// writeJSON writes v to w as JSON followed by a newline, indented or in
+// canonical form as requested by opts.
+func writeJSON(w io.Writer, v interface{}, opts *outputOptions) error {
+	var vJSON []byte
+	var err error
+	if opts.Canonical {
+		vJSON, err = marshalCanonical(v)
+	} else if opts.Indent == "" {
+		vJSON, err = json.Marshal(v)
+	} else {
+		vJSON, err = json.MarshalIndent(v, "", opts.Indent)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %s", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", vJSON)
+	return err
+}
+
+// execFormatter pipes the JSON document of every package to a new process
+// started from args and copies the process output to w. The package import
+// path and schema version are passed in the GODOCJSON_IMPORT_PATH and
+// GODOCJSON_SCHEMA_VERSION environment variables.
+func execFormatter(args []string) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		pkgJSON, err := json.Marshal(pkg)
+		if err != nil {
+			return fmt.Errorf("failed to encode JSON: %s", err)
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = strings.NewReader(string(pkgJSON))
+		cmd.Stdout = w
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(),
+			"GODOCJSON_IMPORT_PATH="+pkg.ImportPath,
+			"GODOCJSON_SCHEMA_VERSION="+pkg.SchemaVersion,
+		)
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("renderer %s failed for %s: %s", args[0], pkg.ImportPath, err)
+		}
+		return nil
+	}
+}
+
+// outputTarget writes documented packages to stdout, a file or a
+// directory.
+type outputTarget struct {
+	Path      string // file or directory to write to; empty for stdout
+	Dir       bool   // whether Path is a directory receiving one file per package and page
+	Format    string // name of the output format
+	Write     formatter
+	Template  *template.Template // names the file of each package in Dir, relative to Path; nil for outputFileName
+	Workspace *Workspace         // listed by the index file, if set
+
+	files    []*OutputFile // files written to Dir since the last reset
+	packages int           // packages written since the last reset
+}
+
+// OutputFile is a file written to the output directory, as listed by its
+// index file.
+type OutputFile struct {
+	Path       string `json:"path"` // relative to the output directory, with forward slashes
+	ImportPath string `json:"importPath"`
+	Name       string `json:"name"`
+	Page       string `json:"page,omitempty"`
+	Synopsis   string `json:"synopsis,omitempty"`
+	Module     string `json:"module,omitempty"` // path of the module containing the package
+}
+
+// OutputIndex lists the files written to the output directory.
+type OutputIndex struct {
+	SchemaVersion string        `json:"schemaVersion"`
+	Workspace     *Workspace    `json:"workspace,omitempty"` // workspace of a dir/... pattern, see FindWorkspace
+	Files         []*OutputFile `json:"files"`
+}
+
+// WritePackage writes pkg to the target.
+func (o *outputTarget) WritePackage(pkg *extract.Package) error {
+	if o.Format == "man" && pkg.Name != "main" {
+		// Only commands have man pages; don't write empty files.
+		warnf("%s is not a command, no man page written", extract.PackagePath(pkg))
+		return nil
+	}
+	o.packages++
+	switch {
+	case o.Path == "":
+		return o.Write(os.Stdout, pkg)
+	case !o.Dir:
+		return extract.WriteFileAtomic(o.Path, func(w io.Writer) error {
+			return o.Write(w, pkg)
+		})
+	}
+	fileName, err := o.fileName(pkg)
+	if err != nil {
+		return err
+	}
+	files, err := writePartitions(o.Path, fileName, pkg, o.Format, o.Write)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		for _, written := range o.files {
+			if written.Path == f.Path {
+				return fmt.Errorf("%s and %s are both written to %s", written.ImportPath, f.ImportPath, f.Path)
+			}
+		}
+	}
+	o.files = append(o.files, files...)
+	return nil
+}
+
+// fileName returns the name of the file holding pkg in the output
+// directory, relative to it.
+func (o *outputTarget) fileName(pkg *extract.Package) (string, error) {
+	if o.Template == nil {
+		return outputFileName(pkg, o.Format), nil
+	}
+	var b strings.Builder
+	if err := o.Template.Execute(&b, pkg); err != nil {
+		return "", err
+	}
+	// Import paths outside of modules may be absolute.
+	name := strings.TrimLeft(filepath.Clean(filepath.FromSlash(b.String())), string(filepath.Separator))
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("output file %q of %s is outside of %s", b.String(), pkg.ImportPath, o.Path)
+	}
+	return name, nil
+}
+
+// reset forgets the files written so far.
+func (o *outputTarget) reset() {
+	o.files = nil
+	o.packages = 0
+}
+
+// Packages returns the number of packages written since the last reset.
+func (o *outputTarget) Packages() int {
+	return o.packages
+}
+
+// writeIndex writes the list of files written to the output directory since
+// the last reset to the file name in it.
+func (o *outputTarget) writeIndex(name string, opts *outputOptions) error {
+	index := &OutputIndex{SchemaVersion: extract.SchemaVersion, Workspace: o.Workspace, Files: o.files}
+	if index.Files == nil {
+		index.Files = []*OutputFile{}
+	}
+	for _, f := range index.Files {
+		if f.Path == filepath.ToSlash(name) {
+			return fmt.Errorf("index file %s overwrites the output of %s", name, f.ImportPath)
+		}
+	}
+	return extract.WriteFileAtomic(filepath.Join(o.Path, name), func(w io.Writer) error {
+		return writeJSON(w, index, opts)
+	})
+}
+
+// outputFileName returns the name of the file holding pkg when writing one
+// file per package, derived from its import path.
+func outputFileName(pkg *extract.Package, format string) string {
+	name := filepath.ToSlash(filepath.Clean(pkg.ImportPath))
+	name = strings.TrimLeft(name, "./")
+	name = strings.Replace(name, "/", "_", -1)
+	if name == "" {
+		name = pkg.Name
+	}
+	return name + formatExtension(format)
+}
+
+// formatExtension returns the file extension of the given format.
+func formatExtension(format string) string {
+	if ext, ok := formatExtensions[format]; ok {
+		return ext
+	}
+	if file, ok := strings.CutPrefix(format, "template:"); ok {
+		return templateExtension(file)
+	}
+	return ".out"
+}
+
+// isOutputDir reports whether the -o value names a directory, either
+// because it exists as one or because it ends with a path separator.
+func isOutputDir(name string) bool {
+	if strings.HasSuffix(name, "/") || strings.HasSuffix(name, string(filepath.Separator)) {
+		return true
+	}
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
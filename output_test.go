@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// writeTestPackage writes the package p and its external test package to
+// a new directory, returned.
+func writeTestPackage(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"p.go":      "package p\n\n// F does nothing.\nfunc F() {}\n",
+		"p_test.go": "package p_test\n\nimport \"testing\"\n\nfunc TestF(t *testing.T) {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestWriteExternalTestsToFile(t *testing.T) {
+	dir := writeTestPackage(t)
+	output := filepath.Join(t.TempDir(), "out.json")
+	out := &outputTarget{Path: output, Format: "json", Write: jsonFormatter(&outputOptions{})}
+	err := documentDirectories([]string{dir}, nil, extract.Options{}, out, 1, nil, nil)
+	var usageErr *usageError
+	if !errors.As(err, &usageErr) {
+		t.Fatalf("got error %v, want a usage error", err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("%s was written", output)
+	}
+
+	// Written to a directory, the packages have their own files.
+	outDir := t.TempDir()
+	out = &outputTarget{Path: outDir, Dir: true, Format: "json", Write: jsonFormatter(&outputOptions{})}
+	if err := documentDirectories([]string{dir}, nil, extract.Options{}, out, 1, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.files) != 2 {
+		t.Errorf("got files %+v, want 2", out.files)
+	}
+}
+
+func TestExternalTestImportPath(t *testing.T) {
+	dir := writeTestPackage(t)
+	t.Chdir(dir)
+	pkgs, err := extract.ParseDirectoryPackages(".", extract.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	abs, _ := filepath.Abs(".")
+	want := filepath.ToSlash(abs) + "_test"
+	if len(pkgs) != 2 || pkgs[1].ImportPath != want {
+		t.Errorf("got packages %+v, want the external test package %q", pkgs, want)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// PartitionPackage splits pkg by the pages assigned to its declarations.
+// The package under the "" key holds the declarations without a page; the
+// others hold only the declarations of their page. Declarations associated
+// with a type stay with the type.
+func PartitionPackage(pkg *extract.Package) map[string]*extract.Package {
+	pages := map[string]*extract.Package{}
+	page := func(name string) *extract.Package {
+		p, ok := pages[name]
+		if !ok {
+			copied := *pkg
+			copied.Consts, copied.Types, copied.Vars, copied.Funcs = []*extract.Value{}, []*extract.Type{}, []*extract.Value{}, []*extract.Func{}
+			p = &copied
+			pages[name] = p
+		}
+		return p
+	}
+	page("")
+	for _, c := range pkg.Consts {
+		p := page(c.Page)
+		p.Consts = append(p.Consts, c)
+	}
+	for _, t := range pkg.Types {
+		p := page(t.Page)
+		p.Types = append(p.Types, t)
+	}
+	for _, v := range pkg.Vars {
+		p := page(v.Page)
+		p.Vars = append(p.Vars, v)
+	}
+	for _, f := range pkg.Funcs {
+		p := page(f.Page)
+		p.Funcs = append(p.Funcs, f)
+	}
+	return pages
+}
+
+var unsafePageChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// writePartitions writes one file per page of pkg to directory, naming the
+// file of the page "" fileName and inserting the page name before the
+// extension of the others. It returns the files written.
+func writePartitions(directory, fileName string, pkg *extract.Package, format string, writePackage formatter) ([]*OutputFile, error) {
+	pages := PartitionPackage(pkg)
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var files []*OutputFile
+	for _, name := range names {
+		pageFileName := fileName
+		if name != "" {
+			ext := formatExtension(format)
+			if !strings.HasSuffix(fileName, ext) {
+				ext = filepath.Ext(fileName)
+			}
+			pageFileName = strings.TrimSuffix(fileName, ext) + "." + unsafePageChars.ReplaceAllString(name, "_") + ext
+		}
+		page := pages[name]
+		path := filepath.Join(directory, pageFileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, err
+		}
+		err := extract.WriteFileAtomic(path, func(w io.Writer) error {
+			return writePackage(w, page)
+		})
+		if err != nil {
+			return nil, err
+		}
+		file := &OutputFile{
+			Path:       filepath.ToSlash(pageFileName),
+			ImportPath: pkg.ImportPath,
+			Name:       pkg.Name,
+			Page:       name,
+			Synopsis:   pkg.Synopsis,
+		}
+		if pkg.Module != nil {
+			file.Module = pkg.Module.Path
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// The Protocol Buffers encoding of the documents is derived from their Go
+// types, like their JSON Schema: each struct type is a message whose fields
+// are listed in the order of the JSON keys, with the numbers pinned by
+// protoFieldNumbers, and maps and values that proto3 cannot represent, such
+// as lists of lists, are JSON-encoded strings.
+
+// protoFieldNumber returns the number of the field name, a JSON key, of the
+// message of the struct type t. Fields without a number in
+// protoFieldNumbers are a programming error.
+func protoFieldNumber(t reflect.Type, name string) int {
+	num, ok := protoFieldNumbers[t.Name()][name]
+	if !ok {
+		panic(fmt.Sprintf("field %s.%s has no number in protoFieldNumbers", t.Name(), name))
+	}
+	return num
+}
+
+// protoSchema returns the .proto definition of the documents and of the
+// Extractor service of the grpc-server subcommand.
+func protoSchema() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// godocjson documents, schema version %s.\n", extract.SchemaVersion)
+	b.WriteString(`syntax = "proto3";
+
+package godocjson;
+
+// Extractor documents the packages below the root directory of the server.
+service Extractor {
+  // Extract returns the documentation of a package, or fails with NOT_FOUND
+  // if there is no package with the requested import path.
+  rpc Extract(ExtractRequest) returns (Package);
+}
+
+message ExtractRequest {
+  // Path of the package directory relative to the root directory of the
+  // server or, when the root holds a go.mod file, full import path below
+  // its module path.
+  string import_path = 1;
+}
+`)
+	seen := map[reflect.Type]bool{}
+	queue := []reflect.Type{reflect.TypeOf(extract.Package{})}
+	seen[queue[0]] = true
+	message := func(t reflect.Type) {
+		if !seen[t] {
+			seen[t] = true
+			queue = append(queue, t)
+		}
+	}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		fmt.Fprintf(&b, "\nmessage %s {\n", t.Name())
+		for _, f := range jsonFields(t) {
+			typ, isJSON := protoType(f.typ, message)
+			if isJSON {
+				b.WriteString("  // JSON-encoded.\n")
+			}
+			fmt.Fprintf(&b, "  %s %s = %d;\n", typ, protoFieldName(f.name), protoFieldNumber(t, f.name))
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// protoFieldName returns the proto field name for a JSON key, e.g.
+// "import_path" for "importPath".
+func protoFieldName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// protoScalar returns the proto type of values of the Go type t, or "" if t
+// is not a scalar type.
+func protoScalar(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int64"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint64"
+	case reflect.Float32, reflect.Float64:
+		return "double"
+	}
+	return ""
+}
+
+// protoType returns the proto type of a field of the Go type t, with its
+// label, and reports whether the field holds JSON. It calls message for the
+// struct types it references.
+func protoType(t reflect.Type, message func(reflect.Type)) (string, bool) {
+	if s := protoScalar(t); s != "" {
+		return s, false
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if s := protoScalar(t.Elem()); s != "" {
+			return "optional " + s, false
+		}
+		return protoType(t.Elem(), message)
+	case reflect.Struct:
+		message(t)
+		return t.Name(), false
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "bytes", false
+		}
+		elem := t.Elem()
+		if elem.Kind() == reflect.Ptr && elem.Elem().Kind() == reflect.Struct {
+			elem = elem.Elem()
+		}
+		if s := protoScalar(elem); s != "" {
+			return "repeated " + s, false
+		}
+		if elem.Kind() == reflect.Struct {
+			message(elem)
+			return "repeated " + elem.Name(), false
+		}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String && protoScalar(t.Elem()) != "" {
+			return "map<string, " + protoScalar(t.Elem()) + ">", false
+		}
+	}
+	return "string", true
+}
+
+// Wire types of the Protocol Buffers encoding.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// marshalProto returns the Protocol Buffers encoding of the struct pointed
+// to by v, as described by protoSchema.
+func marshalProto(v interface{}) ([]byte, error) {
+	return appendProtoMessage(nil, reflect.ValueOf(v).Elem())
+}
+
+func appendProtoTag(b []byte, num, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(num)<<3|uint64(wireType))
+}
+
+func appendProtoBytes(b []byte, num int, data []byte) []byte {
+	b = appendProtoTag(b, num, protoBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendProtoMessage(b []byte, v reflect.Value) ([]byte, error) {
+	for _, f := range jsonFields(v.Type()) {
+		var err error
+		if b, err = appendProtoField(b, protoFieldNumber(v.Type(), f.name), v.FieldByIndex(f.index)); err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", v.Type().Name(), f.name, err)
+		}
+	}
+	return b, nil
+}
+
+// appendProtoScalar appends the scalar v, without its tag, and returns its
+// wire type.
+func appendProtoScalar(b []byte, v reflect.Value) ([]byte, int) {
+	switch v.Kind() {
+	case reflect.String:
+		b = binary.AppendUvarint(b, uint64(v.Len()))
+		return append(b, v.String()...), protoBytes
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 1), protoVarint
+		}
+		return append(b, 0), protoVarint
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendUvarint(b, uint64(v.Int())), protoVarint
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return binary.AppendUvarint(b, v.Uint()), protoVarint
+	case reflect.Float32, reflect.Float64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float())), protoFixed64
+	}
+	panic("not a scalar: " + v.Type().String())
+}
+
+// appendProtoField appends the field num holding v, omitting it if it has
+// its default value.
+func appendProtoField(b []byte, num int, v reflect.Value) ([]byte, error) {
+	t := v.Type()
+	if protoScalar(t) != "" {
+		if v.IsZero() {
+			return b, nil
+		}
+		value, wireType := appendProtoScalar(nil, v)
+		b = appendProtoTag(b, num, wireType)
+		return append(b, value...), nil
+	}
+	if _, isJSON := protoType(t, func(reflect.Type) {}); isJSON {
+		if (t.Kind() == reflect.Map || t.Kind() == reflect.Interface || t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr) && v.IsNil() {
+			return b, nil
+		}
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		return appendProtoBytes(b, num, data), nil
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return b, nil
+		}
+		if protoScalar(t.Elem()) != "" {
+			// Optional scalars are present even with their zero value.
+			value, wireType := appendProtoScalar(nil, v.Elem())
+			b = appendProtoTag(b, num, wireType)
+			return append(b, value...), nil
+		}
+		return appendProtoField(b, num, v.Elem())
+	case reflect.Struct:
+		data, err := appendProtoMessage(nil, v)
+		if err != nil {
+			return nil, err
+		}
+		return appendProtoBytes(b, num, data), nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if v.Len() == 0 {
+				return b, nil
+			}
+			return appendProtoBytes(b, num, v.Bytes()), nil
+		}
+		if k := t.Elem().Kind(); protoScalar(t.Elem()) != "" && k != reflect.String {
+			// Packed encoding of numeric values.
+			if v.Len() == 0 {
+				return b, nil
+			}
+			var packed []byte
+			for i := 0; i < v.Len(); i++ {
+				packed, _ = appendProtoScalar(packed, v.Index(i))
+			}
+			return appendProtoBytes(b, num, packed), nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem := v.Index(i)
+			if elem.Kind() == reflect.String {
+				b = appendProtoTag(b, num, protoBytes)
+				b, _ = appendProtoScalar(b, elem)
+				continue
+			}
+			if elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					// Lists cannot hold null: null elements are empty
+					// messages.
+					b = appendProtoBytes(b, num, nil)
+					continue
+				}
+				elem = elem.Elem()
+			}
+			data, err := appendProtoMessage(nil, elem)
+			if err != nil {
+				return nil, err
+			}
+			b = appendProtoBytes(b, num, data)
+		}
+		return b, nil
+	case reflect.Map:
+		// Maps are encoded as repeated entries with the key in field 1
+		// and the value in field 2, in key order for a deterministic
+		// output.
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			var entry []byte
+			entry, _ = appendProtoField(entry, 1, key)
+			entry, _ = appendProtoField(entry, 2, v.MapIndex(key))
+			b = appendProtoBytes(b, num, entry)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot encode values of type %s", t)
+}
+
+// protoReader decodes the Protocol Buffers encoding of a message.
+type protoReader struct {
+	data []byte
+}
+
+// next returns the number and wire type of the next field, and its value:
+// the integer value of varint and fixed fields, the content of
+// length-delimited ones. It returns a zero number at the end of the
+// message.
+func (r *protoReader) next() (num, wireType int, value uint64, data []byte, err error) {
+	if len(r.data) == 0 {
+		return 0, 0, 0, nil, nil
+	}
+	tag, n := binary.Uvarint(r.data)
+	if n <= 0 || tag>>3 == 0 || tag>>3 > math.MaxInt32 {
+		return 0, 0, 0, nil, fmt.Errorf("invalid field tag")
+	}
+	r.data = r.data[n:]
+	num, wireType = int(tag>>3), int(tag&7)
+	switch wireType {
+	case protoVarint:
+		value, n = binary.Uvarint(r.data)
+		if n <= 0 {
+			return 0, 0, 0, nil, fmt.Errorf("invalid varint in field %d", num)
+		}
+		r.data = r.data[n:]
+	case protoFixed64, protoFixed32:
+		size := 8
+		if wireType == protoFixed32 {
+			size = 4
+		}
+		if len(r.data) < size {
+			return 0, 0, 0, nil, fmt.Errorf("truncated field %d", num)
+		}
+		for i := size - 1; i >= 0; i-- {
+			value = value<<8 | uint64(r.data[i])
+		}
+		r.data = r.data[size:]
+	case protoBytes:
+		length, n := binary.Uvarint(r.data)
+		if n <= 0 || length > uint64(len(r.data)-n) {
+			return 0, 0, 0, nil, fmt.Errorf("truncated field %d", num)
+		}
+		data = r.data[n : n+int(length)]
+		r.data = r.data[n+int(length):]
+	default:
+		return 0, 0, 0, nil, fmt.Errorf("unsupported wire type %d in field %d", wireType, num)
+	}
+	return num, wireType, value, data, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestProtoFieldNumbersPinned(t *testing.T) {
+	seen := map[reflect.Type]bool{}
+	queue := []reflect.Type{reflect.TypeOf(extract.Package{})}
+	for len(queue) > 0 {
+		typ := queue[0]
+		queue = queue[1:]
+		if seen[typ] {
+			continue
+		}
+		seen[typ] = true
+		numbers := map[int]string{}
+		for _, f := range jsonFields(typ) {
+			protoType(f.typ, func(t reflect.Type) { queue = append(queue, t) })
+			num, ok := protoFieldNumbers[typ.Name()][f.name]
+			if !ok {
+				t.Errorf("%s.%s has no number in protoFieldNumbers", typ.Name(), f.name)
+			} else if other, ok := numbers[num]; ok {
+				t.Errorf("%s.%s and %s.%s have the same number %d", typ.Name(), other, typ.Name(), f.name, num)
+			}
+			numbers[num] = f.name
+		}
+	}
+}
+
+// protoFields returns the field definitions of a .proto file by message,
+// e.g. "string name = 4;".
+func protoFields(proto string) map[string]map[string]bool {
+	messages := map[string]map[string]bool{}
+	var fields map[string]bool
+	for _, line := range strings.Split(proto, "\n") {
+		if name, ok := strings.CutPrefix(line, "message "); ok {
+			fields = map[string]bool{}
+			messages[strings.TrimSuffix(name, " {")] = fields
+		} else if fields != nil && strings.HasSuffix(line, ";") && !strings.HasPrefix(line, "  //") {
+			fields[strings.TrimSpace(line)] = true
+		}
+	}
+	return messages
+}
+
+// TestProtoFieldNumbersUnchanged checks that the fields of
+// testdata/godocjson.proto, the definition of schema version 1.37, keep
+// their number, so that clients generated from it can talk to newer
+// servers.
+func TestProtoFieldNumbersUnchanged(t *testing.T) {
+	golden, err := os.ReadFile("testdata/godocjson.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := protoFields(protoSchema())
+	for message, fields := range protoFields(string(golden)) {
+		for field := range fields {
+			if !current[message][field] {
+				t.Errorf("message %s: field %q changed", message, field)
+			}
+		}
+	}
+}
+
+// grpcRequest returns the body of a request of the Extract method.
+func grpcRequest(importPath string) []byte {
+	msg := appendProtoBytes(nil, 1, []byte(importPath))
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+func postGRPC(s *packageServer, body []byte) *http.Response {
+	r := httptest.NewRequest(http.MethodPost, grpcExtractPath, bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/grpc")
+	w := httptest.NewRecorder()
+	s.serveGRPC(w, r)
+	return w.Result()
+}
+
+func TestGRPCExtract(t *testing.T) {
+	s := newTestServer(t, "// Package p is documented.\npackage p\n")
+	resp := postGRPC(s, grpcRequest("p"))
+	if status := resp.Trailer.Get("Grpc-Status"); status != "0" {
+		t.Fatalf("got status %s: %s", status, resp.Trailer.Get("Grpc-Message"))
+	}
+	body := new(bytes.Buffer)
+	body.ReadFrom(resp.Body)
+	if body.Len() < 5 {
+		t.Fatalf("got response %q", body)
+	}
+	fields := map[int]string{}
+	r := &protoReader{data: body.Bytes()[5:]}
+	for {
+		num, _, _, data, err := r.next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if num == 0 {
+			break
+		}
+		fields[num] = string(data)
+	}
+	// Package.schemaVersion = 1, doc = 3 and name = 4.
+	if fields[1] != extract.SchemaVersion || fields[3] != "Package p is documented.\n" || fields[4] != "p" {
+		t.Errorf("got fields %q", fields)
+	}
+}
+
+func TestGRPCOversizedRequest(t *testing.T) {
+	s := newTestServer(t, "package p\n")
+	resp := postGRPC(s, grpcRequest(strings.Repeat("p", maxGRPCRequest)))
+	if status := resp.Trailer.Get("Grpc-Status"); status != "8" {
+		t.Errorf("got status %s, want 8 (RESOURCE_EXHAUSTED)", status)
+	}
+}
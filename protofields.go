@@ -0,0 +1,289 @@
+package main
+
+// protoFieldNumbers pins the field numbers of the messages of protoSchema,
+// by message and JSON key, so that clients generated from the definition
+// of a previous schema version keep decoding the fields they know. Fields
+// added to the documents take the next free number of their message;
+// numbers of removed fields are not reused.
+var protoFieldNumbers = map[string]map[string]int{
+	"Package": {
+		"schemaVersion": 1,
+		"type":          2,
+		"doc":           3,
+		"name":          4,
+		"importPath":    5,
+		"imports":       6,
+		"filenames":     7,
+		"notes":         8,
+		"bugs":          9,
+		"consts":        10,
+		"types":         11,
+		"vars":          12,
+		"funcs":         13,
+		"services":      14,
+		"errors":        15,
+		"embeds":        16,
+		"cgo":           17,
+		"cgoExports":    18,
+		"flags":         19,
+		"import":        20,
+		"metadata":      21,
+		"module":        22,
+		"license":       23,
+		"examples":      24,
+		"title":         25,
+		"synopsis":      26,
+		"frontMatter":   27,
+		"readme":        28,
+		"diagnostics":   29,
+		"trimmed":       30,
+	},
+	"Value": {
+		"packageName":       1,
+		"packageImportPath": 2,
+		"doc":               3,
+		"names":             4,
+		"type":              5,
+		"filename":          6,
+		"line":              7,
+		"offset":            8,
+		"endOffset":         9,
+		"page":              10,
+		"buildConstraints":  11,
+		"platforms":         12,
+		"directives":        13,
+		"import":            14,
+		"source":            15,
+		"instantiations":    16,
+	},
+	"Type": {
+		"packageName":       1,
+		"packageImportPath": 2,
+		"doc":               3,
+		"name":              4,
+		"type":              5,
+		"kind":              6,
+		"underlying":        7,
+		"isAlias":           8,
+		"aliasOf":           9,
+		"enum":              10,
+		"filename":          11,
+		"line":              12,
+		"offset":            13,
+		"endOffset":         14,
+		"page":              15,
+		"buildConstraints":  16,
+		"platforms":         17,
+		"directives":        18,
+		"import":            19,
+		"source":            20,
+		"examples":          21,
+		"consts":            22,
+		"vars":              23,
+		"funcs":             24,
+		"methods":           25,
+		"promotedMethods":   26,
+		"fields":            27,
+		"implements":        28,
+		"implementedBy":     29,
+		"methodSet":         30,
+		"ptrMethodSet":      31,
+		"typeParams":        32,
+		"instantiations":    33,
+	},
+	"Func": {
+		"doc":               1,
+		"name":              2,
+		"packageName":       3,
+		"packageImportPath": 4,
+		"type":              5,
+		"filename":          6,
+		"line":              7,
+		"offset":            8,
+		"endOffset":         9,
+		"parameters":        10,
+		"results":           11,
+		"signature":         12,
+		"page":              13,
+		"buildConstraints":  14,
+		"platforms":         15,
+		"directives":        16,
+		"import":            17,
+		"source":            18,
+		"body":              19,
+		"examples":          20,
+		"bodyLines":         21,
+		"recv":              22,
+		"orig":              23,
+		"level":             24,
+	},
+	"Service": {
+		"name":     1,
+		"fullName": 2,
+		"client":   3,
+		"server":   4,
+		"methods":  5,
+		"messages": 6,
+	},
+	"SentinelError": {
+		"name":     1,
+		"message":  2,
+		"doc":      3,
+		"filename": 4,
+		"line":     5,
+	},
+	"Embed": {
+		"name":     1,
+		"type":     2,
+		"patterns": 3,
+		"filename": 4,
+		"line":     5,
+	},
+	"CgoExport": {
+		"name":      1,
+		"signature": 2,
+		"doc":       3,
+		"filename":  4,
+		"line":      5,
+	},
+	"CommandFlag": {
+		"name":    1,
+		"type":    2,
+		"default": 3,
+		"usage":   4,
+	},
+	"Import": {
+		"path":      1,
+		"statement": 2,
+		"alias":     3,
+		"reason":    4,
+	},
+	"Metadata": {
+		"mode":      1,
+		"build":     2,
+		"platforms": 3,
+		"vcs":       4,
+		"tool":      5,
+	},
+	"Module": {
+		"path":      1,
+		"goVersion": 2,
+		"toolchain": 3,
+		"require":   4,
+	},
+	"License": {
+		"spdx": 1,
+		"file": 2,
+	},
+	"Example": {
+		"name":        1,
+		"symbol":      2,
+		"suffix":      3,
+		"doc":         4,
+		"code":        5,
+		"output":      6,
+		"unordered":   7,
+		"emptyOutput": 8,
+		"filename":    9,
+		"line":        10,
+	},
+	"SourceError": {
+		"file":    1,
+		"line":    2,
+		"column":  3,
+		"message": 4,
+	},
+	"Enum": {
+		"members": 1,
+	},
+	"PromotedMethod": {
+		"name":           1,
+		"signature":      2,
+		"from":           3,
+		"recvImportPath": 4,
+		"recv":           5,
+		"url":            6,
+	},
+	"Field": {
+		"name":           1,
+		"embedded":       2,
+		"type":           3,
+		"doc":            4,
+		"comment":        5,
+		"tag":            6,
+		"tags":           7,
+		"filename":       8,
+		"line":           9,
+		"instantiations": 10,
+		"channels":       11,
+	},
+	"MethodSetEntry": {
+		"name":      1,
+		"signature": 2,
+		"recv":      3,
+		"promoted":  4,
+	},
+	"FuncParam": {
+		"type":           1,
+		"name":           2,
+		"instantiations": 3,
+		"channels":       4,
+		"doc":            5,
+	},
+	"LineRange": {
+		"start": 1,
+		"end":   2,
+	},
+	"ServiceMethod": {
+		"name":            1,
+		"request":         2,
+		"response":        3,
+		"clientStreaming": 4,
+		"serverStreaming": 5,
+	},
+	"BuildConstraints": {
+		"goos":   1,
+		"goarch": 2,
+		"tags":   3,
+	},
+	"VCS": {
+		"type":   1,
+		"commit": 2,
+		"dirty":  3,
+		"tag":    4,
+		"remote": 5,
+	},
+	"BuildInfo": {
+		"version":   1,
+		"commit":    2,
+		"modified":  3,
+		"goVersion": 4,
+	},
+	"Requirement": {
+		"path":     1,
+		"version":  2,
+		"indirect": 3,
+	},
+	"EnumMember": {
+		"name":  1,
+		"value": 2,
+		"doc":   3,
+	},
+	"TypeParam": {
+		"name":       1,
+		"constraint": 2,
+	},
+	"Instantiation": {
+		"type":     1,
+		"generic":  2,
+		"package":  3,
+		"typeArgs": 4,
+	},
+	"Channel": {
+		"type":     1,
+		"dir":      2,
+		"elem":     3,
+		"elemType": 4,
+		"package":  5,
+	},
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// pythonKeywords are the names that cannot be used as attribute names in
+// Python, renamed with a trailing underscore.
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true,
+	"assert": true, "async": true, "await": true, "break": true, "class": true,
+	"continue": true, "def": true, "del": true, "elif": true, "else": true,
+	"except": true, "finally": true, "for": true, "from": true, "global": true,
+	"if": true, "import": true, "in": true, "is": true, "lambda": true,
+	"nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// pythonDefs collects the Python dataclasses of the struct types of a
+// document, in the order they are first referenced.
+type pythonDefs struct {
+	names []string
+	defs  map[string]string
+}
+
+// GetPython returns a Python module of dataclasses, with from_dict class
+// methods, deserializing the documents of type t in the given schema
+// version. Like GetSchema, it is derived from the Go types of the output.
+func GetPython(t reflect.Type, version string) string {
+	d := &pythonDefs{defs: map[string]string{}}
+	d.class(t)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Code generated by godocjson schema -lang python. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "\"\"\"godocjson package documents, schema version %s.\n\n", version)
+	fmt.Fprintf(&b, "Load a document with %s.from_dict(json.load(f)).\n\"\"\"\n\n", t.Name())
+	b.WriteString("from __future__ import annotations\n\n")
+	b.WriteString("from dataclasses import dataclass, field\n")
+	b.WriteString("from typing import Any, Dict, List, Optional\n\n")
+	fmt.Fprintf(&b, "SCHEMA_VERSION = %q\n", version)
+	for _, name := range d.names {
+		fmt.Fprintf(&b, "\n\n%s", d.defs[name])
+	}
+	return b.String()
+}
+
+// class adds the dataclass of the named struct type t to d, unless
+// already added, and returns its name.
+func (d *pythonDefs) class(t reflect.Type) string {
+	name := t.Name()
+	if _, ok := d.defs[name]; ok {
+		return name
+	}
+	// Register the name first so recursive types terminate.
+	d.names = append(d.names, name)
+	d.defs[name] = ""
+
+	var attrs, args strings.Builder
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key, _ := jsonFieldName(f)
+		if key == "" {
+			continue
+		}
+		attr := pythonName(key)
+		fmt.Fprintf(&attrs, "    %s: %s = %s\n", attr, d.annotation(f.Type), pythonDefault(f.Type))
+		value := fmt.Sprintf("data.get(%q)", key)
+		switch f.Type.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Ptr, reflect.Struct, reflect.Interface:
+		default:
+			// Fields omitted when empty take their default.
+			value = fmt.Sprintf("data.get(%q, %s)", key, pythonDefault(f.Type))
+		}
+		fmt.Fprintf(&args, "            %s=%s,\n", attr, d.decoder(f.Type, value, 0))
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "@dataclass\nclass %s:\n", name)
+	b.WriteString(attrs.String())
+	b.WriteString("\n    @classmethod\n")
+	fmt.Fprintf(&b, "    def from_dict(cls, data: Dict[str, Any]) -> %s:\n", name)
+	b.WriteString("        return cls(\n")
+	b.WriteString(args.String())
+	b.WriteString("        )\n")
+	d.defs[name] = b.String()
+	return name
+}
+
+// annotation returns the Python type annotation of the attributes holding
+// the JSON encoding of values of type t.
+func (d *pythonDefs) annotation(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "str"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Slice, reflect.Array:
+		return "List[" + d.annotation(elemType(t)) + "]"
+	case reflect.Map:
+		return "Dict[str, " + d.annotation(elemType(t)) + "]"
+	case reflect.Ptr:
+		return "Optional[" + d.annotation(t.Elem()) + "]"
+	case reflect.Struct:
+		if t.Name() != "" {
+			return d.class(t)
+		}
+		return "Dict[str, Any]"
+	}
+	return "Any"
+}
+
+// decoder returns the Python expression decoding the JSON value of
+// expression v, of type t, nested depth levels in comprehensions. Missing
+// and null lists and dicts are decoded as empty ones.
+func (d *pythonDefs) decoder(t reflect.Type, v string, depth int) string {
+	x := fmt.Sprintf("x%d", depth)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("[%s for %s in %s or []]", d.decoder(elemType(t), x, depth+1), x, v)
+	case reflect.Map:
+		return fmt.Sprintf("{k%d: %s for k%d, %s in (%s or {}).items()}", depth, d.decoder(elemType(t), x, depth+1), depth, x, v)
+	case reflect.Ptr:
+		if t.Elem().Kind() == reflect.Struct && t.Elem().Name() != "" {
+			return fmt.Sprintf("None if %s is None else %s.from_dict(%s)", v, d.class(t.Elem()), v)
+		}
+		return v
+	case reflect.Struct:
+		if t.Name() != "" {
+			return fmt.Sprintf("%s.from_dict(%s or {})", d.class(t), v)
+		}
+	}
+	return v
+}
+
+// elemType returns the element type of slices and maps of type t, without
+// pointer indirection: elements are never nil in documents.
+func elemType(t reflect.Type) reflect.Type {
+	if elem := t.Elem(); elem.Kind() == reflect.Ptr {
+		return elem.Elem()
+	}
+	return t.Elem()
+}
+
+// pythonDefault returns the default value of the attributes of type t.
+func pythonDefault(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return `""`
+	case reflect.Bool:
+		return "False"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "0"
+	case reflect.Float32, reflect.Float64:
+		return "0.0"
+	case reflect.Slice, reflect.Array:
+		return "field(default_factory=list)"
+	case reflect.Map:
+		return "field(default_factory=dict)"
+	case reflect.Struct:
+		if t.Name() != "" {
+			return "field(default_factory=lambda: " + t.Name() + ".from_dict({}))"
+		}
+		return "field(default_factory=dict)"
+	}
+	return "None"
+}
+
+// pythonName returns the snake_case Python attribute name of the JSON key
+// name, e.g. "import_path" for "importPath".
+func pythonName(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Acronyms such as "UID" are kept as one word.
+			if i > 0 && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	if pythonKeywords[b.String()] {
+		b.WriteByte('_')
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestPythonName(t *testing.T) {
+	for name, want := range map[string]string{
+		"name":          "name",
+		"importPath":    "import_path",
+		"UID":           "uid",
+		"goarchUID":     "goarch_uid",
+		"HTMLURL":       "htmlurl",
+		"HTTPServer":    "http_server",
+		"import":        "import_",
+		"from":          "from_",
+		"schemaVersion": "schema_version",
+	} {
+		if got := pythonName(name); got != want {
+			t.Errorf("pythonName(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestPythonTypes(t *testing.T) {
+	type node struct {
+		Name string `json:"name"`
+	}
+	for _, test := range []struct {
+		v                             interface{}
+		annotation, defaultValue, dec string
+	}{
+		{"", "str", `""`, "v"},
+		{false, "bool", "False", "v"},
+		{int64(0), "int", "0", "v"},
+		{0.5, "float", "0.0", "v"},
+		{[]string{}, "List[str]", "field(default_factory=list)", "[x0 for x0 in v or []]"},
+		{[]*node{}, "List[node]", "field(default_factory=list)", "[node.from_dict(x0 or {}) for x0 in v or []]"},
+		{map[string][]int{}, "Dict[str, List[int]]", "field(default_factory=dict)",
+			"{k0: [x1 for x1 in x0 or []] for k0, x0 in (v or {}).items()}"},
+		{(*node)(nil), "Optional[node]", "None", "None if v is None else node.from_dict(v)"},
+		{node{}, "node", "field(default_factory=lambda: node.from_dict({}))", "node.from_dict(v or {})"},
+		{struct{}{}, "Dict[str, Any]", "field(default_factory=dict)", "v"},
+	} {
+		typ := reflect.TypeOf(test.v)
+		d := &pythonDefs{defs: map[string]string{}}
+		if got := d.annotation(typ); got != test.annotation {
+			t.Errorf("annotation(%T) = %q, want %q", test.v, got, test.annotation)
+		}
+		if got := pythonDefault(typ); got != test.defaultValue {
+			t.Errorf("pythonDefault(%T) = %q, want %q", test.v, got, test.defaultValue)
+		}
+		if got := d.decoder(typ, "v", 0); got != test.dec {
+			t.Errorf("decoder(%T) = %q, want %q", test.v, got, test.dec)
+		}
+	}
+}
+
+func TestGetPython(t *testing.T) {
+	py := GetPython(reflect.TypeOf(extract.Package{}), extract.SchemaVersion)
+	for _, want := range []string{
+		"Load a document with Package.from_dict(json.load(f)).\n",
+		"SCHEMA_VERSION = \"" + extract.SchemaVersion + "\"\n",
+		"\n\n@dataclass\nclass Package:\n",
+		"    schema_version: str = \"\"\n",
+		"            schema_version=data.get(\"schemaVersion\", \"\"),\n",
+		"            funcs=[Func.from_dict(x0 or {}) for x0 in data.get(\"funcs\") or []],\n",
+		"\n\n@dataclass\nclass Func:\n",
+	} {
+		if !strings.Contains(py, want) {
+			t.Errorf("missing %q in:\n%s", want, py)
+		}
+	}
+	if strings.Index(py, "class Package:") > strings.Index(py, "class Func:") {
+		t.Error("Package is not defined first")
+	}
+}
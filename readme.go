@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// writeAPIMarkdown renders a concise API reference of pkg, importable as
+// importPath, as Markdown. The install instructions are left out if
+// importPath is empty.
+func writeAPIMarkdown(w io.Writer, pkg *extract.Package, importPath string) {
+	title, s := pkg.Name, pkg.Synopsis
+	if pkg.Title != "" {
+		title = pkg.Title
+	}
+	if s == "" {
+		// Documents of earlier schema versions have no synopsis.
+		s = extract.Synopsis(pkg.Doc)
+	}
+	fmt.Fprintf(w, "# %s\n\n", title)
+	if s != "" {
+		fmt.Fprintf(w, "%s\n\n", s)
+	}
+	if importPath != "" {
+		fmt.Fprintf(w, "## Install\n\n```\ngo get %s\n```\n\n", importPath)
+	}
+
+	fmt.Fprintf(w, "## Index\n\n")
+	if len(pkg.Consts) > 0 {
+		fmt.Fprintf(w, "- [Constants](#constants)\n")
+	}
+	if len(pkg.Vars) > 0 {
+		fmt.Fprintf(w, "- [Variables](#variables)\n")
+	}
+	for _, f := range pkg.Funcs {
+		fmt.Fprintf(w, "- [%s](#%s)\n", markdownCode(oneLine(f.Signature)), f.Name)
+	}
+	for _, t := range pkg.Types {
+		fmt.Fprintf(w, "- [type %s](#%s)\n", t.Name, t.Name)
+		for _, f := range t.Funcs {
+			fmt.Fprintf(w, "  - [%s](#%s)\n", markdownCode(oneLine(f.Signature)), f.Name)
+		}
+		for _, m := range t.Methods {
+			fmt.Fprintf(w, "  - [%s](#%s.%s)\n", markdownCode(oneLine(m.Signature)), t.Name, m.Name)
+		}
+	}
+	fmt.Fprintln(w)
+
+	if len(pkg.Consts) > 0 {
+		fmt.Fprintf(w, "## Constants\n\n")
+		writeValuesMarkdown(w, pkg.Consts)
+	}
+	if len(pkg.Vars) > 0 {
+		fmt.Fprintf(w, "## Variables\n\n")
+		writeValuesMarkdown(w, pkg.Vars)
+	}
+	if len(pkg.Funcs) > 0 {
+		fmt.Fprintf(w, "## Functions\n\n")
+		for _, f := range pkg.Funcs {
+			writeFuncMarkdown(w, f, f.Name)
+		}
+	}
+	if len(pkg.Types) > 0 {
+		fmt.Fprintf(w, "## Types\n\n")
+	}
+	for _, t := range pkg.Types {
+		fmt.Fprintf(w, "<a name=\"%s\"></a>\n### type %s\n\n", t.Name, t.Name)
+		if s := extract.Synopsis(t.Doc); s != "" {
+			fmt.Fprintf(w, "%s\n\n", s)
+		}
+		writeValuesMarkdown(w, t.Consts)
+		writeValuesMarkdown(w, t.Vars)
+		for _, f := range t.Funcs {
+			writeFuncMarkdown(w, f, f.Name)
+		}
+		for _, m := range t.Methods {
+			writeFuncMarkdown(w, m, t.Name+"."+m.Name)
+		}
+	}
+}
+
+func writeValuesMarkdown(w io.Writer, values []*extract.Value) {
+	for _, v := range values {
+		fmt.Fprintf(w, "- %s `%s`", v.Type, strings.Join(v.Names, "`, `"))
+		if s := extract.Synopsis(v.Doc); s != "" {
+			fmt.Fprintf(w, ": %s", s)
+		}
+		fmt.Fprintln(w)
+	}
+	if len(values) > 0 {
+		fmt.Fprintln(w)
+	}
+}
+
+func writeFuncMarkdown(w io.Writer, f *extract.Func, anchor string) {
+	fmt.Fprintf(w, "<a name=\"%s\"></a>\n#### %s\n\n```go\n%s\n```\n\n", anchor, f.Name, f.Signature)
+	if s := extract.Synopsis(f.Doc); s != "" {
+		fmt.Fprintf(w, "%s\n\n", s)
+	}
+}
+
+// oneLine joins the lines of a wrapped signature.
+func oneLine(s string) string {
+	s = strings.Replace(s, "(\n\t", "(", -1)
+	s = strings.Replace(s, ",\n)", ")", -1)
+	return strings.Replace(s, ",\n\t", ", ", -1)
+}
+
+// markdownCode formats s as inline code.
+func markdownCode(s string) string {
+	return "`" + strings.Replace(s, "`", "'", -1) + "`"
+}
+
+// runReadme implements the readme subcommand.
+func runReadme(args []string) int {
+	flags := flag.NewFlagSet("readme", flag.ExitOnError)
+	output := flags.String("o", "", "Write the Markdown to this file instead of stdout")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson readme [-o API.md] [-e pattern] directory")
+		return 2
+	}
+	directory := flags.Arg(0)
+
+	pkg, err := extract.Extract(directory, extract.Options{Filter: fileFilter})
+	if err == nil && pkg == nil {
+		err = fmt.Errorf("no Go files in %s", directory)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	write := func(w io.Writer) error {
+		importPath, _ := extract.ResolveImportPath(directory)
+		writeAPIMarkdown(w, pkg, importPath)
+		return nil
+	}
+	if *output == "" {
+		write(os.Stdout)
+	} else if err := extract.WriteFileAtomic(*output, write); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
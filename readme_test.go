@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOneLine(t *testing.T) {
+	if got, want := oneLine("func F(\n\ta int,\n\tb string,\n)"), "func F(a int, b string)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRunReadme(t *testing.T) {
+	dir := writeModule(t)
+	output := filepath.Join(t.TempDir(), "API.md")
+	if status := runReadme([]string{"-o", output, filepath.Join(dir, "p")}); status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	readme, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# p\n\nPackage p adds numbers.\n\n## Install\n\n```\ngo get example.com/m/p\n```\n",
+		"- [Constants](#constants)\n- [`func Add(a, b int) int`](#Add)\n- [type T](#T)\n  - [`func (t T) Double() int`](#T.Double)\n",
+		"- const `Max`: Max is the largest operand.\n",
+		"<a name=\"T.Double\"></a>\n#### Double\n",
+	} {
+		if !strings.Contains(string(readme), want) {
+			t.Errorf("missing %q in\n%s", want, readme)
+		}
+	}
+}
+
+func TestRunReadmeUsage(t *testing.T) {
+	silenceStderr(t)
+	dir := writeModule(t)
+	if status := runReadme([]string{filepath.Join(dir, "p"), filepath.Join(dir, "q")}); status != 2 {
+		t.Errorf("got exit status %d for two directories, want 2", status)
+	}
+	if status := runReadme([]string{t.TempDir()}); status != 1 {
+		t.Errorf("got exit status %d for a directory without Go files, want 1", status)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"go/doc/comment"
+	"io"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// rstFormatter renders every package as a reStructuredText document that
+// plain docutils or Sphinx can build without extensions: symbols are
+// sections with a label named like their Sphinx inventory entry, e.g.
+// "net/http.Client.Do", and declarations are go code blocks.
+func rstFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		r := &rstWriter{w: w, importPath: extract.PackagePath(pkg)}
+		r.writePackage(pkg)
+		return r.err
+	}
+}
+
+// rstWriter writes a package document as reStructuredText, keeping the
+// first write error.
+type rstWriter struct {
+	w          io.Writer
+	importPath string
+	err        error
+}
+
+func (r *rstWriter) printf(format string, args ...interface{}) {
+	if r.err == nil {
+		_, r.err = fmt.Fprintf(r.w, format, args...)
+	}
+}
+
+// heading writes a section title, preceded by the label of the symbol name
+// if not empty.
+func (r *rstWriter) heading(title string, underline byte, name string) {
+	if name != "" {
+		r.printf(".. _%s:\n\n", rstLabel(r.importPath, name))
+	}
+	title = rstEscape(title)
+	r.printf("%s\n%s\n\n", title, strings.Repeat(string(underline), len(title)))
+}
+
+// code writes src as a go code block.
+func (r *rstWriter) code(src string) {
+	r.literal(".. code-block:: go", src)
+}
+
+// literal writes text indented below the line introducing a literal block.
+func (r *rstWriter) literal(intro, text string) {
+	r.printf("%s\n\n", intro)
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if line == "" {
+			r.printf("\n")
+		} else {
+			r.printf("   %s\n", strings.ReplaceAll(line, "\t", "    "))
+		}
+	}
+	r.printf("\n")
+}
+
+// doc writes a doc comment as reStructuredText.
+func (r *rstWriter) doc(text string) {
+	var p comment.Parser
+	for _, block := range p.Parse(text).Content {
+		switch b := block.(type) {
+		case *comment.Paragraph:
+			r.printf("%s\n\n", rstText(b.Text))
+		case *comment.Heading:
+			r.printf("**%s**\n\n", rstText(b.Text))
+		case *comment.Code:
+			r.literal("::", b.Text)
+		case *comment.List:
+			for i, item := range b.Items {
+				bullet := "- "
+				if item.Number != "" {
+					bullet = fmt.Sprintf("%d. ", i+1)
+				}
+				indent := strings.Repeat(" ", len(bullet))
+				for _, c := range item.Content {
+					if para, ok := c.(*comment.Paragraph); ok {
+						r.printf("%s%s\n", bullet, strings.ReplaceAll(rstText(para.Text), "\n", "\n"+indent))
+						bullet = indent
+					}
+				}
+			}
+			r.printf("\n")
+		}
+	}
+}
+
+func (r *rstWriter) writePackage(pkg *extract.Package) {
+	title := "package " + pkg.Name
+	if pkg.Title != "" {
+		title = pkg.Title
+	}
+	r.printf(".. _%s:\n\n", rstLabel(r.importPath, ""))
+	title = rstEscape(title)
+	r.printf("%s\n%s\n%s\n\n", strings.Repeat("=", len(title)), title, strings.Repeat("=", len(title)))
+	if pkg.Import != nil {
+		r.code(pkg.Import.Statement)
+	}
+	r.doc(pkg.Doc)
+
+	if len(pkg.Consts) > 0 {
+		r.heading("Constants", '-', "")
+		r.values(pkg.Consts)
+	}
+	if len(pkg.Vars) > 0 {
+		r.heading("Variables", '-', "")
+		r.values(pkg.Vars)
+	}
+	if len(pkg.Funcs) > 0 {
+		r.heading("Functions", '-', "")
+		for _, f := range pkg.Funcs {
+			r.function(f, f.Name, '~')
+		}
+	}
+	if len(pkg.Types) > 0 {
+		r.heading("Types", '-', "")
+	}
+	for _, t := range pkg.Types {
+		r.heading("type "+t.Name, '~', t.Name)
+		if t.Source != "" {
+			r.code(t.Source)
+		}
+		r.doc(t.Doc)
+		r.values(t.Consts)
+		r.values(t.Vars)
+		for _, f := range t.Funcs {
+			r.function(f, f.Name, '^')
+		}
+		for _, m := range t.Methods {
+			r.function(m, t.Name+"."+m.Name, '^')
+		}
+	}
+}
+
+// values writes declarations of constants or variables, each with its
+// source if known and doc comment.
+func (r *rstWriter) values(values []*extract.Value) {
+	for _, v := range values {
+		for _, name := range v.Names {
+			r.printf(".. _%s:\n", rstLabel(r.importPath, name))
+		}
+		r.printf("\n")
+		if v.Source != "" {
+			r.code(v.Source)
+		} else {
+			r.code(v.Type + " " + strings.Join(v.Names, ", "))
+		}
+		r.doc(v.Doc)
+	}
+}
+
+func (r *rstWriter) function(f *extract.Func, name string, underline byte) {
+	title := "func " + name
+	if f.Recv != "" {
+		title = "func (" + f.Recv + ") " + f.Name
+	}
+	r.heading(title, underline, name)
+	r.code(f.Signature)
+	r.doc(f.Doc)
+	if f.Level > 0 {
+		r.printf("Promoted from the embedded type ``%s``.\n\n", strings.TrimPrefix(f.Orig, "*"))
+	}
+}
+
+// rstLabel returns the label of the symbol name of the package importPath,
+// or of the package itself if name is empty.
+func rstLabel(importPath, name string) string {
+	label := importPath
+	if name != "" {
+		label += "." + name
+	}
+	// Labels ending in a colon would end the target early.
+	return strings.ReplaceAll(label, ":", "-")
+}
+
+// rstText formats the inline text of a doc comment.
+func rstText(text []comment.Text) string {
+	var b strings.Builder
+	for _, t := range text {
+		switch t := t.(type) {
+		case comment.Plain:
+			b.WriteString(rstEscape(string(t)))
+		case comment.Italic:
+			b.WriteString("*" + rstEscape(string(t)) + "*")
+		case *comment.Link:
+			b.WriteString("`" + strings.ReplaceAll(rstText(t.Text), "`", "") + " <" + t.URL + ">`_")
+		case *comment.DocLink:
+			b.WriteString(rstText(t.Text))
+		}
+	}
+	return b.String()
+}
+
+// rstEscape escapes the characters of s starting inline markup.
+func rstEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "|", `\|`, "_", `\_`).Replace(s)
+}
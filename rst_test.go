@@ -0,0 +1,59 @@
+package main
+
+import (
+	"go/doc/comment"
+	"strings"
+	"testing"
+)
+
+func TestRstFormatter(t *testing.T) {
+	pkg := extractSource(t, schemaSource)
+	var b strings.Builder
+	if err := rstFormatter(&outputOptions{})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	path := pkg.ImportPath
+	for _, want := range []string{
+		".. _" + path + ":\n\n",
+		".. _" + path + ".Max:\n",
+		".. _" + path + ".T:\n\ntype T\n~~~~~~\n\n",
+		".. _" + path + ".F:\n\nfunc F\n^^^^^^\n\n.. code-block:: go\n\n   func F(t T) T\n",
+		"F returns t.\n\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %q in\n%s", want, b.String())
+		}
+	}
+}
+
+func TestRstLabel(t *testing.T) {
+	tests := []struct {
+		importPath, name, want string
+	}{
+		{"net/http", "", "net/http"},
+		{"net/http", "Client.Do", "net/http.Client.Do"},
+		{"example.com:8080/p", "T", "example.com-8080/p.T"},
+	}
+	for _, test := range tests {
+		if got := rstLabel(test.importPath, test.name); got != test.want {
+			t.Errorf("rstLabel(%q, %q) = %q, want %q", test.importPath, test.name, got, test.want)
+		}
+	}
+}
+
+func TestRstText(t *testing.T) {
+	tests := []struct {
+		text []comment.Text
+		want string
+	}{
+		{[]comment.Text{comment.Plain("a_b *c*")}, `a\_b \*c\*`},
+		{[]comment.Text{comment.Italic("x")}, "*x*"},
+		{[]comment.Text{&comment.Link{Text: []comment.Text{comment.Plain("Go")}, URL: "https://go.dev"}}, "`Go <https://go.dev>`_"},
+		{[]comment.Text{&comment.DocLink{Text: []comment.Text{comment.Plain("io.Reader")}}}, "io.Reader"},
+	}
+	for _, test := range tests {
+		if got := rstText(test.text); got != test.want {
+			t.Errorf("rstText(%v) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
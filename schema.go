@@ -0,0 +1,159 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// jsonSchema is a JSON Schema document or subschema.
+type jsonSchema map[string]interface{}
+
+// GetSchema returns the JSON Schema describing the documents produced by
+// godocjson. It is derived from the Go types of the output, so it always
+// matches what the current version emits.
+func GetSchema() jsonSchema {
+	return documentSchema(reflect.TypeOf(extract.Package{}), extract.SchemaVersion)
+}
+
+// GetSchemaV2 returns the JSON Schema describing the documents of the v2
+// schema, written with -schema v2.
+func GetSchemaV2() jsonSchema {
+	return documentSchema(reflect.TypeOf(extract.PackageV2{}), extract.SchemaVersionV2)
+}
+
+// documentSchema returns the JSON Schema of the documents of type t, in the
+// given schema version.
+func documentSchema(t reflect.Type, version string) jsonSchema {
+	defs := jsonSchema{}
+	root := schemaOf(t, defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["$id"] = "https://github.com/rtfd/godocjson/schema/" + version
+	root["title"] = "godocjson package document"
+	root["$defs"] = defs
+	return root
+}
+
+// schemaOf returns the schema for values of type t. Struct types are added
+// to defs and referenced by name.
+func schemaOf(t reflect.Type, defs jsonSchema) jsonSchema {
+	switch t.Kind() {
+	case reflect.String:
+		return jsonSchema{"type": "string"}
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return jsonSchema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return jsonSchema{"type": []interface{}{"array", "null"}, "items": schemaOf(t.Elem(), defs)}
+	case reflect.Map:
+		return jsonSchema{"type": []interface{}{"object", "null"}, "additionalProperties": schemaOf(t.Elem(), defs)}
+	case reflect.Ptr:
+		return jsonSchema{"anyOf": []interface{}{schemaOf(t.Elem(), defs), jsonSchema{"type": "null"}}}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, defs)
+		}
+		if _, ok := defs[t.Name()]; !ok {
+			// Register the name first so recursive types terminate.
+			defs[t.Name()] = jsonSchema{}
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return jsonSchema{"$ref": "#/$defs/" + t.Name()}
+	}
+	return jsonSchema{}
+}
+
+func structSchema(t reflect.Type, defs jsonSchema) jsonSchema {
+	properties := jsonSchema{}
+	required := []interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty := jsonFieldName(f)
+		if name == "" {
+			continue
+		}
+		properties[name] = schemaOf(f.Type, defs)
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+	return jsonSchema{"type": "object", "properties": properties, "required": required}
+}
+
+// jsonFieldName returns the JSON key of a struct field as encoding/json
+// would, or "" for fields that are not encoded.
+func jsonFieldName(f reflect.StructField) (name string, omitempty bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitempty = true
+		}
+	}
+	return name, omitempty
+}
+
+// jsonStructField is a field of a struct type as encoded in JSON, found at
+// index in the struct.
+type jsonStructField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+var jsonFieldCache sync.Map // reflect.Type to []*jsonStructField
+
+// jsonFields returns the fields of the struct type t, named like their JSON
+// keys, with the fields of embedded structs promoted like encoding/json
+// does.
+func jsonFields(t reflect.Type) []*jsonStructField {
+	if fields, ok := jsonFieldCache.Load(t); ok {
+		return fields.([]*jsonStructField)
+	}
+	var fields []*jsonStructField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for _, ef := range jsonFields(embedded) {
+					fields = append(fields, &jsonStructField{ef.name, append([]int{i}, ef.index...), ef.typ})
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, &jsonStructField{name, []int{i}, f.Type})
+	}
+	jsonFieldCache.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+const schemaSource = `// Package p is documented.
+package p
+
+// Max is the largest value.
+const Max = 10
+
+// T is a type.
+type T struct{ N int }
+
+// F returns t.
+func F(t T) T { return t }
+`
+
+// TestSchemaValidatesOutput checks that the documents written by the json
+// format match the schema printed by the schema subcommand.
+func TestSchemaValidatesOutput(t *testing.T) {
+	out, status := captureStdout(t, func() int { return runSchema(nil) })
+	if status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://github.com/rtfd/godocjson/schema/" + extract.SchemaVersion; schema["$id"] != want {
+		t.Errorf("got $id %v, want %s", schema["$id"], want)
+	}
+
+	var b strings.Builder
+	if err := jsonFormatter(&outputOptions{})(&b, extractSource(t, schemaSource)); err != nil {
+		t.Fatal(err)
+	}
+	var w strings.Builder
+	invalid, err := validateDocuments(&w, "p.json", strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invalid != 0 {
+		t.Errorf("the document is invalid:\n%s", w.String())
+	}
+}
+
+// TestSchemaV2ValidatesOutput checks that the documents written with
+// -schema v2 match the schema printed by schema -schema v2, and not the v1
+// one.
+func TestSchemaV2ValidatesOutput(t *testing.T) {
+	out, status := captureStdout(t, func() int { return runSchema([]string{"-schema", "v2"}) })
+	if status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	var schema jsonSchema
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://github.com/rtfd/godocjson/schema/" + extract.SchemaVersionV2; schema["$id"] != want {
+		t.Errorf("got $id %v, want %s", schema["$id"], want)
+	}
+
+	var b strings.Builder
+	if err := jsonFormatter(&outputOptions{SchemaV2: true})(&b, extractSource(t, schemaSource)); err != nil {
+		t.Fatal(err)
+	}
+	var w strings.Builder
+	invalid, err := validateDocuments(&w, "p.json", strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if invalid != 0 {
+		t.Errorf("the document is invalid:\n%s", w.String())
+	}
+	dec := json.NewDecoder(strings.NewReader(b.String()))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if errs := Validate(GetSchema(), doc); len(errs) == 0 {
+		t.Error("the v2 document matches the v1 schema")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := GetSchema()
+	for _, test := range []struct {
+		name string
+		doc  string
+		want string // JSON pointer of the expected error, if any
+	}{
+		{"wrong type", `{"name": 1}`, "/name"},
+		{"wrong item type", `{"filenames": [1]}`, "/filenames/0"},
+		{"missing member", `{"funcs": [{}]}`, "/funcs/0"},
+		{"null", `null`, "/"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dec := json.NewDecoder(strings.NewReader(test.doc))
+			dec.UseNumber()
+			var doc interface{}
+			if err := dec.Decode(&doc); err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, err := range Validate(schema, doc) {
+				found = found || err.Path == test.want
+			}
+			if !found {
+				t.Errorf("got errors %v, want one at %s", Validate(schema, doc), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// SearchRecord is the search index entry of a documented symbol.
+type SearchRecord struct {
+	ID        string `json:"id"`      // import path and qualified name, e.g. "net/http.Client.Do"
+	Kind      string `json:"kind"`    // "package", "const", "var", "type", "func" or "method"
+	Name      string `json:"name"`    // qualified name, e.g. "Client.Do"
+	Package   string `json:"package"` // import path
+	Synopsis  string `json:"synopsis"`
+	Signature string `json:"signature,omitempty"` // declaration of funcs, and of types and values with -source
+}
+
+// BuildSearchRecords returns the search index entries of the symbols
+// documented in pkg. Values declaring several names have an entry per name.
+func BuildSearchRecords(pkg *extract.Package) []*SearchRecord {
+	importPath := extract.PackagePath(pkg)
+	records := []*SearchRecord{}
+	extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+		record := &SearchRecord{ID: importPath, Kind: entry.Kind, Name: entry.Name, Package: importPath}
+		if entry.Kind != "package" {
+			record.ID += "." + entry.Name
+		}
+		switch s := symbol.(type) {
+		case *extract.Package:
+			record.Name = s.Name
+			record.Synopsis = s.Synopsis
+			if record.Synopsis == "" {
+				record.Synopsis = extract.Synopsis(s.Doc)
+			}
+		case *extract.Type:
+			record.Synopsis, record.Signature = extract.Synopsis(s.Doc), s.Source
+		case *extract.Func:
+			record.Synopsis, record.Signature = extract.Synopsis(s.Doc), oneLine(s.Signature)
+		case *extract.Value:
+			record.Synopsis, record.Signature = extract.Synopsis(s.Doc), s.Source
+		}
+		records = append(records, record)
+	})
+	return records
+}
+
+// lunrFormatter writes the search records of every package as a JSON array
+// of documents, to be added to a lunr.js index.
+func lunrFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		return writeJSON(w, BuildSearchRecords(pkg), opts)
+	}
+}
+
+// esBulkFormatter writes the search records of every package in the
+// newline-delimited format of the Elasticsearch bulk API: an index action,
+// identified by the record ID, followed by the record.
+func esBulkFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		lineOpts := opts.line()
+		for _, record := range BuildSearchRecords(pkg) {
+			action := map[string]interface{}{"index": map[string]string{"_id": record.ID}}
+			if err := writeJSON(w, action, lineOpts); err != nil {
+				return err
+			}
+			if err := writeJSON(w, record, lineOpts); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
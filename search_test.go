@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const searchSource = `// Package p is documented.
+package p
+
+// A and B are values.
+var A, B int
+
+// T is a type.
+type T int
+
+// M is a method.
+func (T) M() {}
+`
+
+func TestBuildSearchRecords(t *testing.T) {
+	pkg := extractSource(t, searchSource)
+	path := pkg.ImportPath
+	want := []SearchRecord{
+		{path, "package", "p", path, "Package p is documented.", ""},
+		{path + ".A", "var", "A", path, "A and B are values.", ""},
+		{path + ".B", "var", "B", path, "A and B are values.", ""},
+		{path + ".T", "type", "T", path, "T is a type.", ""},
+		{path + ".T.M", "method", "T.M", path, "M is a method.", "func (T) M()"},
+	}
+	records := BuildSearchRecords(pkg)
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i, record := range records {
+		if *record != want[i] {
+			t.Errorf("got record %+v, want %+v", *record, want[i])
+		}
+	}
+}
+
+func TestEsBulkFormatter(t *testing.T) {
+	pkg := extractSource(t, searchSource)
+	var b strings.Builder
+	if err := esBulkFormatter(&outputOptions{})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("got %d lines, want an action and a record for each of 5 symbols:\n%s", len(lines), b.String())
+	}
+	if want := `{"index":{"_id":"` + pkg.ImportPath + `.T.M"}}`; lines[8] != want {
+		t.Errorf("got action %s, want %s", lines[8], want)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// packageServer serves the documentation of the packages below a root
+// directory, parsing them on first request and caching the result until one
+// of their files changes.
+type packageServer struct {
+	root       string
+	modulePath string // module path declared in root/go.mod, if any
+	opts       extract.Options
+
+	mu    sync.Mutex
+	cache map[string]*servedPackage
+}
+
+type servedPackage struct {
+	pkg     *extract.Package
+	json    []byte
+	modTime map[string]time.Time // modification time of every .go file when parsed
+}
+
+// directory returns the directory of the package with the given import
+// path, which is either relative to the root or below the module path of
+// the root.
+func (s *packageServer) directory(importPath string) string {
+	if s.modulePath != "" {
+		if importPath == s.modulePath {
+			importPath = ""
+		} else if strings.HasPrefix(importPath, s.modulePath+"/") {
+			importPath = strings.TrimPrefix(importPath, s.modulePath+"/")
+		}
+	}
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+importPath)))
+}
+
+// goFileTimes returns the modification times of the .go files in directory.
+func goFileTimes(directory string) (map[string]time.Time, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	times := map[string]time.Time{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		times[entry.Name()] = info.ModTime()
+	}
+	return times, nil
+}
+
+func sameTimes(a, b map[string]time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, t := range a {
+		if !b[name].Equal(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// servedPackage returns the package with the given import path, parsing it
+// if it is not cached or has changed, or nil if there is no such package.
+func (s *packageServer) servedPackage(importPath string) (*servedPackage, error) {
+	directory := s.directory(importPath)
+	times, err := goFileTimes(directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[importPath]
+	s.mu.Unlock()
+	if ok && sameTimes(cached.modTime, times) {
+		return cached, nil
+	}
+	// Packages are parsed without holding the lock, so that requests for
+	// different packages are served concurrently.
+	pkg, err := extract.Extract(directory, s.opts)
+	if err != nil || pkg == nil {
+		return nil, err
+	}
+	pkgJSON, err := json.Marshal(pkg)
+	if err != nil {
+		return nil, err
+	}
+	served := &servedPackage{pkg: pkg, json: pkgJSON, modTime: times}
+	s.mu.Lock()
+	s.cache[importPath] = served
+	s.mu.Unlock()
+	return served, nil
+}
+
+// packageJSON returns the JSON document of the package with the given
+// import path, or nil if there is no such package.
+func (s *packageServer) packageJSON(importPath string) ([]byte, error) {
+	served, err := s.servedPackage(importPath)
+	if err != nil || served == nil {
+		return nil, err
+	}
+	return served.json, nil
+}
+
+// lookupPackage returns the package with the given import path, or nil if
+// there is no such package. The package is shared by all requests and
+// must not be modified.
+func (s *packageServer) lookupPackage(importPath string) (*extract.Package, error) {
+	served, err := s.servedPackage(importPath)
+	if err != nil || served == nil {
+		return nil, err
+	}
+	return served.pkg, nil
+}
+
+func (s *packageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	importPath := strings.TrimPrefix(r.URL.Path, "/pkg/")
+	if importPath == r.URL.Path || !strings.HasSuffix(importPath, ".json") {
+		http.NotFound(w, r)
+		return
+	}
+	importPath = strings.TrimSuffix(importPath, ".json")
+	pkgJSON, err := s.packageJSON(importPath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if pkgJSON == nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(pkgJSON)
+}
+
+// maxGraphQLRequest is the size limit of the body of GraphQL POST requests.
+const maxGraphQLRequest = 1 << 20
+
+// serveGraphQL answers GraphQL queries on the packages of s, given by the
+// query, variables and operationName parameters of GET requests or as a
+// JSON object in the body of POST requests.
+func (s *packageServer) serveGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req gqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "invalid variables: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		body := http.MaxBytesReader(w, r.Body, maxGraphQLRequest)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, fmt.Sprintf("request larger than %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "missing query", http.StatusBadRequest)
+		return
+	}
+	resp := executeGraphQL(&req, s.lookupPackage)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// runServe implements the serve subcommand.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	root := flags.String("root", ".", "Directory containing the packages to serve")
+	addr := flags.String("addr", "localhost:8080", "Address to listen on")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	var opts extract.Options
+	flags.BoolVar(&opts.ResolveEmbedded, "resolve-embedded", false, "List methods promoted from embedded types of other packages")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	opts.Filter = fileFilter
+
+	s := &packageServer{
+		root:       *root,
+		modulePath: extract.ModulePath(*root),
+		opts:       opts,
+		cache:      map[string]*servedPackage{},
+	}
+	http.Handle("/pkg/", s)
+	http.HandleFunc("/graphql", s.serveGraphQL)
+	http.HandleFunc("/graphql/schema", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, graphQLSchema())
+	})
+	log.Printf("Serving packages of %s on http://%s/pkg/ and http://%s/graphql", *root, *addr, *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
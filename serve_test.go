@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestServePackages(t *testing.T) {
+	root := writeModule(t)
+	s := &packageServer{root: root, modulePath: extract.ModulePath(root), cache: map[string]*servedPackage{}}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	funcs := func(w *httptest.ResponseRecorder) []string {
+		var pkg extract.Package
+		if err := json.Unmarshal(w.Body.Bytes(), &pkg); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range pkg.Funcs {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	// Packages are named by their import path or their directory.
+	for _, path := range []string{"/pkg/example.com/m/q.json", "/pkg/q.json"} {
+		w := get(path)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("GET %s: got status %d, type %q", path, w.Code, w.Header().Get("Content-Type"))
+		}
+		if names := funcs(w); len(names) != 1 || names[0] != "Sum" {
+			t.Errorf("GET %s: got funcs %q, want Sum", path, names)
+		}
+	}
+	for _, path := range []string{"/pkg/example.com/m/missing.json", "/pkg/q", "/q.json"} {
+		if w := get(path); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s: got status %d, want 404", path, w.Code)
+		}
+	}
+
+	// A changed package is parsed again.
+	filename := filepath.Join(root, "q", "q.go")
+	if err := os.WriteFile(filename, []byte("package q\n\nfunc Diff() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filename, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if names := funcs(get("/pkg/q.json")); len(names) != 1 || names[0] != "Diff" {
+		t.Errorf("got funcs %q after the change, want Diff", names)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// sphinxRoles maps index entry kinds to the roles of the go domain in Sphinx
+// inventories.
+var sphinxRoles = map[string]string{
+	"package": "go:package",
+	"const":   "go:const",
+	"var":     "go:var",
+	"type":    "go:type",
+	"func":    "go:func",
+	"method":  "go:method",
+}
+
+// sphinxInventoryFormatter writes a package as a Sphinx inventory
+// (objects.inv): a header naming the project, the module of the package or
+// else its import path, followed by one zlib-compressed line per symbol,
+// "name domain:role priority uri dispname". Symbols are named like the
+// labels of the rst pages, see rstLabel, e.g. "net/http.Client.Do", and
+// point to the anchor of the symbol in the page of the html site, see
+// htmlURI, e.g. "net_http.html#Client.Do".
+func sphinxInventoryFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		importPath := extract.PackagePath(pkg)
+		project := importPath
+		if pkg.Module != nil {
+			project = pkg.Module.Path
+		}
+		var body bytes.Buffer
+		extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+			var name string
+			if entry.Kind != "package" {
+				name = entry.Name
+			}
+			fmt.Fprintf(&body, "%s %s 1 %s -\n", strings.ReplaceAll(rstLabel(importPath, name), " ", "_"), sphinxRoles[entry.Kind], htmlURI(importPath, name))
+		})
+		if _, err := fmt.Fprintf(w, "# Sphinx inventory version 2\n# Project: %s\n# Version: \n# The remainder of this file is compressed using zlib.\n", project); err != nil {
+			return err
+		}
+		zw := zlib.NewWriter(w)
+		if _, err := zw.Write(body.Bytes()); err != nil {
+			return err
+		}
+		return zw.Close()
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"compress/zlib"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSphinxInventoryFormatter(t *testing.T) {
+	pkg := extractSource(t, schemaSource)
+	var b strings.Builder
+	if err := sphinxInventoryFormatter(&outputOptions{})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(strings.NewReader(b.String()))
+	for _, want := range []string{
+		"# Sphinx inventory version 2\n",
+		"# Project: " + pkg.ImportPath + "\n",
+		"# Version: \n",
+		"# The remainder of this file is compressed using zlib.\n",
+	} {
+		if line, err := r.ReadString('\n'); line != want {
+			t.Fatalf("header line = %q (%v), want %q", line, err, want)
+		}
+	}
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := pkg.ImportPath
+	page := htmlFileName(path)
+	for _, want := range []string{
+		path + " go:package 1 " + page + " -\n",
+		path + ".Max go:const 1 " + page + "#Max -\n",
+		path + ".T go:type 1 " + page + "#T -\n",
+		path + ".F go:func 1 " + page + "#F -\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+}
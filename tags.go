@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// Tag is an entry of the tags file written by the tags subcommand.
+type Tag struct {
+	Name     string // identifier, e.g. "Do" for the method Client.Do
+	Filename string // as documented, relative to the working directory
+	Line     int
+	Kind     byte   // 'c' const, 'v' var, 'f' func or method, 't' type, 'm' struct field
+	Scope    string // type of methods and fields, e.g. "Client"
+}
+
+// ctagsKinds maps the kinds of index entries to the kind letters of
+// Exuberant Ctags for Go.
+var ctagsKinds = map[string]byte{
+	"const":  'c',
+	"var":    'v',
+	"func":   'f',
+	"method": 'f',
+	"type":   't',
+}
+
+// collectTags returns the tags of the symbols documented in pkgs, and of
+// the exported fields of their struct types, sorted by name, file and line.
+// Methods promoted from embedded types are left out: their declaration is
+// tagged with the embedded type. Constants and variables are tagged on the
+// line of their spec, read from their file, rather than on the line of the
+// keyword of their declaration.
+func collectTags(pkgs []*extract.Package) []*Tag {
+	var tags []*Tag
+	sources := map[string][]byte{}
+	specLines := map[*extract.Value]map[string]int{}
+	for _, pkg := range pkgs {
+		extract.WalkSymbols(pkg, func(entry *extract.IndexEntry, symbol interface{}) {
+			kind, ok := ctagsKinds[entry.Kind]
+			if !ok || entry.Filename == "" {
+				return
+			}
+			if f, ok := symbol.(*extract.Func); ok && f.Level > 0 {
+				return
+			}
+			tag := &Tag{Name: entry.Name, Filename: entry.Filename, Line: entry.Line, Kind: kind}
+			if v, ok := symbol.(*extract.Value); ok {
+				lines, ok := specLines[v]
+				if !ok {
+					lines = valueSpecLines(v, sources)
+					specLines[v] = lines
+				}
+				if line, ok := lines[entry.Name]; ok {
+					tag.Line = line
+				}
+			}
+			if i := strings.Index(entry.Name, "."); i >= 0 {
+				tag.Scope, tag.Name = entry.Name[:i], entry.Name[i+1:]
+			}
+			tags = append(tags, tag)
+			if t, ok := symbol.(*extract.Type); ok {
+				for _, field := range t.Fields {
+					tags = append(tags, &Tag{Name: field.Name, Filename: field.Filename, Line: field.Line, Kind: 'm', Scope: t.Name})
+				}
+			}
+		})
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Line < b.Line
+	})
+	return tags
+}
+
+// valueSpecLines returns the line of each name declared by v, parsed from
+// the source of its declaration, or nil if the file cannot be read. Files
+// are read once, through sources.
+func valueSpecLines(v *extract.Value, sources map[string][]byte) map[string]int {
+	src, ok := sources[v.Filename]
+	if !ok {
+		src, _ = os.ReadFile(v.Filename)
+		sources[v.Filename] = src
+	}
+	if v.Offset < 0 || v.Offset >= v.EndOffset || v.EndOffset > len(src) {
+		return nil
+	}
+	// The declaration starts on the second line, after the package clause.
+	fileSet := token.NewFileSet()
+	file, err := parser.ParseFile(fileSet, "", "package p\n"+string(src[v.Offset:v.EndOffset]), 0)
+	if err != nil || len(file.Decls) != 1 {
+		return nil
+	}
+	decl, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok {
+		return nil
+	}
+	lines := map[string]int{}
+	for _, spec := range decl.Specs {
+		for _, name := range spec.(*ast.ValueSpec).Names {
+			lines[name.Name] = v.Line + fileSet.Position(name.Pos()).Line - 2
+		}
+	}
+	return lines
+}
+
+// tagPath returns the path of filename relative to dir, the directory of
+// the tags file, with forward slashes, or its absolute path if it is not
+// below dir.
+func tagPath(dir, filename string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	if rel, err := filepath.Rel(dir, abs); err == nil && filepath.IsLocal(rel) {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(abs)
+}
+
+// writeCtags writes tags in the extended format of Exuberant Ctags, with
+// line numbers as addresses and the type of methods and fields as scope.
+func writeCtags(w io.Writer, dir string, tags []*Tag) error {
+	var b strings.Builder
+	b.WriteString("!_TAG_FILE_FORMAT\t2\t/extended format; --format=1 will not append ;\" to lines/\n")
+	b.WriteString("!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted, 2=foldcase/\n")
+	b.WriteString("!_TAG_PROGRAM_NAME\tgodocjson\t//\n")
+	for _, tag := range tags {
+		fmt.Fprintf(&b, "%s\t%s\t%d;\"\t%c", tag.Name, tagPath(dir, tag.Filename), tag.Line, tag.Kind)
+		if tag.Scope != "" {
+			fmt.Fprintf(&b, "\ttype:%s", tag.Scope)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeEtags writes tags in the format of Emacs etags: a section per file,
+// in file name order, whose entries hold the start of the tagged line, up
+// to the name, and its line number and byte offset. Files are read to find
+// the lines.
+func writeEtags(w io.Writer, dir string, tags []*Tag) error {
+	byFile := map[string][]*Tag{}
+	var filenames []string
+	for _, tag := range tags {
+		if byFile[tag.Filename] == nil {
+			filenames = append(filenames, tag.Filename)
+		}
+		byFile[tag.Filename] = append(byFile[tag.Filename], tag)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		lines := bytes.SplitAfter(src, []byte("\n"))
+		fileTags := byFile[filename]
+		sort.SliceStable(fileTags, func(i, j int) bool { return fileTags[i].Line < fileTags[j].Line })
+		var section bytes.Buffer
+		for _, tag := range fileTags {
+			if tag.Line < 1 || tag.Line > len(lines) {
+				continue
+			}
+			offset := 0
+			for _, line := range lines[:tag.Line-1] {
+				offset += len(line)
+			}
+			text := strings.TrimRight(string(lines[tag.Line-1]), "\r\n")
+			if i := strings.Index(text, tag.Name); i >= 0 {
+				text = text[:i+len(tag.Name)]
+			}
+			fmt.Fprintf(&section, "%s\x7f%s\x01%d,%d\n", text, tag.Name, tag.Line, offset)
+		}
+		if _, err := fmt.Fprintf(w, "\x0c\n%s,%d\n%s", tagPath(dir, filename), section.Len(), section.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runTags implements the tags subcommand.
+func runTags(args []string) int {
+	flags := flag.NewFlagSet("tags", flag.ExitOnError)
+	output := flags.String("o", "", "Tags file to write; tags for ctags, TAGS for etags")
+	format := flags.String("format", "ctags", "Format of the tags file: ctags or etags")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() == 0 || (*format != "ctags" && *format != "etags") {
+		fmt.Fprintln(os.Stderr, "usage: godocjson tags [-o file] [-format ctags|etags] [-e pattern] directory...")
+		return 2
+	}
+	if *output == "" {
+		*output = "tags"
+		if *format == "etags" {
+			*output = "TAGS"
+		}
+	}
+
+	directories, err := ExpandDirectories(flags.Args(), WalkRules{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	opts := extract.Options{Filter: fileFilter, ExcludeTests: true}
+	var pkgs []*extract.Package
+	for _, directory := range directories {
+		pkg, err := extract.Extract(directory, opts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if pkg != nil {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	dir, err := filepath.Abs(filepath.Dir(*output))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	tags := collectTags(pkgs)
+	err = extract.WriteFileAtomic(*output, func(w io.Writer) error {
+		if *format == "etags" {
+			return writeEtags(w, dir, tags)
+		}
+		return writeCtags(w, dir, tags)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+const tagsSource = `package p
+
+// Colors.
+const (
+	Red = iota
+	Green
+
+	Blue, Cyan = 2, 3
+)
+
+// V is a variable.
+var V int
+
+// T is a type.
+type T struct {
+	Field int
+}
+`
+
+func TestCollectTags(t *testing.T) {
+	pkg := extractSource(t, tagsSource)
+	var got []string
+	for _, tag := range collectTags([]*extract.Package{pkg}) {
+		got = append(got, fmt.Sprintf("%s %d %c %s", tag.Name, tag.Line, tag.Kind, tag.Scope))
+	}
+	want := []string{"Blue 8 c ", "Cyan 8 c ", "Field 16 m T", "Green 6 c ", "Red 5 c ", "T 15 t ", "V 12 v "}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got tags\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWriteEtags(t *testing.T) {
+	pkg := extractSource(t, tagsSource)
+	dir := filepath.Dir(pkg.Filenames[0])
+	var b strings.Builder
+	if err := writeEtags(&b, dir, collectTags([]*extract.Package{pkg})); err != nil {
+		t.Fatal(err)
+	}
+	// Entries hold their line up to the name, and its line and offset.
+	for _, entry := range []string{
+		"\tRed\x7fRed\x015,",
+		"\tGreen\x7fGreen\x016,",
+		"\tBlue\x7fBlue\x018,",
+		"\tBlue, Cyan\x7fCyan\x018,",
+	} {
+		if !strings.Contains(b.String(), entry) {
+			t.Errorf("missing entry %q in\n%s", entry, b.String())
+		}
+	}
+}
+
+func TestWriteCtags(t *testing.T) {
+	pkg := extractSource(t, tagsSource)
+	dir := filepath.Dir(pkg.Filenames[0])
+	var b strings.Builder
+	if err := writeCtags(&b, dir, collectTags([]*extract.Package{pkg})); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"Green\tp.go\t6;\"\tc\n",
+		"Field\tp.go\t16;\"\tm\ttype:T\n",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("missing line %q in\n%s", line, b.String())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// templateFuncs are the functions available to -template templates, in
+// addition to the predefined functions of text/template.
+var templateFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"trim":       strings.TrimSpace,
+	"replace":    strings.ReplaceAll,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"oneLine":    oneLine,
+	"code":       markdownCode,
+	"synopsis":   extract.Synopsis,
+	"importPath": extract.PackagePath,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"csv": func(fields ...string) (string, error) {
+		var b strings.Builder
+		w := csv.NewWriter(&b)
+		w.Write(fields)
+		w.Flush()
+		return strings.TrimSuffix(b.String(), "\n"), w.Error()
+	},
+	"xml": func(s string) (string, error) {
+		var b strings.Builder
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+}
+
+// templateFormatter renders every package through the text/template read
+// from the file name, with the *extract.Package as data.
+func templateFormatter(name string) (formatter, error) {
+	tmpl, err := template.New(filepath.Base(name)).Funcs(templateFuncs).ParseFiles(name)
+	if err != nil {
+		return nil, err
+	}
+	return func(w io.Writer, pkg *extract.Package) error {
+		return tmpl.Execute(w, pkg)
+	}, nil
+}
+
+// templateExtension returns the extension of the files rendered by the
+// template file name: that of its name without the .tmpl, .tpl or .gotmpl
+// suffix, e.g. ".md" for "reference.md.tmpl", or ".txt" if it has none.
+func templateExtension(name string) string {
+	base := filepath.Base(name)
+	for _, suffix := range []string{".tmpl", ".tpl", ".gotmpl"} {
+		base = strings.TrimSuffix(base, suffix)
+	}
+	if ext := filepath.Ext(base); ext != "" {
+		return ext
+	}
+	return ".txt"
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateExtension(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"reference.md.tmpl", ".md"},
+		{"dir/api.html.gotmpl", ".html"},
+		{"page.rst.tpl", ".rst"},
+		{"page.tmpl", ".txt"},
+		{"page", ".txt"},
+		{"index.html", ".html"},
+	}
+	for _, test := range tests {
+		if got := templateExtension(test.name); got != test.want {
+			t.Errorf("templateExtension(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestTemplateFormatter(t *testing.T) {
+	tests := []struct {
+		tmpl, want string
+	}{
+		{"{{.Name}}", "p"},
+		{"{{synopsis .Doc}}", "Package p is documented."},
+		{"{{range .Consts}}{{join .Names \",\"}}{{end}}", "Max"},
+		{"{{range .Types}}{{range .Funcs}}{{oneLine .Signature}}{{end}}{{end}}", "func F(t T) T"},
+		{"{{upper (lower \"Mixed\")}} {{replace \"a-b\" \"-\" \"+\"}} {{trim \" x \"}}", "MIXED a+b x"},
+		{"{{json .Name}} {{csv \"a,b\" \"c\"}} {{xml \"<&>\"}}", "\"p\" \"a,b\",c &lt;&amp;&gt;"},
+	}
+	pkg := extractSource(t, schemaSource)
+	dir := t.TempDir()
+	for _, test := range tests {
+		name := filepath.Join(dir, "page.txt.tmpl")
+		if err := os.WriteFile(name, []byte(test.tmpl), 0644); err != nil {
+			t.Fatal(err)
+		}
+		write, err := templateFormatter(name)
+		if err != nil {
+			t.Errorf("%s: %s", test.tmpl, err)
+			continue
+		}
+		var b strings.Builder
+		if err := write(&b, pkg); err != nil {
+			t.Errorf("%s: %s", test.tmpl, err)
+			continue
+		}
+		if b.String() != test.want {
+			t.Errorf("%s: got %q, want %q", test.tmpl, b.String(), test.want)
+		}
+	}
+	if _, err := templateFormatter(filepath.Join(dir, "missing.tmpl")); err == nil {
+		t.Error("got no error for a missing template")
+	}
+}
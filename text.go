@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/doc/comment"
+	"go/parser"
+	"go/token"
+	"io"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// textIndent indents doc comments below declarations, like go doc.
+const textIndent = "    "
+
+// textFormatter renders every package as plain text laid out like the
+// output of go doc -all: the package clause and comment, then sections of
+// constants, variables, functions and types, each declaration followed by
+// its indented doc comment. Declarations are printed from their source,
+// which main requests for this format.
+func textFormatter(opts *outputOptions) formatter {
+	return func(w io.Writer, pkg *extract.Package) error {
+		t := &textWriter{syms: map[string]bool{}, imports: map[string]string{}}
+		for _, entry := range extract.BuildIndex(pkg) {
+			t.syms[entry.Name] = true
+		}
+		for _, typ := range pkg.Types {
+			for _, f := range typ.Fields {
+				t.syms[typ.Name+"."+f.Name] = true
+			}
+			for _, name := range interfaceMethods(typ.Source) {
+				t.syms[typ.Name+"."+name] = true
+			}
+		}
+		for _, path := range pkg.Imports {
+			t.imports[path[strings.LastIndex(path, "/")+1:]] = path
+		}
+		t.writePackage(pkg)
+		_, err := w.Write(t.buf.Bytes())
+		return err
+	}
+}
+
+// textWriter builds the plain text of a package document.
+type textWriter struct {
+	buf     bytes.Buffer
+	header  string            // last section header written
+	syms    map[string]bool   // symbols of the package, e.g. "T.Method", resolving doc links
+	imports map[string]string // import paths by package name, resolving doc links
+}
+
+func (t *textWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&t.buf, format, args...)
+}
+
+// newlines makes sure that the text ends with n newlines, n being 1 or 2.
+func (t *textWriter) newlines(n int) {
+	for !bytes.HasSuffix(t.buf.Bytes(), []byte("\n\n")[:n]) {
+		t.buf.WriteByte('\n')
+	}
+}
+
+// section writes the header of a section unless it is the current one.
+func (t *textWriter) section(header string) {
+	if t.header != header {
+		t.printf("\n%s\n\n", header)
+		t.header = header
+	}
+}
+
+// doc writes a doc comment as wrapped text, prefixing its lines with
+// prefix and those of its code blocks with codePrefix.
+func (t *textWriter) doc(text, prefix, codePrefix string) {
+	p := comment.Parser{
+		LookupPackage: func(name string) (string, bool) {
+			path, ok := t.imports[name]
+			return path, ok
+		},
+		LookupSym: func(recv, name string) bool {
+			if recv != "" {
+				name = recv + "." + name
+			}
+			return t.syms[name]
+		},
+	}
+	pr := comment.Printer{TextPrefix: prefix, TextCodePrefix: codePrefix}
+	t.buf.Write(pr.Text(p.Parse(text)))
+}
+
+// decl writes a declaration followed by its indented doc comment, and a
+// blank line after the comment.
+func (t *textWriter) decl(source, doc string) {
+	t.printf("%s", source)
+	t.newlines(1)
+	if doc != "" {
+		t.doc(doc, textIndent, textIndent+textIndent)
+		t.newlines(2)
+	}
+}
+
+func (t *textWriter) writePackage(pkg *extract.Package) {
+	if pkg.Name != "main" {
+		// Like go doc, only the package clause of commands is left out.
+		t.printf("package %s // import %q\n\n", pkg.Name, extract.PackagePath(pkg))
+	}
+	t.doc(pkg.Doc, "", textIndent)
+	t.newlines(1)
+
+	for _, v := range pkg.Consts {
+		t.section("CONSTANTS")
+		t.value(v)
+	}
+	for _, v := range pkg.Vars {
+		t.section("VARIABLES")
+		t.value(v)
+	}
+	for _, f := range pkg.Funcs {
+		t.section("FUNCTIONS")
+		t.decl(f.Signature, f.Doc)
+	}
+	for _, typ := range pkg.Types {
+		t.section("TYPES")
+		source := typ.Source
+		if source == "" {
+			source = "type " + typ.Name + " " + typ.Underlying
+		}
+		t.decl(goDocFiltered(source), typ.Doc)
+		t.newlines(2)
+		for _, v := range typ.Consts {
+			t.value(v)
+		}
+		for _, v := range typ.Vars {
+			t.value(v)
+		}
+		for _, f := range append(typ.Funcs[:len(typ.Funcs):len(typ.Funcs)], typ.Methods...) {
+			t.decl(f.Signature, f.Doc)
+			if f.Doc == "" {
+				t.newlines(2)
+			}
+		}
+	}
+
+	if len(pkg.Bugs) > 0 {
+		t.printf("\n")
+		for _, bug := range pkg.Bugs {
+			t.printf("BUG: %s\n", bug)
+		}
+	}
+}
+
+// value writes a declaration of constants or variables.
+func (t *textWriter) value(v *extract.Value) {
+	source := v.Source
+	if source == "" {
+		// Without sources, name the declared values.
+		source = v.Type + " " + strings.Join(v.Names, ", ")
+	}
+	t.decl(source, v.Doc)
+}
+
+// goDocFiltered words the comments replacing the unexported fields and
+// methods of a type declaration, printed by go/printer, like go doc does:
+// unexported fields are set apart from the exported ones by a blank line.
+func goDocFiltered(source string) string {
+	lines := strings.Split(source, "\n")
+	var out []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		what, ok := strings.CutPrefix(trimmed, "// contains filtered or unexported ")
+		if !ok || (what != "fields" && what != "methods") {
+			out = append(out, line)
+			continue
+		}
+		if what == "fields" && i > 0 && !strings.HasSuffix(lines[i-1], "{") {
+			out = append(out, "")
+		}
+		out = append(out, line[:len(line)-len(trimmed)]+"// Has unexported "+what+".")
+	}
+	return strings.Join(out, "\n")
+}
+
+// interfaceMethods returns the names of the methods listed by the source
+// of an interface type declaration, or nil for other types.
+func interfaceMethods(source string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", "package p\n"+source, 0)
+	if err != nil || len(file.Decls) == 0 {
+		return nil
+	}
+	var names []string
+	for _, spec := range file.Decls[0].(*ast.GenDecl).Specs {
+		iface, ok := spec.(*ast.TypeSpec).Type.(*ast.InterfaceType)
+		if !ok {
+			continue
+		}
+		for _, method := range iface.Methods.List {
+			for _, name := range method.Names {
+				names = append(names, name.Name)
+			}
+		}
+	}
+	return names
+}
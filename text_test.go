@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestTextFormatter(t *testing.T) {
+	dir := writeModule(t)
+	pkg, err := extract.Extract(filepath.Join(dir, "p"), extract.Options{ExcludeTests: true, Source: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := textFormatter(&outputOptions{})(&b, pkg); err != nil {
+		t.Fatal(err)
+	}
+	want := `package p // import "example.com/m/p"
+
+Package p adds numbers.
+
+CONSTANTS
+
+const Max = 10
+    Max is the largest operand.
+
+
+FUNCTIONS
+
+func Add(a, b int) int
+    Add returns the sum of a and b.
+
+
+TYPES
+
+type T struct{ N int }
+    T holds a number.
+
+func (t T) Double() int
+    Double returns twice N.
+
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestGoDocFiltered(t *testing.T) {
+	for _, test := range []struct {
+		source, want string
+	}{
+		{
+			"type T struct {\n\t// contains filtered or unexported fields\n}",
+			"type T struct {\n\t// Has unexported fields.\n}",
+		},
+		{
+			"type T struct {\n\tN int\n\t// contains filtered or unexported fields\n}",
+			"type T struct {\n\tN int\n\n\t// Has unexported fields.\n}",
+		},
+		{
+			"type I interface {\n\tM()\n\t// contains filtered or unexported methods\n}",
+			"type I interface {\n\tM()\n\t// Has unexported methods.\n}",
+		},
+		{"type T int", "type T int"},
+	} {
+		if got := goDocFiltered(test.source); got != test.want {
+			t.Errorf("goDocFiltered(%q) = %q, want %q", test.source, got, test.want)
+		}
+	}
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	for _, test := range []struct {
+		source string
+		want   []string
+	}{
+		{"type I interface {\n\tM()\n\tN(int) error\n\tio.Reader\n}", []string{"M", "N"}},
+		{"type I interface{}", nil},
+		{"type T struct{ N int }", nil},
+		{"not Go", nil},
+	} {
+		if got := interfaceMethods(test.source); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("interfaceMethods(%q) = %q, want %q", test.source, got, test.want)
+		}
+	}
+}
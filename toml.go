@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// tomlBareKey matches the keys written without quotes in TOML.
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// writeTOML writes the JSON value v, an object as decoded by jsonValue, to w
+// as a TOML document. Null values, which TOML cannot represent, are
+// omitted.
+func writeTOML(w io.Writer, v map[string]interface{}) error {
+	var b strings.Builder
+	writeTOMLTable(&b, nil, v)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTOMLTable writes the members of the table at path: values first,
+// then tables and arrays of tables, each in key order.
+func writeTOMLTable(b *strings.Builder, path []string, m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if v := m[key]; v != nil && !isTOMLTable(v) && !isTOMLTableArray(v) {
+			fmt.Fprintf(b, "%s = %s\n", tomlKey(key), tomlValue(v))
+		}
+	}
+	for _, key := range keys {
+		sub := append(path[:len(path):len(path)], tomlKey(key))
+		switch v := m[key].(type) {
+		case map[string]interface{}:
+			fmt.Fprintf(b, "\n[%s]\n", strings.Join(sub, "."))
+			writeTOMLTable(b, sub, v)
+		case []interface{}:
+			if !isTOMLTableArray(v) {
+				continue
+			}
+			for _, elem := range v {
+				fmt.Fprintf(b, "\n[[%s]]\n", strings.Join(sub, "."))
+				writeTOMLTable(b, sub, elem.(map[string]interface{}))
+			}
+		}
+	}
+}
+
+// isTOMLTable reports whether v is written as a table.
+func isTOMLTable(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+// isTOMLTableArray reports whether v is a non-empty array of objects,
+// written as an array of tables.
+func isTOMLTableArray(v interface{}) bool {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return false
+	}
+	for _, elem := range list {
+		if !isTOMLTable(elem) {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlKey returns key as a bare or quoted TOML key.
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlValue returns the inline TOML value of the JSON value v.
+func tomlValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return tomlString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []interface{}:
+		elems := make([]string, 0, len(v))
+		for _, elem := range v {
+			if elem != nil {
+				elems = append(elems, tomlValue(elem))
+			}
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		members := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if v[key] != nil {
+				members = append(members, tomlKey(key)+" = "+tomlValue(v[key]))
+			}
+		}
+		return "{" + strings.Join(members, ", ") + "}"
+	}
+	return `""`
+}
+
+// tomlString returns s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
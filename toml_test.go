@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTOMLValue(t *testing.T) {
+	for _, test := range []struct {
+		v    interface{}
+		want string
+	}{
+		{"a \"b\"\n\\", `"a \"b\"\n\\"`},
+		{"\x01", `"\u0001"`},
+		{true, "true"},
+		{float64(42), "42"},
+		{1.5, "1.5"},
+		{[]interface{}{"a", nil, float64(1)}, `["a", 1]`},
+		{map[string]interface{}{"b": "x", "a b": float64(1), "c": nil}, `{"a b" = 1, b = "x"}`},
+		{nil, `""`},
+	} {
+		if got := tomlValue(test.v); got != test.want {
+			t.Errorf("tomlValue(%#v) = %s, want %s", test.v, got, test.want)
+		}
+	}
+}
+
+func TestWriteTOML(t *testing.T) {
+	var b strings.Builder
+	err := writeTOML(&b, map[string]interface{}{
+		"name":   "p",
+		"empty":  []interface{}{},
+		"none":   nil,
+		"module": map[string]interface{}{"path": "example.com/m"},
+		"funcs": []interface{}{
+			map[string]interface{}{"name": "F", "line": float64(3)},
+			map[string]interface{}{"name": "G", "params": map[string]interface{}{"x": "int"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `empty = []
+name = "p"
+
+[[funcs]]
+line = 3
+name = "F"
+
+[[funcs]]
+name = "G"
+
+[funcs.params]
+x = "int"
+
+[module]
+path = "example.com/m"
+`
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// typeScriptDefs collects the TypeScript interfaces of the struct types of
+// a document, in the order they are first referenced.
+type typeScriptDefs struct {
+	names []string
+	defs  map[string]string
+}
+
+// GetTypeScript returns TypeScript definitions, for a .d.ts file, of the
+// documents of type t in the given schema version. Like GetSchema, they
+// are derived from the Go types of the output.
+func GetTypeScript(t reflect.Type, version string) string {
+	d := &typeScriptDefs{defs: map[string]string{}}
+	d.typeOf(t)
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by godocjson schema -lang ts. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "// godocjson package documents, schema version %s.\n", version)
+	for _, name := range d.names {
+		fmt.Fprintf(&b, "\nexport interface %s %s\n", name, d.defs[name])
+	}
+	return b.String()
+}
+
+// typeOf returns the TypeScript type of the JSON encoding of values of type
+// t. Named struct types are added to d and referenced by name.
+func (d *typeScriptDefs) typeOf(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		elem := d.elemType(t.Elem())
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[] | null"
+	case reflect.Map:
+		return "{ [key: string]: " + d.elemType(t.Elem()) + " } | null"
+	case reflect.Ptr:
+		return d.typeOf(t.Elem()) + " | null"
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structType(t, "")
+		}
+		if _, ok := d.defs[t.Name()]; !ok {
+			// Register the name first so recursive types terminate.
+			d.names = append(d.names, t.Name())
+			d.defs[t.Name()] = ""
+			d.defs[t.Name()] = d.structType(t, "")
+		}
+		return t.Name()
+	}
+	return "unknown"
+}
+
+// elemType returns the TypeScript type of the elements of slices and maps
+// of element type t. Pointer elements are never nil in documents.
+func (d *typeScriptDefs) elemType(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return d.typeOf(t)
+}
+
+// structType returns the TypeScript object type of the struct type t, with
+// its lines indented by indent. Fields omitted when empty are optional.
+func (d *typeScriptDefs) structType(t reflect.Type, indent string) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty := jsonFieldName(f)
+		if name == "" {
+			continue
+		}
+		optional := ""
+		if omitempty {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, typeScriptName(name), optional, d.typeOf(f.Type))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// typeScriptName returns name as a TypeScript property name, quoted unless
+// it is an identifier.
+func typeScriptName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || i > 0 && '0' <= r && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
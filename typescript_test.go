@@ -0,0 +1,76 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+func TestTypeScriptName(t *testing.T) {
+	for name, want := range map[string]string{
+		"name":       "name",
+		"_x$1":       "_x$1",
+		"importPath": "importPath",
+		"1st":        `"1st"`,
+		"go-build":   `"go-build"`,
+	} {
+		if got := typeScriptName(name); got != want {
+			t.Errorf("typeScriptName(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestTypeScriptTypeOf(t *testing.T) {
+	type node struct {
+		Name     string  `json:"name"`
+		Children []*node `json:"children,omitempty"`
+	}
+	for _, test := range []struct {
+		v    interface{}
+		want string
+	}{
+		{"", "string"},
+		{true, "boolean"},
+		{uint8(0), "number"},
+		{1.5, "number"},
+		{[]string{}, "string[] | null"},
+		{[][]int{}, "(number[] | null)[] | null"},
+		{map[string]*node{}, "{ [key: string]: node } | null"},
+		{(*int)(nil), "number | null"},
+		{struct{}{}, "{\n}"},
+		{node{}, "node"},
+	} {
+		d := &typeScriptDefs{defs: map[string]string{}}
+		if got := d.typeOf(reflect.TypeOf(test.v)); got != test.want {
+			t.Errorf("typeOf(%T) = %q, want %q", test.v, got, test.want)
+		}
+	}
+}
+
+func TestGetTypeScript(t *testing.T) {
+	ts := GetTypeScript(reflect.TypeOf(extract.Package{}), extract.SchemaVersion)
+	for _, want := range []string{
+		"// godocjson package documents, schema version " + extract.SchemaVersion + ".\n",
+		"\nexport interface Package {\n",
+		"  schemaVersion: string;\n",
+		"  funcs: Func[] | null;\n",
+		"\nexport interface Func {\n",
+	} {
+		if !strings.Contains(ts, want) {
+			t.Errorf("missing %q in:\n%s", want, ts)
+		}
+	}
+	if strings.Index(ts, "interface Package ") > strings.Index(ts, "interface Func ") {
+		t.Error("Package is not defined first")
+	}
+
+	out, status := captureStdout(t, func() int { return runSchema([]string{"-schema", "v2", "-lang", "ts"}) })
+	if status != 0 {
+		t.Fatalf("got exit status %d", status)
+	}
+	if !strings.Contains(out, "export interface PackageV2 {\n") {
+		t.Errorf("schema -schema v2 -lang ts does not define PackageV2:\n%s", out)
+	}
+}
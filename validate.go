@@ -0,0 +1,307 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// ValidationError describes a place where a document does not match the
+// schema.
+type ValidationError struct {
+	Path    string // JSON pointer to the offending value
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks a decoded JSON value against schema. Numbers must have
+// been decoded with json.Decoder.UseNumber.
+func Validate(schema jsonSchema, value interface{}) []ValidationError {
+	v := validator{root: schema}
+	v.validate(schema, value, "")
+	return v.errors
+}
+
+type validator struct {
+	root   jsonSchema
+	errors []ValidationError
+}
+
+func (v *validator) errorf(path string, format string, args ...interface{}) {
+	if path == "" {
+		path = "/"
+	}
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) resolve(ref string) jsonSchema {
+	s := v.root
+	for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		next, ok := s[name].(jsonSchema)
+		if !ok {
+			return nil
+		}
+		s = next
+	}
+	return s
+}
+
+func (v *validator) validate(schema jsonSchema, value interface{}, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved := v.resolve(ref)
+		if resolved == nil {
+			v.errorf(path, "unresolved schema reference %s", ref)
+			return
+		}
+		v.validate(resolved, value, path)
+		return
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		var first []ValidationError
+		for i, sub := range anyOf {
+			errs := Validate(v.subschema(sub.(jsonSchema)), value)
+			if len(errs) == 0 {
+				return
+			}
+			if i == 0 {
+				first = errs
+			}
+		}
+		// Report the problems against the first alternative, which is
+		// the documented shape of the value.
+		for _, e := range first {
+			v.errorf(path+strings.TrimSuffix(e.Path, "/"), "%s", e.Message)
+		}
+		return
+	}
+	if t, ok := schema["type"]; ok && !matchesType(t, value) {
+		v.errorf(path, "expected %s, got %s", describeType(t), jsonTypeOf(value))
+		return
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(jsonSchema)
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, name := range required {
+				if _, ok := value[name.(string)]; !ok {
+					v.errorf(path, "missing required field %q", name)
+				}
+			}
+		}
+		for name, field := range value {
+			fieldPath := path + "/" + escapePointer(name)
+			if sub, ok := properties[name].(jsonSchema); ok {
+				v.validate(sub, field, fieldPath)
+			} else if sub, ok := schema["additionalProperties"].(jsonSchema); ok {
+				v.validate(sub, field, fieldPath)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(jsonSchema); ok {
+			for i, item := range value {
+				v.validate(items, item, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+}
+
+// subschema returns s with the definitions of the root schema attached, so
+// that references inside s can be resolved on their own.
+func (v *validator) subschema(s jsonSchema) jsonSchema {
+	sub := jsonSchema{"$defs": v.root["$defs"]}
+	for k, val := range s {
+		sub[k] = val
+	}
+	return sub
+}
+
+func matchesType(t interface{}, value interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return t == jsonTypeOf(value) || (t == "number" && jsonTypeOf(value) == "integer")
+	case []interface{}:
+		for _, alt := range t {
+			if matchesType(alt, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func describeType(t interface{}) string {
+	if alts, ok := t.([]interface{}); ok {
+		names := make([]string, len(alts))
+		for i, alt := range alts {
+			names[i] = fmt.Sprint(alt)
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(value.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+// withoutRequired returns schema without the required members of its
+// objects, for documents trimmed with -fields or -omitempty.
+func withoutRequired(schema jsonSchema) jsonSchema {
+	var strip func(v interface{})
+	strip = func(v interface{}) {
+		switch v := v.(type) {
+		case jsonSchema:
+			delete(v, "required")
+			for _, sub := range v {
+				strip(sub)
+			}
+		case []interface{}:
+			for _, sub := range v {
+				strip(sub)
+			}
+		}
+	}
+	strip(schema)
+	return schema
+}
+
+// validateDocuments validates every JSON document in r, reporting problems
+// to w under the given name. Documents are validated against the schema of
+// the major version of their schemaVersion, whose members are all optional
+// in documents listing the options they were trimmed with. It returns the
+// number of invalid documents.
+func validateDocuments(w io.Writer, name string, r io.Reader) (int, error) {
+	schemaV1, schemaV2 := GetSchema(), GetSchemaV2()
+	trimmedV1, trimmedV2 := withoutRequired(GetSchema()), withoutRequired(GetSchemaV2())
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	invalid := 0
+	for i := 0; ; i++ {
+		var doc interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			return invalid, nil
+		} else if err != nil {
+			return invalid, fmt.Errorf("%s: document %d: %s", name, i, err)
+		}
+		schema := schemaV1
+		if m, ok := doc.(map[string]interface{}); ok {
+			v2 := strings.HasPrefix(fmt.Sprint(m["schemaVersion"]), "2.")
+			trimmed := len(asList(m["trimmed"])) > 0
+			switch {
+			case v2 && trimmed:
+				schema = trimmedV2
+			case v2:
+				schema = schemaV2
+			case trimmed:
+				schema = trimmedV1
+			}
+		}
+		errs := Validate(schema, doc)
+		for _, e := range errs {
+			fmt.Fprintf(w, "%s: document %d: %s\n", name, i, e)
+		}
+		if len(errs) > 0 {
+			invalid++
+		}
+	}
+}
+
+// runValidate implements the validate subcommand.
+func runValidate(args []string) int {
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	status := 0
+	for _, name := range args {
+		var r io.Reader = os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				status = 1
+				continue
+			}
+			defer f.Close()
+			r = f
+		}
+		invalid, err := validateDocuments(os.Stderr, name, r)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		} else if invalid > 0 {
+			status = 1
+		}
+	}
+	return status
+}
+
+// runSchema implements the schema subcommand.
+func runSchema(args []string) int {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	version := flags.String("schema", "v1", "Schema described: v1, or v2 as written with -schema v2")
+	lang := flags.String("lang", "json", "Language of the description: json for a JSON Schema, ts for TypeScript definitions, or python for Python dataclasses")
+	flags.Parse(args)
+	var schema jsonSchema
+	var docType reflect.Type
+	var schemaVersion string
+	switch *version {
+	case "v1":
+		schema = GetSchema()
+		docType, schemaVersion = reflect.TypeOf(extract.Package{}), extract.SchemaVersion
+	case "v2":
+		schema = GetSchemaV2()
+		docType, schemaVersion = reflect.TypeOf(extract.PackageV2{}), extract.SchemaVersionV2
+	default:
+		fmt.Fprintf(os.Stderr, "unknown schema %q, expected v1 or v2\n", *version)
+		return 2
+	}
+	switch *lang {
+	case "json":
+	case "ts":
+		fmt.Print(GetTypeScript(docType, schemaVersion))
+		return 0
+	case "python":
+		fmt.Print(GetPython(docType, schemaVersion))
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown language %q, expected json, ts or python\n", *lang)
+		return 2
+	}
+	schemaJSON, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%s\n", schemaJSON)
+	return 0
+}
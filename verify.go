@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// pkgsiteKinds maps the data-kind attributes of pkg.go.dev pages to symbol
+// kinds. Struct fields and interface methods are not compared.
+var pkgsiteKinds = map[string]string{
+	"constant": "const",
+	"variable": "var",
+	"type":     "type",
+	"function": "func",
+	"method":   "method",
+}
+
+var (
+	htmlTag  = regexp.MustCompile(`<[a-zA-Z][a-zA-Z0-9]*\s[^>]*>`)
+	htmlAttr = regexp.MustCompile(`\s(id|data-kind)="([^"]*)"`)
+)
+
+// pkgsiteSymbols returns the exported symbols of a pkg.go.dev page, keyed
+// by kind and name like exportedSymbols, from the id and data-kind
+// attributes of their anchors.
+func pkgsiteSymbols(page string) map[string]bool {
+	symbols := map[string]bool{}
+	for _, tag := range htmlTag.FindAllString(page, -1) {
+		var id, kind string
+		for _, attr := range htmlAttr.FindAllStringSubmatch(tag, -1) {
+			if attr[1] == "id" {
+				id = attr[2]
+			} else {
+				kind = pkgsiteKinds[attr[2]]
+			}
+		}
+		if id != "" && kind != "" {
+			symbols[kind+" "+id] = true
+		}
+	}
+	return symbols
+}
+
+// fetchPkgsite returns the pkg.go.dev page of importPath at version, or at
+// the latest version if version is empty.
+func fetchPkgsite(baseURL, importPath, version string) (string, error) {
+	url := strings.TrimSuffix(baseURL, "/") + "/" + importPath
+	if version != "" {
+		url += "@" + version
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	page, err := io.ReadAll(resp.Body)
+	return string(page), err
+}
+
+// runVerify implements the verify subcommand.
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	against := flags.String("against", "pkgsite", "Reference documentation to compare with; only pkgsite is supported")
+	version := flags.String("version", "", "Published module version to compare with (default: latest)")
+	baseURL := flags.String("pkgsite-url", "https://pkg.go.dev", "Base URL of the pkgsite instance")
+	var filter excludePatterns
+	flags.Var(&filter, "e", "Regex filter for excluding source files; may be repeated or comma-separated")
+	flags.Parse(args)
+	fileFilter, err := extract.GetExcludeFilter(filter...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: godocjson verify [-against pkgsite] [-version v] [-pkgsite-url url] [-e pattern] directory")
+		return 2
+	}
+	if *against != "pkgsite" {
+		fmt.Fprintf(os.Stderr, "unsupported reference %q\n", *against)
+		return 2
+	}
+	directory := flags.Arg(0)
+
+	pkg, err := extract.Extract(directory, extract.Options{Filter: fileFilter, ExcludeTests: true})
+	if err == nil && pkg == nil {
+		err = fmt.Errorf("no Go files in %s", directory)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	importPath, ok := extract.ResolveImportPath(directory)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "cannot determine the import path of %s: it is neither in a module nor in GOPATH\n", directory)
+		return 2
+	}
+	page, err := fetchPkgsite(*baseURL, importPath, *version)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ours, theirs := exportedSymbols(pkg), pkgsiteSymbols(page)
+	var differences []string
+	for key, s := range ours {
+		if s.parent != "" {
+			// Fields and interface methods, see pkgsiteKinds.
+			continue
+		}
+		if !theirs[key] {
+			differences = append(differences, "only in godocjson: "+key)
+		}
+	}
+	for key := range theirs {
+		if _, ok := ours[key]; !ok {
+			differences = append(differences, "only in pkg.go.dev: "+key)
+		}
+	}
+	sort.Strings(differences)
+	for _, d := range differences {
+		fmt.Println(d)
+	}
+	if len(differences) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// WalkRules selects the directories visited below the root of a recursive
+// pattern. By default, they follow the conventions of the go command and
+// skip vendor and testdata directories, and those whose name starts with
+// "." or "_".
+type WalkRules struct {
+	Vendor   bool // include vendor directories
+	Testdata bool // include testdata directories
+	Hidden   bool // include directories starting with "." or "_"
+	// ExcludeDirs, if set, skips the directories whose slash-separated path
+	// relative to the root of the pattern matches, and those below them,
+	// e.g. "internal/generated".
+	ExcludeDirs *regexp.Regexp
+}
+
+// skip reports whether the rules exclude the directory name, and those
+// below it.
+func (r WalkRules) skip(name string) bool {
+	switch {
+	case name == "vendor":
+		return !r.Vendor
+	case name == "testdata":
+		return !r.Testdata
+	case strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_"):
+		return !r.Hidden
+	}
+	return false
+}
+
+// excludes reports whether ExcludeDirs matches the path of directory
+// relative to root.
+func (r WalkRules) excludes(root, directory string) bool {
+	if r.ExcludeDirs == nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, directory)
+	return err == nil && r.ExcludeDirs.MatchString(filepath.ToSlash(rel))
+}
+
+// ExpandDirectories returns the directories named by args. An argument of
+// the form "dir/..." names dir and every directory below it holding .go
+// files, as selected by rules; "..." alone starts from the current
+// directory. When dir holds a go.work file, the pattern names the
+// directories of the modules of the workspace instead, and those below
+// them. Other arguments are returned as is.
+func ExpandDirectories(args []string, rules WalkRules) ([]string, error) {
+	var directories []string
+	for _, arg := range args {
+		root := strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/")
+		if root == arg {
+			directories = append(directories, arg)
+			continue
+		}
+		if root == "" {
+			root = "."
+		}
+		roots, err := workspaceRoots(root)
+		if err != nil {
+			return nil, err
+		}
+		if roots == nil {
+			roots = []string{root}
+		}
+		// Modules nested in the directory of another module of the
+		// workspace are only walked once.
+		isRoot := map[string]bool{}
+		for _, r := range roots {
+			isRoot[filepath.Clean(r)] = true
+		}
+		for _, root := range roots {
+			err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() {
+					return nil
+				}
+				if path != root && (rules.skip(d.Name()) || rules.excludes(root, path) || isRoot[filepath.Clean(path)]) {
+					return filepath.SkipDir
+				}
+				if hasGoFiles(path) {
+					directories = append(directories, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return directories, nil
+}
+
+// hasGoFiles reports whether directory holds .go files.
+func hasGoFiles(directory string) bool {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
+
+// StdlibDirectories returns the directories of the standard library packages
+// named by importPaths in goroot/src. Import paths may end in "/..." to
+// name the packages below them, and "std" names the whole standard library,
+// without the commands of cmd.
+func StdlibDirectories(importPaths []string, goroot string, rules WalkRules) ([]string, error) {
+	src := filepath.Join(goroot, "src")
+	var args []string
+	for _, importPath := range importPaths {
+		if importPath != "std" {
+			directory := filepath.Join(src, filepath.FromSlash(strings.TrimSuffix(importPath, "/...")))
+			if info, err := os.Stat(directory); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("%s is not a standard library package in %s", importPath, src)
+			}
+			args = append(args, filepath.Join(src, filepath.FromSlash(importPath)))
+			continue
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != "cmd" && !rules.skip(entry.Name()) {
+				args = append(args, filepath.Join(src, entry.Name(), "..."))
+			}
+		}
+	}
+	return ExpandDirectories(args, rules)
+}
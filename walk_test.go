@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestExpandDirectories(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"a", "a/gen", "a/gen/deep", "b/internal/gen", "vendor/v", "testdata/d", ".hidden", "_skip", "nogo"} {
+		filename := filepath.Join(root, filepath.FromSlash(dir), "x.go")
+		if dir == "nogo" {
+			filename = filepath.Join(root, dir, "README")
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		rules WalkRules
+		want  []string
+	}{
+		{WalkRules{}, []string{"a", "a/gen", "a/gen/deep", "b/internal/gen"}},
+		{WalkRules{Vendor: true, Testdata: true}, []string{"a", "a/gen", "a/gen/deep", "b/internal/gen", "testdata/d", "vendor/v"}},
+		{WalkRules{Hidden: true}, []string{".hidden", "_skip", "a", "a/gen", "a/gen/deep", "b/internal/gen"}},
+		{WalkRules{ExcludeDirs: regexp.MustCompile(`^a/gen$`)}, []string{"a", "b/internal/gen"}},
+		{WalkRules{ExcludeDirs: regexp.MustCompile(`(^|/)gen$`)}, []string{"a"}},
+		{WalkRules{ExcludeDirs: regexp.MustCompile(`^b`)}, []string{"a", "a/gen", "a/gen/deep"}},
+		{WalkRules{ExcludeDirs: regexp.MustCompile(`^\.$`)}, []string{"a", "a/gen", "a/gen/deep", "b/internal/gen"}},
+	}
+	for _, test := range tests {
+		directories, err := ExpandDirectories([]string{root + "/...", "other"}, test.rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, directory := range directories[:len(directories)-1] {
+			rel, err := filepath.Rel(root, directory)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, filepath.ToSlash(rel))
+		}
+		if !reflect.DeepEqual(got, test.want) || directories[len(directories)-1] != "other" {
+			t.Errorf("%+v: got %q, want %q and other", test.rules, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDelay is how long to wait after the last change to a .go file before
+// documenting again, so that editors saving several files trigger one run.
+const watchDelay = 200 * time.Millisecond
+
+// watchDirectories calls document whenever a .go file in one of the
+// directories is created, written, removed or renamed. It only returns if
+// watching fails.
+func watchDirectories(directories []string, document func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+	for _, directory := range directories {
+		if err := watcher.Add(directory); err != nil {
+			return err
+		}
+	}
+
+	timer := time.NewTimer(watchDelay)
+	timer.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if strings.HasSuffix(event.Name, ".go") && event.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0 {
+				timer.Reset(watchDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Error: %s", err)
+		case <-timer.C:
+			log.Printf("Change detected, documenting %s", strings.Join(directories, " "))
+			document()
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rtfd/godocjson/extract"
+)
+
+// Workspace holds the modules of a go.work file.
+type Workspace struct {
+	GoVersion string             `json:"goVersion,omitempty"` // version of the go directive, e.g. "1.22"
+	Modules   []*WorkspaceModule `json:"modules"`             // in the order of the use directives
+}
+
+// WorkspaceModule is a module used by a workspace.
+type WorkspaceModule struct {
+	Path string `json:"path"` // module path declared in its go.mod
+	Dir  string `json:"dir"`  // directory as written in the use directive, e.g. "./tools"
+
+	root string // directory of the module
+}
+
+// readWorkspace reads the go.work file in directory, or returns nil if
+// there is none.
+func readWorkspace(directory string) (*Workspace, error) {
+	filename := filepath.Join(directory, "go.work")
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ws := &Workspace{Modules: []*WorkspaceModule{}}
+	use := func(dir string) error {
+		dir = strings.Trim(dir, "\"`")
+		root := dir
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(directory, filepath.FromSlash(dir))
+		}
+		modPath := extract.ModulePath(root)
+		if modPath == "" {
+			return fmt.Errorf("%s: no go.mod file in %s", filename, dir)
+		}
+		ws.Modules = append(ws.Modules, &WorkspaceModule{Path: modPath, Dir: dir, root: root})
+		return nil
+	}
+	inUse, inBlock := false, false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if inBlock {
+			if fields[0] == ")" {
+				inUse, inBlock = false, false
+			} else if inUse {
+				if err := use(fields[0]); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		switch {
+		case fields[0] == "go" && len(fields) >= 2:
+			ws.GoVersion = fields[1]
+		case len(fields) == 2 && fields[1] == "(":
+			// Blocks of use, replace and godebug directives.
+			inUse, inBlock = fields[0] == "use", true
+		case fields[0] == "use" && len(fields) >= 2:
+			if err := use(fields[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ws, scanner.Err()
+}
+
+// workspaceRoots returns the directories of the modules of the workspace
+// in directory, to be walked in place of directory, or nil if directory
+// holds no go.work file.
+func workspaceRoots(directory string) ([]string, error) {
+	ws, err := readWorkspace(directory)
+	if ws == nil || err != nil {
+		return nil, err
+	}
+	roots := []string{}
+	for _, m := range ws.Modules {
+		roots = append(roots, m.root)
+	}
+	return roots, nil
+}
+
+// FindWorkspace returns the workspace of the first dir/... pattern of args
+// whose dir holds a go.work file, or nil if there is none.
+func FindWorkspace(args []string) (*Workspace, error) {
+	for _, arg := range args {
+		root := strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/")
+		if root == arg {
+			continue
+		}
+		if root == "" {
+			root = "."
+		}
+		if ws, err := readWorkspace(root); ws != nil || err != nil {
+			return ws, err
+		}
+	}
+	return nil, nil
+}
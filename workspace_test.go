@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles writes files, by slash-separated name, below dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadWorkspace(t *testing.T) {
+	modules := map[string]string{
+		"a/go.mod":       "module example.com/a\n",
+		"b/go.mod":       "module example.com/b\n",
+		"a/tools/go.mod": "module example.com/a/tools\n",
+	}
+	tests := []struct {
+		name, goWork string
+		goVersion    string
+		modules      []string // "path dir"
+		err          bool
+	}{
+		{"single", "go 1.22\n\nuse ./a\n", "1.22", []string{"example.com/a ./a"}, false},
+		{"block", "go 1.23\n\nuse (\n\t./a // app\n\t\"./b\"\n\t./a/tools\n)\n", "1.23", []string{"example.com/a ./a", "example.com/b ./b", "example.com/a/tools ./a/tools"}, false},
+		{"replace", "use ./b\n\nreplace (\n\texample.com/x => ./x\n)\n", "", []string{"example.com/b ./b"}, false},
+		{"missing", "use ./c\n", "", nil, true},
+	}
+	for _, test := range tests {
+		dir := t.TempDir()
+		writeFiles(t, dir, modules)
+		writeFiles(t, dir, map[string]string{"go.work": test.goWork})
+		ws, err := readWorkspace(dir)
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v", test.name, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var got []string
+		for _, m := range ws.Modules {
+			got = append(got, m.Path+" "+m.Dir)
+		}
+		if ws.GoVersion != test.goVersion || !reflect.DeepEqual(got, test.modules) {
+			t.Errorf("%s: got go %q and modules %q, want go %q and modules %q", test.name, ws.GoVersion, got, test.goVersion, test.modules)
+		}
+	}
+	if ws, err := readWorkspace(t.TempDir()); ws != nil || err != nil {
+		t.Errorf("got workspace %v and error %v without go.work", ws, err)
+	}
+}
+
+func TestExpandWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.work":          "use (\n\t./a\n\t./a/tools\n)\n",
+		"a/go.mod":         "module example.com/a\n",
+		"a/a.go":           "package a\n",
+		"a/tools/go.mod":   "module example.com/a/tools\n",
+		"a/tools/t.go":     "package tools\n",
+		"unused/unused.go": "package unused\n",
+	})
+	directories, err := ExpandDirectories([]string{dir + "/..."}, WalkRules{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a"), filepath.Join(dir, "a", "tools")}
+	if !reflect.DeepEqual(directories, want) {
+		t.Errorf("got directories %q, want %q", directories, want)
+	}
+	ws, err := FindWorkspace([]string{"p", dir + "/..."})
+	if err != nil || ws == nil || len(ws.Modules) != 2 {
+		t.Errorf("got workspace %+v and error %v", ws, err)
+	}
+}